@@ -0,0 +1,613 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/cookieimport"
+	"github.com/nileshnk/reddit-migrate/internal/jobs"
+	"github.com/nileshnk/reddit-migrate/internal/journal"
+	"github.com/nileshnk/reddit-migrate/internal/migration"
+	"github.com/nileshnk/reddit-migrate/internal/reddit"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+)
+
+// Exit codes returned by the CLI subcommands.
+const (
+	exitOK        = 0
+	exitFailure   = 1   // The operation ran but did not fully succeed, or could not run at all
+	exitUsage     = 2   // Invalid command line
+	exitAuth      = 3   // An account could not be authenticated
+	exitCancelled = 130 // Interrupted with Ctrl-C or SIGTERM
+)
+
+// printUsage writes the top-level help text.
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, `Usage: reddit-migrate [command] [flags]
+
+Commands:
+  serve     Start the web interface (default when no command is given)
+  migrate   Migrate subreddits, followed users and saved posts and comments between two accounts
+  export    Write an account's subreddits, followed users, saved items and custom feeds to a JSON file
+  import    Subscribe and save the contents of an export file on an account
+  resume    Continue an interrupted or partially failed migrate or import job from its journal
+  profile   List, add or remove saved account profiles in the encrypted vault
+  version   Print the version
+  help      Show this help
+
+Token files contain either an OAuth access token, a full Reddit cookie string (with token_v2), or a browser
+cookie export (cookies.txt, HAR or a copy of Firefox's cookies.sqlite) from which the reddit.com cookies are read.
+Instead of a token file, an account can be given by the name of a saved profile; set VAULT_PASSPHRASE to unlock the vault.
+Export files do not include votes, hidden posts, blocked users or preferences; only migrate copies those.
+Run "reddit-migrate <command> -h" for the flags of a command.
+
+Exit codes: %d success, %d failed or partially failed, %d usage error, %d authentication error, %d interrupted.
+`, exitOK, exitFailure, exitUsage, exitAuth, exitCancelled)
+}
+
+// parseErrorExit maps a flag parsing error to an exit code; asking for help with -h is not an error.
+func parseErrorExit(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	return exitUsage
+}
+
+// setupCLILogging routes all logging to stderr when verbose is set and discards it otherwise,
+// keeping stdout free for command output, then loads the configuration.
+func setupCLILogging(verbose bool) {
+	var out io.Writer = io.Discard
+	if verbose {
+		out = os.Stderr
+	}
+	config.InfoLogger = log.New(out, "INFO: ", log.Ldate|log.Ltime|log.Lmicroseconds)
+	config.ErrorLogger = log.New(out, "ERROR: ", log.Ldate|log.Ltime|log.Lmicroseconds)
+	config.DebugLogger = log.New(out, "DEBUG: ", log.Ldate|log.Ltime|log.Lmicroseconds)
+	config.LoadConfig()
+}
+
+// cliAccount is an authenticated account resolved from a token file.
+type cliAccount struct {
+	token    string
+	username string
+}
+
+// loadAccount reads a token file and verifies the account it belongs to.
+// The file may hold an OAuth access token, a cookie string containing token_v2, or a browser cookie export
+// (cookies.txt, HAR or Firefox cookies.sqlite) holding the reddit.com cookies.
+func loadAccount(label, path string) (cliAccount, error) {
+	secret, err := readSecretFile(label+" account token", path)
+	if err != nil {
+		return cliAccount{}, err
+	}
+
+	authMethod, cookie, token := "oauth", "", secret
+	if strings.Contains(secret, "token_v2=") {
+		authMethod, cookie, token = "cookie", secret, ""
+	}
+	token, username, err := migration.ResolveAccount(label, "", authMethod, cookie, token, "")
+	if err != nil {
+		return cliAccount{}, err
+	}
+	return cliAccount{token: token, username: username}, nil
+}
+
+// readSecretFile reads a file holding a single secret, such as a cookie string or a refresh token.
+// Browser cookie exports are recognized and replaced by their reddit.com cookies.
+func readSecretFile(what, path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s file: %w", what, err)
+	}
+	cookie, format, err := cookieimport.RedditCookieHeader(content)
+	if err == nil {
+		config.DebugLogger.Printf("Read reddit.com cookies from %s export %s", format, path)
+		return cookie, nil
+	}
+	if !errors.Is(err, cookieimport.ErrUnknownFormat) {
+		return "", fmt.Errorf("error reading cookies from %s: %w", path, err)
+	}
+	secret := strings.TrimSpace(string(content))
+	if secret == "" {
+		return "", fmt.Errorf("%s file %s is empty", what, path)
+	}
+	return secret, nil
+}
+
+// runJob runs fn as a background job and waits for it to finish.
+// Progress is printed to stderr when showProgress is set. Ctrl-C cancels the job, which then stops after its in-flight requests.
+func runJob(kind string, fn jobs.RunFunc, showProgress bool) (types.JobInfo, error) {
+	job, err := jobs.DefaultManager.Submit(kind, fn)
+	if err != nil {
+		return types.JobInfo{}, err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	seq := 0
+	for {
+		events, changed := job.EventsSince(seq)
+		for _, event := range events {
+			seq = event.Seq
+			if showProgress {
+				printProgress(event)
+			}
+			if event.Type == types.DoneEvent {
+				return job.Info(), nil
+			}
+		}
+		select {
+		case <-changed:
+		case <-signals:
+			fmt.Fprintln(os.Stderr, "Interrupted, stopping after in-flight requests...")
+			if err := jobs.DefaultManager.Cancel(job.ID); err != nil && !errors.Is(err, jobs.ErrJobFinished) {
+				return job.Info(), err
+			}
+		}
+	}
+}
+
+// printProgress writes a human-readable line for the progress events worth showing in a terminal.
+func printProgress(event types.ProgressEvent) {
+	switch event.Type {
+	case types.PhaseEvent:
+		if event.Total > 0 {
+			fmt.Fprintf(os.Stderr, "==> %s (%d)\n", event.Phase, event.Total)
+		} else {
+			fmt.Fprintf(os.Stderr, "==> %s\n", event.Phase)
+		}
+	case types.SubredditChunkEvent:
+		fmt.Fprintf(os.Stderr, "    %d/%d subreddits\n", event.Completed, event.Total)
+		for _, item := range event.FailedItems {
+			fmt.Fprintf(os.Stderr, "    failed: %s\n", item)
+		}
+	case types.UserEvent, types.PostEvent:
+		if !event.Success {
+			fmt.Fprintf(os.Stderr, "    failed: %s %s\n", event.Item, event.Error)
+		}
+	case types.RateLimitPauseEvent:
+		fmt.Fprintf(os.Stderr, "    %s\n", event.Message)
+	case types.RateLimitResumeEvent:
+		fmt.Fprintln(os.Stderr, "    Resumed after rate limit")
+	}
+}
+
+// finishJob prints the job result as JSON or as a human summary and returns the exit code for it.
+func finishJob(info types.JobInfo, asJSON bool) int {
+	if info.Result == nil {
+		fmt.Fprintf(os.Stderr, "Job %s finished with status %s and no result.\n", info.ID, info.Status)
+		return exitFailure
+	}
+	result := *info.Result
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding result: %v\n", err)
+			return exitFailure
+		}
+	} else {
+		printResult(result)
+	}
+
+	switch {
+	case info.Status == types.JobCancelled:
+		return exitCancelled
+	case !result.Success:
+		return exitFailure
+	default:
+		return exitOK
+	}
+}
+
+// printResult writes a human summary of a migration result to stdout.
+func printResult(result types.MigrationResponseType) {
+	fmt.Println(result.Message)
+	data := result.Data
+
+	if plan := data.Plan; plan != nil {
+		printPlanSection("Subreddits to subscribe", plan.SubredditsToSubscribe)
+		printPlanSection("Subreddits already present", plan.SubredditsAlreadyPresent)
+		printPlanSection("Subreddits to unsubscribe from old account", plan.SubredditsToUnsubscribe)
+		printPlanSection("Users to follow", plan.UsersToFollow)
+		printPlanSection("Users already followed", plan.UsersAlreadyFollowed)
+		printPlanSection("Posts to save", plan.PostsToSave)
+		printPlanSection("Posts already saved", plan.PostsAlreadySaved)
+		printPlanSection("Posts to unsave from old account", plan.PostsToUnsave)
+		printPlanSection("Posts to upvote", plan.PostsToUpvote)
+		printPlanSection("Posts already upvoted", plan.PostsAlreadyUpvoted)
+		printPlanSection("Posts to downvote", plan.PostsToDownvote)
+		printPlanSection("Posts already downvoted", plan.PostsAlreadyDownvoted)
+		printPlanSection("Posts to hide", plan.PostsToHide)
+		printPlanSection("Posts already hidden", plan.PostsAlreadyHidden)
+		printPlanSection("Posts to unhide on old account", plan.PostsToUnhide)
+		printPlanSection("Custom feeds to create", plan.MultiredditsToCreate)
+		printPlanSection("Custom feeds to merge", plan.MultiredditsToMerge)
+		printPlanSection("Custom feeds already present", plan.MultiredditsAlreadyPresent)
+		printPlanSection("Users to block", plan.UsersToBlock)
+		printPlanSection("Users already blocked", plan.UsersAlreadyBlocked)
+		fmt.Printf("Preferences to change: %d\n", len(plan.PrefChanges))
+		for _, change := range plan.PrefChanges {
+			fmt.Printf("  %s: %v -> %v\n", change.Key, change.Before, change.After)
+		}
+		printPlanSection("Preferences unchanged", plan.PrefsUnchanged)
+		return
+	}
+
+	fmt.Printf("Subreddits subscribed:   %d (failed %d)\n", data.SubscribeSubreddit.SuccessCount, data.SubscribeSubreddit.FailedCount)
+	fmt.Printf("Subreddits unsubscribed: %d (failed %d)\n", data.UnsubscribeSubreddit.SuccessCount, data.UnsubscribeSubreddit.FailedCount)
+	fmt.Printf("Posts saved:             %d (failed %d)\n", data.SavePost.SuccessCount, data.SavePost.FailedCount)
+	fmt.Printf("Posts unsaved:           %d (failed %d)\n", data.UnsavePost.SuccessCount, data.UnsavePost.FailedCount)
+	fmt.Printf("Posts upvoted:           %d (failed %d)\n", data.UpvotePost.SuccessCount, data.UpvotePost.FailedCount)
+	fmt.Printf("Posts downvoted:         %d (failed %d)\n", data.DownvotePost.SuccessCount, data.DownvotePost.FailedCount)
+	fmt.Printf("Posts hidden:            %d (failed %d)\n", data.HidePost.SuccessCount, data.HidePost.FailedCount)
+	fmt.Printf("Posts unhidden:          %d (failed %d)\n", data.UnhidePost.SuccessCount, data.UnhidePost.FailedCount)
+	fmt.Printf("Custom feeds copied:     %d created, %d merged (failed %d)\n", data.Multireddits.CreatedCount, data.Multireddits.MergedCount, data.Multireddits.FailedCount)
+	fmt.Printf("Users blocked:           %d (failed %d)\n", data.BlockUser.SuccessCount, data.BlockUser.FailedCount)
+	fmt.Printf("Preferences changed:     %d\n", len(data.Prefs.Changes))
+	for _, change := range data.Prefs.Changes {
+		fmt.Printf("  %s: %v -> %v\n", change.Key, change.Before, change.After)
+	}
+}
+
+// printPlanSection prints one list of a dry-run plan.
+func printPlanSection(title string, items []string) {
+	fmt.Printf("%s: %d\n", title, len(items))
+	for _, item := range items {
+		fmt.Printf("  %s\n", item)
+	}
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// migrateCommand implements "reddit-migrate migrate".
+func migrateCommand(args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fromFile := flags.String("from-token-file", "", "file with the old account's OAuth token or cookie")
+	toFile := flags.String("to-token-file", "", "file with the new account's OAuth token or cookie")
+	fromProfile := flags.String("from-profile", "", "saved profile of the old account, instead of --from-token-file")
+	toProfile := flags.String("to-profile", "", "saved profile of the new account, instead of --to-token-file")
+	subreddits := flags.Bool("subreddits", false, "migrate subscribed subreddits and followed users")
+	posts := flags.Bool("posts", false, "migrate saved posts and comments")
+	deleteSubreddits := flags.Bool("delete-old-subreddits", false, "unsubscribe the old account from its subreddits")
+	deletePosts := flags.Bool("delete-old-posts", false, "unsave migrated posts on the old account")
+	upvotes := flags.Bool("upvotes", false, "re-cast the old account's upvotes on the new account")
+	downvotes := flags.Bool("downvotes", false, "re-cast the old account's downvotes on the new account")
+	hidden := flags.Bool("hidden", false, "hide the old account's hidden posts on the new account")
+	unhideOld := flags.Bool("unhide-old", false, "unhide migrated hidden posts on the old account")
+	multireddits := flags.Bool("multireddits", false, "recreate the old account's custom feeds on the new account")
+	blocked := flags.Bool("blocked", false, "block the old account's blocked users on the new account")
+	prefs := flags.Bool("prefs", false, "copy account preferences to the new account")
+	prefKeys := flags.String("pref-keys", "", "comma-separated preference keys to copy with --prefs (default: display, sorting and email settings)")
+	preserveOrder := flags.Bool("preserve-order", false, "save posts one at a time, oldest first, so the saved list keeps its order (slower)")
+	dryRun := flags.Bool("dry-run", false, "only print the plan; do not modify either account")
+	asJSON := flags.Bool("json", false, "print the result as JSON")
+	verbose := flags.Bool("verbose", false, "write logs to stderr")
+	if err := flags.Parse(args); err != nil {
+		return parseErrorExit(err)
+	}
+	if (*fromFile == "") == (*fromProfile == "") || (*toFile == "") == (*toProfile == "") {
+		fmt.Fprintln(os.Stderr, "Give each account with either a token file or a profile: --from-token-file or --from-profile, and --to-token-file or --to-profile.")
+		return exitUsage
+	}
+	if !*subreddits && !*posts && !*deleteSubreddits && !*deletePosts && !*upvotes && !*downvotes && !*hidden && !*unhideOld && !*multireddits && !*blocked && !*prefs {
+		fmt.Fprintln(os.Stderr, "Nothing to do: pass --subreddits, --posts, --upvotes, --downvotes, --hidden, --multireddits, --blocked and/or --prefs.")
+		return exitUsage
+	}
+
+	setupCLILogging(*verbose)
+
+	oldAccount, err := loadAccountFrom("old", *fromProfile, *fromFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitAuth
+	}
+	newAccount, err := loadAccountFrom("new", *toProfile, *toFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitAuth
+	}
+	if !*asJSON {
+		fmt.Fprintf(os.Stderr, "Migrating %s -> %s\n", oldAccount.username, newAccount.username)
+	}
+
+	// Both accounts are already verified, so the engine gets the resolved tokens and usernames directly.
+	req := types.MigrationRequestType{
+		AuthMethod:         "oauth",
+		OldAccountToken:    oldAccount.token,
+		NewAccountToken:    newAccount.token,
+		OldAccountUsername: oldAccount.username,
+		NewAccountUsername: newAccount.username,
+		Preferences: types.PreferencesType{
+			MigrateSubredditBool:    *subreddits,
+			MigratePostBool:         *posts,
+			DeleteSubredditBool:     *deleteSubreddits,
+			DeletePostBool:          *deletePosts,
+			MigrateUpvotesBool:      *upvotes,
+			MigrateDownvotesBool:    *downvotes,
+			MigrateHiddenBool:       *hidden,
+			UnhideOldBool:           *unhideOld,
+			MigrateMultiredditsBool: *multireddits,
+			MigrateBlockedBool:      *blocked,
+			MigratePrefsBool:        *prefs,
+			PrefKeys:                splitList(*prefKeys),
+			PreserveOrderBool:       *preserveOrder,
+			DryRun:                  *dryRun,
+		},
+	}
+	info, err := runJob("migrate", func(ctx context.Context, jobID string) types.MigrationResponseType {
+		return migration.RunMigration(ctx, jobID, req)
+	}, !*asJSON)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error running migration: %v\n", err)
+		return exitFailure
+	}
+	if !*asJSON && !*dryRun {
+		printResumeHint(info, info.ID)
+	}
+	return finishJob(info, *asJSON)
+}
+
+// exportCommand implements "reddit-migrate export".
+func exportCommand(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: reddit-migrate export [flags]")
+		fmt.Fprintln(flags.Output(), "Writes the subreddits, followed users, saved posts and comments and custom feeds of an account.")
+		fmt.Fprintln(flags.Output(), "Votes, hidden posts, blocked users and preferences are not exported; use migrate to copy them.")
+		flags.PrintDefaults()
+	}
+	tokenFile := flags.String("token-file", "", "file with the account's OAuth token or cookie")
+	profile := flags.String("profile", "", "saved profile of the account, instead of --token-file")
+	output := flags.String("output", "", "file to write the export to (default stdout)")
+	verbose := flags.Bool("verbose", false, "write logs to stderr")
+	if err := flags.Parse(args); err != nil {
+		return parseErrorExit(err)
+	}
+	if (*tokenFile == "") == (*profile == "") {
+		fmt.Fprintln(os.Stderr, "Either --token-file or --profile is required.")
+		return exitUsage
+	}
+
+	setupCLILogging(*verbose)
+
+	account, err := loadAccountFrom("source", *profile, *tokenFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitAuth
+	}
+
+	archive, err := reddit.ExportAccount(account.token, account.username)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error exporting account: %v\n", err)
+		return exitFailure
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		file, err := os.OpenFile(*output, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating %s: %v\n", *output, err)
+			return exitFailure
+		}
+		defer file.Close()
+		out = file
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(archive); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing export: %v\n", err)
+		return exitFailure
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d subreddits, %d followed users, %d saved posts, %d saved comments and %d custom feeds of %s.\n",
+		len(archive.Subreddits), len(archive.FollowedUsers), len(archive.SavedPosts), len(archive.SavedComments), len(archive.Multireddits), account.username)
+	return exitOK
+}
+
+// importCommand implements "reddit-migrate import".
+// The whole archive is imported; items already present on the target account are skipped.
+func importCommand(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	tokenFile := flags.String("token-file", "", "file with the target account's OAuth token or cookie")
+	profile := flags.String("profile", "", "saved profile of the target account, instead of --token-file")
+	input := flags.String("input", "", "export file to import (required)")
+	subreddits := flags.Bool("subreddits", false, "subscribe to the exported subreddits and follow the exported users")
+	posts := flags.Bool("posts", false, "save the exported posts and comments")
+	multireddits := flags.Bool("multireddits", false, "recreate the exported custom feeds")
+	preserveOrder := flags.Bool("preserve-order", false, "save posts one at a time, oldest first, so the saved list keeps its order (slower)")
+	dryRun := flags.Bool("dry-run", false, "only print what would be imported")
+	asJSON := flags.Bool("json", false, "print the result as JSON")
+	verbose := flags.Bool("verbose", false, "write logs to stderr")
+	if err := flags.Parse(args); err != nil {
+		return parseErrorExit(err)
+	}
+	if (*tokenFile == "") == (*profile == "") || *input == "" {
+		fmt.Fprintln(os.Stderr, "--input and either --token-file or --profile are required.")
+		return exitUsage
+	}
+	if !*subreddits && !*posts && !*multireddits {
+		fmt.Fprintln(os.Stderr, "Nothing to do: pass --subreddits, --posts and/or --multireddits.")
+		return exitUsage
+	}
+
+	setupCLILogging(*verbose)
+
+	content, err := os.ReadFile(*input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", *input, err)
+		return exitFailure
+	}
+	var archive types.AccountArchive
+	if err := json.Unmarshal(content, &archive); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", *input, err)
+		return exitFailure
+	}
+	if err := migration.ValidateArchive(archive); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", *input, err)
+		return exitFailure
+	}
+
+	account, err := loadAccountFrom("target", *profile, *tokenFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitAuth
+	}
+
+	req := types.ImportRequest{
+		AuthMethod:    "oauth",
+		AccessToken:   account.token,
+		Username:      account.username,
+		Archive:       archive,
+		PreserveOrder: *preserveOrder,
+		DryRun:        *dryRun,
+	}
+	if *subreddits {
+		for _, subreddit := range archive.Subreddits {
+			req.SelectedSubreddits = append(req.SelectedSubreddits, subreddit.DisplayName)
+		}
+		req.SelectedUsers = archive.FollowedUsers
+	}
+	if *posts {
+		req.SelectedPosts = reddit.SavedItemFullNames(archive.SavedPosts, archive.SavedComments)
+	}
+	if *multireddits {
+		for _, multi := range archive.Multireddits {
+			req.SelectedMultireddits = append(req.SelectedMultireddits, multi.Name)
+		}
+	}
+
+	info, err := runJob("import", func(ctx context.Context, jobID string) types.MigrationResponseType {
+		return migration.ImportArchive(ctx, jobID, req)
+	}, !*asJSON)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error running import: %v\n", err)
+		return exitFailure
+	}
+	if !*asJSON && !*dryRun {
+		printResumeHint(info, info.ID)
+	}
+	return finishJob(info, *asJSON)
+}
+
+// resumeCommand implements "reddit-migrate resume <job-id>".
+// It continues a migrate or import job from its journal in JOURNAL_DIR, processing only the items still pending or
+// failed. Credentials are not journaled, so the accounts are given again and must be the ones the job ran against.
+func resumeCommand(args []string) int {
+	flags := flag.NewFlagSet("resume", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: reddit-migrate resume <job-id> [flags]")
+		flags.PrintDefaults()
+	}
+	fromFile := flags.String("from-token-file", "", "file with the old account's OAuth token or cookie (not needed for import jobs)")
+	toFile := flags.String("to-token-file", "", "file with the new account's OAuth token or cookie")
+	fromProfile := flags.String("from-profile", "", "saved profile of the old account, instead of --from-token-file")
+	toProfile := flags.String("to-profile", "", "saved profile of the new account, instead of --to-token-file")
+	asJSON := flags.Bool("json", false, "print the result as JSON")
+	verbose := flags.Bool("verbose", false, "write logs to stderr")
+
+	// The job ID may come before or after the flags.
+	var jobID string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		jobID, args = args[0], args[1:]
+	}
+	if err := flags.Parse(args); err != nil {
+		return parseErrorExit(err)
+	}
+	rest := flags.Args()
+	if jobID == "" && len(rest) > 0 {
+		jobID, rest = rest[0], rest[1:]
+	}
+	if jobID == "" || len(rest) > 0 {
+		flags.Usage()
+		return exitUsage
+	}
+	if (*toFile == "") == (*toProfile == "") || (*fromFile != "" && *fromProfile != "") {
+		fmt.Fprintln(os.Stderr, "Give each account with either a token file or a profile: --from-token-file or --from-profile, and --to-token-file or --to-profile.")
+		return exitUsage
+	}
+
+	setupCLILogging(*verbose)
+
+	jrnl, err := journal.Open(config.JournalDir, jobID)
+	switch {
+	case errors.Is(err, journal.ErrNotFound):
+		fmt.Fprintf(os.Stderr, "No journal found for job %s in %s.\n", jobID, config.JournalDir)
+		return exitFailure
+	case errors.Is(err, journal.ErrInUse):
+		fmt.Fprintf(os.Stderr, "Job %s is still running or already being resumed.\n", jobID)
+		return exitFailure
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Error opening journal of job %s: %v\n", jobID, err)
+		return exitFailure
+	}
+	defer jrnl.Close()
+
+	// Imports only ever touch the new account.
+	req := types.ResumeMigrationRequest{AuthMethod: "oauth"}
+	if jrnl.Header().Kind != "import" {
+		if (*fromFile == "") == (*fromProfile == "") {
+			fmt.Fprintf(os.Stderr, "Job %s is a migration: give the old account with --from-token-file or --from-profile.\n", jobID)
+			return exitUsage
+		}
+		oldAccount, err := loadAccountFrom("old", *fromProfile, *fromFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitAuth
+		}
+		req.OldAccountToken, req.OldAccountUsername = oldAccount.token, oldAccount.username
+	}
+	newAccount, err := loadAccountFrom("new", *toProfile, *toFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitAuth
+	}
+	req.NewAccountToken, req.NewAccountUsername = newAccount.token, newAccount.username
+
+	if !*asJSON {
+		header := jrnl.Header()
+		fmt.Fprintf(os.Stderr, "Resuming %s job %s (%s -> %s)\n", header.Kind, jobID, header.OldUsername, header.NewUsername)
+	}
+	info, err := runJob("resume", func(ctx context.Context, _ string) types.MigrationResponseType {
+		return migration.ResumeMigration(ctx, jrnl, req)
+	}, !*asJSON)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resuming job %s: %v\n", jobID, err)
+		return exitFailure
+	}
+	if !*asJSON {
+		printResumeHint(info, jobID)
+	}
+	return finishJob(info, *asJSON)
+}
+
+// printResumeHint tells how to continue the job whose journal is journalID when the job info shows it did not
+// finish cleanly.
+func printResumeHint(info types.JobInfo, journalID string) {
+	if info.Status == types.JobCompleted && info.Result != nil && info.Result.Success {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Job ID %s: run \"reddit-migrate resume %s\" with the same accounts to retry what is left.\n", journalID, journalID)
+}
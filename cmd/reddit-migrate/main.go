@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"net"
@@ -12,7 +13,10 @@ import (
 	"strings"
 
 	"github.com/nileshnk/reddit-migrate/internal/api"
+	"github.com/nileshnk/reddit-migrate/internal/auth"
 	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/vault"
+	"github.com/nileshnk/reddit-migrate/internal/websession"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -21,21 +25,88 @@ import (
 // Version is the application version, injected at build time.
 var Version = "dev" // Default to "dev" if not built with version info
 
-// DefaultAddress is the address the server will listen on if no other address is specified.
-
 func main() {
 	// Initialize loggers
 	config.InfoLogger = log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime|log.Lmicroseconds)
 	config.ErrorLogger = log.New(os.Stderr, "ERROR: ", log.Ldate|log.Ltime|log.Lmicroseconds)
 	config.DebugLogger = log.New(os.Stdout, "DEBUG: ", log.Ldate|log.Ltime|log.Lmicroseconds)
 
+	// Without a subcommand (or with only flags such as --addr=) the binary starts the web server, as it always has.
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "serve":
+		os.Exit(serveCommand(args))
+	case "migrate":
+		os.Exit(migrateCommand(args))
+	case "export":
+		os.Exit(exportCommand(args))
+	case "import":
+		os.Exit(importCommand(args))
+	case "resume":
+		os.Exit(resumeCommand(args))
+	case "profile":
+		os.Exit(profileCommand(args))
+	case "version":
+		fmt.Println(Version)
+	case "help":
+		printUsage(os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q.\n\n", command)
+		printUsage(os.Stderr)
+		os.Exit(exitUsage)
+	}
+}
+
+// serveCommand starts the web server and opens the UI in the default browser.
+func serveCommand(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addrFlag := flags.String("addr", "", "address to listen on (default "+config.DefaultAddress+", or GO_ADDR)")
+	noBrowser := flags.Bool("no-browser", false, "do not open the UI in a browser")
+	if err := flags.Parse(args); err != nil {
+		return parseErrorExit(err)
+	}
+
+	config.LoadConfig()
 	config.InfoLogger.Printf("Application version: %s", Version) // Print the version
 
+	if *addrFlag != "" {
+		config.ServerAddress = *addrFlag
+		config.RedditOauthRedirectUri = fmt.Sprintf("http://%s/api/oauth/callback", *addrFlag)
+	}
+
+	// With VAULT_PASSPHRASE set, saved profiles can be used without unlocking the vault in the UI first.
+	if config.VaultPassphrase != "" {
+		if _, err := vault.Unlock(config.VaultPassphrase); err != nil {
+			config.ErrorLogger.Printf("Could not unlock the credential vault at %s: %v", config.VaultPath, err)
+		}
+	}
+
+	if config.OAuthSessionFile != "" {
+		store, err := auth.NewFileSessionStore(config.OAuthSessionFile, auth.OAuthSessionTTL)
+		if err != nil {
+			config.ErrorLogger.Printf("Could not open the OAuth session file, keeping sessions in memory: %v", err)
+		} else {
+			auth.DefaultSessionStore = store
+		}
+	}
+
+	// Only the browser opened with the startup access token may use the UI and the API.
+	guard, err := websession.NewGuard(config.ServerAddress, config.AllowedHosts)
+	if err != nil {
+		config.ErrorLogger.Printf("Could not generate the access token: %v", err)
+		return exitFailure
+	}
+
 	// Create a new Chi router.
 	router := chi.NewRouter()
 
 	// Use a logger middleware for HTTP requests.
 	router.Use(middleware.Logger)
+	router.Use(guard.Middleware)
 
 	// Register routes for the main application.
 	router.Route("/", mainRouter)
@@ -46,15 +117,18 @@ func main() {
 	// Start listening on the specified address.
 	listener, err := net.Listen("tcp", addr)
 	if err != nil {
-		config.ErrorLogger.Fatalf("Could not start the application. Check if port is available: %v", err)
+		config.ErrorLogger.Printf("Could not start the application. Check if port is available: %v", err)
+		return exitFailure
 	}
 
-	// Construct the URL for browser opening.
-	urlAddr := constructURL(addr)
+	// Construct the URL for browser opening. It carries the access token, so it is only shown in the terminal.
+	urlAddr := guard.URL(constructURL(addr))
 	config.InfoLogger.Printf("Application is attempting to run on %s", urlAddr)
 
 	// Attempt to open the URL in the default browser.
-	if err := openInBrowser(urlAddr); err != nil {
+	if *noBrowser {
+		config.InfoLogger.Printf("Application is running on %s 🚀", urlAddr)
+	} else if err := openInBrowser(urlAddr); err != nil {
 		config.ErrorLogger.Printf("Failed to open URL in browser: %v. Please open it manually.", err)
 	} else {
 		config.InfoLogger.Printf("Application is running on %s 🚀", urlAddr)
@@ -63,8 +137,10 @@ func main() {
 	// Start the HTTP server.
 	config.InfoLogger.Printf("Starting server on %s", addr)
 	if err := http.Serve(listener, router); err != nil {
-		config.ErrorLogger.Fatalf("Error while serving the application: %v", err)
+		config.ErrorLogger.Printf("Error while serving the application: %v", err)
+		return exitFailure
 	}
+	return exitOK
 }
 
 // constructURL creates a full HTTP URL from an address string.
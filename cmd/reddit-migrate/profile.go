@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nileshnk/reddit-migrate/internal/auth"
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/vault"
+)
+
+// unlockVault unlocks the credential vault with VAULT_PASSPHRASE. The passphrase is never taken from a flag,
+// so it does not end up in shell history or process listings.
+func unlockVault() (*vault.Vault, error) {
+	if config.VaultPassphrase == "" {
+		return nil, errors.New("set VAULT_PASSPHRASE to use saved profiles")
+	}
+	return vault.Unlock(config.VaultPassphrase)
+}
+
+// loadProfile logs in with a saved profile of the vault.
+func loadProfile(label, name string) (cliAccount, error) {
+	if _, err := unlockVault(); err != nil {
+		return cliAccount{}, err
+	}
+	token, username, err := auth.LoginProfile(context.Background(), name)
+	if err != nil {
+		return cliAccount{}, fmt.Errorf("error logging in to %s account with profile %s: %w", label, name, err)
+	}
+	return cliAccount{token: token, username: username}, nil
+}
+
+// loadAccountFrom resolves an account from a saved profile when profile is set and from a token file otherwise.
+func loadAccountFrom(label, profile, tokenFile string) (cliAccount, error) {
+	if profile != "" {
+		return loadProfile(label, profile)
+	}
+	return loadAccount(label, tokenFile)
+}
+
+// profileCommand implements "reddit-migrate profile", which manages the saved account profiles of the vault.
+func profileCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: reddit-migrate profile list|add|remove [flags]")
+		return exitUsage
+	}
+	switch args[0] {
+	case "list":
+		return profileListCommand(args[1:])
+	case "add":
+		return profileAddCommand(args[1:])
+	case "remove":
+		return profileRemoveCommand(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown profile command %q. Use list, add or remove.\n", args[0])
+		return exitUsage
+	}
+}
+
+// profileListCommand implements "reddit-migrate profile list".
+func profileListCommand(args []string) int {
+	flags := flag.NewFlagSet("profile list", flag.ContinueOnError)
+	verbose := flags.Bool("verbose", false, "write logs to stderr")
+	if err := flags.Parse(args); err != nil {
+		return parseErrorExit(err)
+	}
+
+	setupCLILogging(*verbose)
+
+	v, err := unlockVault()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitAuth
+	}
+	profiles := v.Profiles()
+	if len(profiles) == 0 {
+		fmt.Fprintf(os.Stderr, "No profiles saved in %s.\n", v.Path())
+		return exitOK
+	}
+	for _, profile := range profiles {
+		fmt.Printf("%-24s %-24s %-6s %s\n", profile.Name, profile.Username, profile.AuthMethod, profile.UpdatedAt.Format("2006-01-02 15:04"))
+	}
+	return exitOK
+}
+
+// profileAddCommand implements "reddit-migrate profile add".
+// The credentials are checked against Reddit before the profile is saved.
+func profileAddCommand(args []string) int {
+	flags := flag.NewFlagSet("profile add", flag.ContinueOnError)
+	name := flags.String("name", "", "name of the profile (required)")
+	cookieFile := flags.String("cookie-file", "", "file with the account's full cookie string or a cookies.txt, HAR or Firefox cookies.sqlite export")
+	clientID := flags.String("client-id", "", "client ID of the Reddit app that issued the refresh token")
+	clientSecret := flags.String("client-secret", "", "client secret of the Reddit app (empty for installed apps)")
+	refreshTokenFile := flags.String("refresh-token-file", "", "file with an OAuth refresh token")
+	verbose := flags.Bool("verbose", false, "write logs to stderr")
+	if err := flags.Parse(args); err != nil {
+		return parseErrorExit(err)
+	}
+	if *name == "" {
+		fmt.Fprintln(os.Stderr, "--name is required.")
+		return exitUsage
+	}
+	if (*cookieFile == "") == (*refreshTokenFile == "") {
+		fmt.Fprintln(os.Stderr, "Pass either --cookie-file or --client-id and --refresh-token-file.")
+		return exitUsage
+	}
+	if *refreshTokenFile != "" && *clientID == "" {
+		fmt.Fprintln(os.Stderr, "--client-id is required with --refresh-token-file.")
+		return exitUsage
+	}
+
+	setupCLILogging(*verbose)
+
+	v, err := unlockVault()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitAuth
+	}
+
+	var profile vault.Profile
+	if *cookieFile != "" {
+		cookie, err := readSecretFile("cookie", *cookieFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		profile, err = auth.ProfileFromCookie(*name, cookie)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitAuth
+		}
+	} else {
+		refreshToken, err := readSecretFile("refresh token", *refreshTokenFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		profile, err = auth.ProfileFromRefreshToken(context.Background(), *name, *clientID, *clientSecret, refreshToken)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitAuth
+		}
+	}
+
+	if err := v.Put(profile); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	fmt.Fprintf(os.Stderr, "Saved profile %s for %s in %s.\n", profile.Name, profile.Username, v.Path())
+	return exitOK
+}
+
+// profileRemoveCommand implements "reddit-migrate profile remove".
+func profileRemoveCommand(args []string) int {
+	flags := flag.NewFlagSet("profile remove", flag.ContinueOnError)
+	name := flags.String("name", "", "name of the profile to remove (required)")
+	verbose := flags.Bool("verbose", false, "write logs to stderr")
+	if err := flags.Parse(args); err != nil {
+		return parseErrorExit(err)
+	}
+	if *name == "" {
+		fmt.Fprintln(os.Stderr, "--name is required.")
+		return exitUsage
+	}
+
+	setupCLILogging(*verbose)
+
+	v, err := unlockVault()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitAuth
+	}
+	if err := v.Delete(*name); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	fmt.Fprintf(os.Stderr, "Removed profile %s.\n", *name)
+	return exitOK
+}
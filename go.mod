@@ -2,4 +2,7 @@ module github.com/nileshnk/reddit-migrate
 
 go 1.21
 
-require github.com/go-chi/chi/v5 v5.2.1
+require (
+	github.com/go-chi/chi/v5 v5.2.1
+	golang.org/x/crypto v0.33.0
+)
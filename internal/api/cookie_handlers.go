@@ -0,0 +1,72 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/nileshnk/reddit-migrate/internal/auth"
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/cookieimport"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+)
+
+// maxCookieFileSize bounds uploaded cookie files. Firefox cookie databases of long-used profiles reach a few megabytes.
+const maxCookieFileSize = 32 << 20
+
+// ImportCookiesHandler handles POST /api/import-cookies. It takes a cookies.txt, HAR or Firefox cookies.sqlite file
+// uploaded as the "file" field of a multipart form, extracts the reddit.com cookies and verifies them like
+// /api/verify-cookie. The uploaded file is not stored.
+func ImportCookiesHandler(w http.ResponseWriter, r *http.Request) {
+	config.InfoLogger.Printf("Received cookie import request from %s", r.RemoteAddr)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxCookieFileSize+1<<20)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		config.ErrorLogger.Printf("Error reading uploaded cookie file from %s: %v", r.RemoteAddr, err)
+		SendErrorResponse(w, "Upload a cookie file as the 'file' field of a multipart form", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	if r.MultipartForm != nil {
+		defer r.MultipartForm.RemoveAll()
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, maxCookieFileSize+1))
+	if err != nil {
+		config.ErrorLogger.Printf("Error reading uploaded cookie file %s: %v", header.Filename, err)
+		SendErrorResponse(w, "Failed to read the uploaded file", http.StatusBadRequest)
+		return
+	}
+	if len(data) > maxCookieFileSize {
+		SendErrorResponse(w, "Cookie file is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	cookie, format, err := cookieimport.RedditCookieHeader(data)
+	if err != nil {
+		config.ErrorLogger.Printf("Error importing cookies from %s (%d bytes): %v", header.Filename, len(data), err)
+		status := http.StatusUnprocessableEntity
+		if errors.Is(err, cookieimport.ErrUnknownFormat) {
+			status = http.StatusUnsupportedMediaType
+		}
+		SendErrorResponse(w, err.Error(), status)
+		return
+	}
+	config.InfoLogger.Printf("Imported reddit.com cookies from %s (%s); verifying them.", header.Filename, format)
+
+	verified := auth.VerifyCookieAndGetResponse(cookie)
+	response := types.CookieImportResponseType{
+		Success: verified.Success,
+		Message: verified.Message,
+		Format:  format,
+	}
+	if verified.Success {
+		response.Cookie = cookie
+		response.Data.Username = verified.Data.Username
+	}
+
+	if err := SendJSONResponse(w, response); err != nil {
+		config.ErrorLogger.Printf("Error encoding cookie import response for %s: %v", r.RemoteAddr, err)
+	}
+}
@@ -109,6 +109,109 @@ func SubredditsHandler(w http.ResponseWriter, r *http.Request) {
 	config.InfoLogger.Printf("Successfully sent %d subreddits to %s", len(subreddits), r.RemoteAddr)
 }
 
+// MultiredditsHandler handles the /api/multireddits endpoint
+func MultiredditsHandler(w http.ResponseWriter, r *http.Request) {
+	config.DebugLogger.Printf("Received request for /api/multireddits from %s", r.RemoteAddr)
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		config.ErrorLogger.Printf("Invalid content type for /api/multireddits from %s: %s", r.RemoteAddr, r.Header.Get("Content-Type"))
+		http.Error(w, "Content Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var requestBody types.GetMultiredditsRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&requestBody); err != nil {
+		config.ErrorLogger.Printf("Error decoding /api/multireddits request from %s: %v", r.RemoteAddr, err)
+		http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, _, err := extractAuthData(requestBody.AuthMethod, requestBody.Cookie, requestBody.AccessToken, requestBody.Username)
+	if err != nil {
+		config.ErrorLogger.Printf("Failed to extract auth data for /api/multireddits from %s: %v", r.RemoteAddr, err)
+		http.Error(w, "Authentication failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	multis, err := reddit.FetchMultireddits(token)
+	if err != nil {
+		config.ErrorLogger.Printf("Error fetching multireddits for %s: %v", r.RemoteAddr, err)
+		http.Error(w, "Failed to fetch multireddits: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := types.GetMultiredditsResponse{
+		Success:      true,
+		Message:      "Multireddits fetched successfully",
+		Multireddits: multis,
+		Count:        len(multis),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		config.ErrorLogger.Printf("Error encoding multireddits response for %s: %v", r.RemoteAddr, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	config.InfoLogger.Printf("Successfully sent %d multireddits to %s", len(multis), r.RemoteAddr)
+}
+
+// PrefsHandler handles the /api/prefs endpoint.
+// It returns the account's preferences and the keys copied by default, so the user can pick which ones to migrate.
+func PrefsHandler(w http.ResponseWriter, r *http.Request) {
+	config.DebugLogger.Printf("Received request for /api/prefs from %s", r.RemoteAddr)
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		config.ErrorLogger.Printf("Invalid content type for /api/prefs from %s: %s", r.RemoteAddr, r.Header.Get("Content-Type"))
+		http.Error(w, "Content Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var requestBody types.GetPrefsRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&requestBody); err != nil {
+		config.ErrorLogger.Printf("Error decoding /api/prefs request from %s: %v", r.RemoteAddr, err)
+		http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, _, err := extractAuthData(requestBody.AuthMethod, requestBody.Cookie, requestBody.AccessToken, requestBody.Username)
+	if err != nil {
+		config.ErrorLogger.Printf("Failed to extract auth data for /api/prefs from %s: %v", r.RemoteAddr, err)
+		http.Error(w, "Authentication failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	prefs, err := reddit.FetchPrefs(token)
+	if err != nil {
+		config.ErrorLogger.Printf("Error fetching preferences for %s: %v", r.RemoteAddr, err)
+		http.Error(w, "Failed to fetch preferences: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := types.GetPrefsResponse{
+		Success:     true,
+		Message:     "Preferences fetched successfully",
+		Prefs:       prefs,
+		DefaultKeys: reddit.DefaultPrefKeys,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		config.ErrorLogger.Printf("Error encoding preferences response for %s: %v", r.RemoteAddr, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	config.InfoLogger.Printf("Successfully sent %d preferences to %s", len(prefs), r.RemoteAddr)
+}
+
 // SavedPostsHandler handles the /api/saved-posts endpoint
 func SavedPostsHandler(w http.ResponseWriter, r *http.Request) {
 	config.DebugLogger.Printf("Received request for /api/saved-posts from %s", r.RemoteAddr)
@@ -137,8 +240,8 @@ func SavedPostsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Fetch detailed saved posts information
-	posts, err := reddit.FetchSavedPostsWithDetails(token, username)
+	// Fetch detailed saved posts and comments information
+	posts, comments, err := reddit.FetchSavedItemsWithDetails(token, username)
 	if err != nil {
 		config.ErrorLogger.Printf("Error fetching saved posts for %s: %v", r.RemoteAddr, err)
 		http.Error(w, "Failed to fetch saved posts: "+err.Error(), http.StatusInternalServerError)
@@ -146,10 +249,12 @@ func SavedPostsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := types.GetSavedPostsResponse{
-		Success: true,
-		Message: "Saved posts fetched successfully",
-		Posts:   posts,
-		Count:   len(posts),
+		Success:      true,
+		Message:      "Saved posts fetched successfully",
+		Posts:        posts,
+		Count:        len(posts),
+		Comments:     comments,
+		CommentCount: len(comments),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -159,7 +264,7 @@ func SavedPostsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	config.InfoLogger.Printf("Successfully sent %d saved posts to %s", len(posts), r.RemoteAddr)
+	config.InfoLogger.Printf("Successfully sent %d saved posts and %d saved comments to %s", len(posts), len(comments), r.RemoteAddr)
 }
 
 // AccountCountsHandler handles the /api/account-counts endpoint
@@ -197,18 +302,20 @@ func AccountCountsHandler(w http.ResponseWriter, r *http.Request) {
 		subredditCount = -1 // Indicate error
 	}
 
-	postsCount, err := reddit.GetSavedPostsCount(token, username)
+	postsCount, commentsCount, err := reddit.GetSavedItemsCount(token, username)
 	if err != nil {
 		config.ErrorLogger.Printf("Error getting saved posts count for %s: %v", r.RemoteAddr, err)
 		postsCount = -1 // Indicate error
+		commentsCount = -1
 	}
 
 	response := types.AccountCountsResponse{
-		Success:         true,
-		Message:         "Account counts retrieved successfully",
-		Username:        username,
-		SubredditCount:  subredditCount,
-		SavedPostsCount: postsCount,
+		Success:            true,
+		Message:            "Account counts retrieved successfully",
+		Username:           username,
+		SubredditCount:     subredditCount,
+		SavedPostsCount:    postsCount,
+		SavedCommentsCount: commentsCount,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -218,5 +325,55 @@ func AccountCountsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	config.InfoLogger.Printf("Successfully sent account counts to %s: %d subreddits, %d posts", r.RemoteAddr, subredditCount, postsCount)
+	config.InfoLogger.Printf("Successfully sent account counts to %s: %d subreddits, %d posts, %d comments", r.RemoteAddr, subredditCount, postsCount, commentsCount)
+}
+
+// ExportHandler handles the /api/export endpoint.
+// It responds with the account archive as a downloadable JSON document.
+func ExportHandler(w http.ResponseWriter, r *http.Request) {
+	config.DebugLogger.Printf("Received request for /api/export from %s", r.RemoteAddr)
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		config.ErrorLogger.Printf("Invalid content type for /api/export from %s: %s", r.RemoteAddr, r.Header.Get("Content-Type"))
+		http.Error(w, "Content Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var requestBody types.ExportRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&requestBody); err != nil {
+		config.ErrorLogger.Printf("Error decoding /api/export request from %s: %v", r.RemoteAddr, err)
+		http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Extract authentication data
+	token, username, err := extractAuthData(requestBody.AuthMethod, requestBody.Cookie, requestBody.AccessToken, requestBody.Username)
+	if err != nil {
+		config.ErrorLogger.Printf("Failed to extract auth data for /api/export from %s: %v", r.RemoteAddr, err)
+		http.Error(w, "Authentication failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	archive, err := reddit.ExportAccount(token, username)
+	if err != nil {
+		config.ErrorLogger.Printf("Error exporting account for %s: %v", r.RemoteAddr, err)
+		http.Error(w, "Failed to export account: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("reddit-%s-%s.json", username, archive.ExportedAt.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(archive); err != nil {
+		config.ErrorLogger.Printf("Error encoding export for %s: %v", r.RemoteAddr, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	config.InfoLogger.Printf("Successfully sent export of %s to %s", username, r.RemoteAddr)
 }
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/jobs"
+	"github.com/nileshnk/reddit-migrate/internal/journal"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ListJobsHandler handles GET /api/jobs and returns all known migration jobs, newest first.
+func ListJobsHandler(w http.ResponseWriter, r *http.Request) {
+	config.DebugLogger.Printf("Received job list request from %s", r.RemoteAddr)
+
+	jobList := jobs.DefaultManager.List()
+	response := types.JobListResponseType{
+		Success: true,
+		Message: "Jobs fetched successfully",
+		Jobs:    jobList,
+		Count:   len(jobList),
+	}
+
+	if err := SendJSONResponse(w, response); err != nil {
+		config.ErrorLogger.Printf("Error encoding job list response for %s: %v", r.RemoteAddr, err)
+	}
+}
+
+// ListJournalsHandler handles GET /api/journals and returns the checkpoint journals of past migrations, newest first.
+// Journals survive restarts, so this is how a migration interrupted by a crash is found and resumed.
+func ListJournalsHandler(w http.ResponseWriter, r *http.Request) {
+	config.DebugLogger.Printf("Received journal list request from %s", r.RemoteAddr)
+
+	journals, err := journal.List(config.JournalDir)
+	if err != nil {
+		config.ErrorLogger.Printf("Error listing journals in %s: %v", config.JournalDir, err)
+		SendErrorResponse(w, "Failed to list migration journals", http.StatusInternalServerError)
+		return
+	}
+
+	response := types.JournalListResponseType{
+		Success:  true,
+		Message:  "Journals fetched successfully",
+		Journals: journals,
+		Count:    len(journals),
+	}
+
+	if err := SendJSONResponse(w, response); err != nil {
+		config.ErrorLogger.Printf("Error encoding journal list response for %s: %v", r.RemoteAddr, err)
+	}
+}
+
+// GetJobHandler handles GET /api/jobs/{id} and returns the job's status and, once finished, its result.
+func GetJobHandler(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+	config.DebugLogger.Printf("Received status request for job %s from %s", jobID, r.RemoteAddr)
+
+	job, ok := jobs.DefaultManager.Get(jobID)
+	if !ok {
+		SendErrorResponse(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	response := types.JobResponseType{
+		Success: true,
+		Message: "Job fetched successfully",
+		Job:     job.Info(),
+	}
+
+	if err := SendJSONResponse(w, response); err != nil {
+		config.ErrorLogger.Printf("Error encoding job %s response for %s: %v", jobID, r.RemoteAddr, err)
+	}
+}
+
+// CancelJobHandler handles DELETE /api/jobs/{id} and cancels a pending or running job.
+// Workers stop after their in-flight request; the job then reports the cancelled status.
+func CancelJobHandler(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+	config.InfoLogger.Printf("Received cancel request for job %s from %s", jobID, r.RemoteAddr)
+
+	job, ok := jobs.DefaultManager.Get(jobID)
+	if !ok {
+		SendErrorResponse(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	err := jobs.DefaultManager.Cancel(jobID)
+	switch {
+	case errors.Is(err, jobs.ErrJobNotFound):
+		SendErrorResponse(w, "Job not found", http.StatusNotFound)
+		return
+	case errors.Is(err, jobs.ErrJobFinished):
+		SendErrorResponse(w, "Job has already finished", http.StatusConflict)
+		return
+	case err != nil:
+		config.ErrorLogger.Printf("Error cancelling job %s: %v", jobID, err)
+		SendErrorResponse(w, "Failed to cancel job", http.StatusInternalServerError)
+		return
+	}
+
+	response := types.JobResponseType{
+		Success: true,
+		Message: "Job cancellation requested",
+		Job:     job.Info(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := SendJSONResponse(w, response); err != nil {
+		config.ErrorLogger.Printf("Error encoding cancel response for job %s: %v", jobID, err)
+	}
+}
+
+// sseKeepAliveInterval is how often a comment line is sent on an idle event stream
+// so that proxies and browsers do not drop the connection.
+const sseKeepAliveInterval = 15 * time.Second
+
+// JobEventsHandler handles GET /api/jobs/{id}/events and streams the job's progress as Server-Sent Events.
+// Past events are replayed first, so clients connecting late still see the history, as far back as the job keeps it
+// (jobs.MaxEvents). Reconnecting clients can send the standard Last-Event-ID header to resume after the last event
+// they received, or from the oldest event kept if that one has been dropped since.
+// The stream ends after the job's "done" event.
+func JobEventsHandler(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+	config.DebugLogger.Printf("Received event stream request for job %s from %s", jobID, r.RemoteAddr)
+
+	job, ok := jobs.DefaultManager.Get(jobID)
+	if !ok {
+		SendErrorResponse(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		config.ErrorLogger.Printf("Streaming not supported by response writer for %s", r.RemoteAddr)
+		SendErrorResponse(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	lastSeq := 0
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		if seq, err := strconv.Atoi(lastEventID); err == nil {
+			lastSeq = seq
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		events, changed := job.EventsSince(lastSeq)
+		for _, event := range events {
+			if err := writeSSEEvent(w, event); err != nil {
+				config.ErrorLogger.Printf("Error writing event to stream for job %s: %v", jobID, err)
+				return
+			}
+			lastSeq = event.Seq
+			if event.Type == types.DoneEvent {
+				flusher.Flush()
+				config.DebugLogger.Printf("Event stream for job %s finished.", jobID)
+				return
+			}
+		}
+		flusher.Flush()
+
+		select {
+		case <-changed:
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			config.DebugLogger.Printf("Client %s disconnected from event stream for job %s.", r.RemoteAddr, jobID)
+			return
+		}
+	}
+}
+
+// writeSSEEvent writes a single progress event in the Server-Sent Events wire format.
+func writeSSEEvent(w http.ResponseWriter, event types.ProgressEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data)
+	return err
+}
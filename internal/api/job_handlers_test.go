@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/jobs"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestMain(m *testing.M) {
+	config.InfoLogger = log.New(io.Discard, "", 0)
+	config.ErrorLogger = log.New(io.Discard, "", 0)
+	config.DebugLogger = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+func TestCancelJobHandler(t *testing.T) {
+	manager := jobs.DefaultManager
+	jobs.DefaultManager = jobs.NewManager()
+	t.Cleanup(func() { jobs.DefaultManager = manager })
+
+	router := chi.NewRouter()
+	router.Route("/api", Router)
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	started := make(chan struct{})
+	job, err := jobs.DefaultManager.Submit("migrate", func(ctx context.Context, jobID string) types.MigrationResponseType {
+		close(started)
+		<-ctx.Done()
+		return types.MigrationResponseType{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	cancel := func(id string) int {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodDelete, srv.URL+"/api/jobs/"+id, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var body types.JobResponseType
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("DELETE /api/jobs/%s: decoding response: %v", id, err)
+		}
+		if body.Success != (resp.StatusCode == http.StatusAccepted) {
+			t.Errorf("DELETE /api/jobs/%s = %d with success %v", id, resp.StatusCode, body.Success)
+		}
+		return resp.StatusCode
+	}
+
+	if got := cancel(job.ID); got != http.StatusAccepted {
+		t.Fatalf("cancelling a running job = %d, want 202", got)
+	}
+	select {
+	case <-job.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was not cancelled")
+	}
+	if status := job.Info().Status; status != types.JobCancelled {
+		t.Errorf("job finished as %s, want cancelled", status)
+	}
+	if got := cancel(job.ID); got != http.StatusConflict {
+		t.Errorf("cancelling a finished job = %d, want 409", got)
+	}
+	if got := cancel("unknown"); got != http.StatusNotFound {
+		t.Errorf("cancelling an unknown job = %d, want 404", got)
+	}
+}
@@ -1,15 +1,22 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/jobs"
+	"github.com/nileshnk/reddit-migrate/internal/journal"
 	"github.com/nileshnk/reddit-migrate/internal/migration"
 	"github.com/nileshnk/reddit-migrate/internal/types"
+
+	"github.com/go-chi/chi/v5"
 )
 
-// CustomMigrationHandler handles the /api/migrate-custom endpoint
+// CustomMigrationHandler handles the /api/migrate-custom endpoint.
+// The migration runs as a background job; the response carries the job ID to poll.
 func CustomMigrationHandler(w http.ResponseWriter, r *http.Request) {
 	config.DebugLogger.Printf("Received custom migration request from %s", r.RemoteAddr)
 
@@ -32,14 +39,137 @@ func CustomMigrationHandler(w http.ResponseWriter, r *http.Request) {
 	config.InfoLogger.Printf("Custom migration request for %s: %d subreddits, %d posts",
 		r.RemoteAddr, len(requestBody.SelectedSubreddits), len(requestBody.SelectedPosts))
 
-	finalResponse := migration.HandleCustomMigration(requestBody)
+	job, err := jobs.DefaultManager.Submit("migrate-custom", func(ctx context.Context, jobID string) types.MigrationResponseType {
+		return migration.HandleCustomMigration(ctx, jobID, requestBody)
+	})
+	if err != nil {
+		config.ErrorLogger.Printf("Error starting custom migration job for %s: %v", r.RemoteAddr, err)
+		SendErrorResponse(w, "Failed to start migration job", http.StatusInternalServerError)
+		return
+	}
+
+	response := types.JobResponseType{
+		Success: true,
+		Message: "Custom migration job started",
+		Job:     job.Info(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		config.ErrorLogger.Printf("Error encoding custom migration job response for %s: %v", r.RemoteAddr, err)
+		return
+	}
+
+	config.InfoLogger.Printf("Started custom migration job %s for %s.", job.ID, r.RemoteAddr)
+}
+
+// ImportHandler handles the /api/import endpoint.
+// The request carries an exported archive and the destination account; the import runs as a background job.
+func ImportHandler(w http.ResponseWriter, r *http.Request) {
+	config.DebugLogger.Printf("Received import request from %s", r.RemoteAddr)
+
+	if !ValidateContentType(r) {
+		SendErrorResponse(w, "Content Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var requestBody types.ImportRequest
+	if err := DecodeJSONRequest(r, &requestBody); err != nil {
+		config.ErrorLogger.Printf("Error decoding /api/import request from %s: %v", r.RemoteAddr, err)
+		SendErrorResponse(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := migration.ValidateArchive(requestBody.Archive); err != nil {
+		config.ErrorLogger.Printf("Rejected archive from %s: %v", r.RemoteAddr, err)
+		SendErrorResponse(w, "Invalid archive: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	config.InfoLogger.Printf("Import request for %s: archive of %s, %d subreddits, %d users, %d posts selected",
+		r.RemoteAddr, requestBody.Archive.Username, len(requestBody.SelectedSubreddits), len(requestBody.SelectedUsers), len(requestBody.SelectedPosts))
+
+	job, err := jobs.DefaultManager.Submit("import", func(ctx context.Context, jobID string) types.MigrationResponseType {
+		return migration.ImportArchive(ctx, jobID, requestBody)
+	})
+	if err != nil {
+		config.ErrorLogger.Printf("Error starting import job for %s: %v", r.RemoteAddr, err)
+		SendErrorResponse(w, "Failed to start import job", http.StatusInternalServerError)
+		return
+	}
+
+	response := types.JobResponseType{
+		Success: true,
+		Message: "Import job started",
+		Job:     job.Info(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		config.ErrorLogger.Printf("Error encoding import job response for %s: %v", r.RemoteAddr, err)
+		return
+	}
+
+	config.InfoLogger.Printf("Started import job %s for %s.", job.ID, r.RemoteAddr)
+}
+
+// ResumeMigrationHandler handles POST /api/jobs/{id}/resume.
+// It reopens the journal of a previous migration job and starts a new "resume" job that processes
+// only the items still pending or failed. Both accounts must be authenticated again in the request body.
+func ResumeMigrationHandler(w http.ResponseWriter, r *http.Request) {
+	sourceJobID := chi.URLParam(r, "id")
+	config.InfoLogger.Printf("Received resume request for job %s from %s", sourceJobID, r.RemoteAddr)
+
+	if !ValidateContentType(r) {
+		SendErrorResponse(w, "Content Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var requestBody types.ResumeMigrationRequest
+	if err := DecodeJSONRequest(r, &requestBody); err != nil {
+		config.ErrorLogger.Printf("Error decoding resume request for job %s from %s: %v", sourceJobID, r.RemoteAddr, err)
+		SendErrorResponse(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jrnl, err := journal.Open(config.JournalDir, sourceJobID)
+	switch {
+	case errors.Is(err, journal.ErrNotFound):
+		SendErrorResponse(w, "No journal found for job", http.StatusNotFound)
+		return
+	case errors.Is(err, journal.ErrInUse):
+		SendErrorResponse(w, "Migration is still running or already being resumed", http.StatusConflict)
+		return
+	case err != nil:
+		config.ErrorLogger.Printf("Error opening journal for job %s: %v", sourceJobID, err)
+		SendErrorResponse(w, "Failed to open migration journal", http.StatusInternalServerError)
+		return
+	}
+
+	job, err := jobs.DefaultManager.Submit("resume", func(ctx context.Context, jobID string) types.MigrationResponseType {
+		defer jrnl.Close()
+		return migration.ResumeMigration(ctx, jrnl, requestBody)
+	})
+	if err != nil {
+		jrnl.Close()
+		config.ErrorLogger.Printf("Error starting resume job for %s: %v", sourceJobID, err)
+		SendErrorResponse(w, "Failed to start migration job", http.StatusInternalServerError)
+		return
+	}
+
+	response := types.JobResponseType{
+		Success: true,
+		Message: "Resume job started",
+		Job:     job.Info(),
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(finalResponse); err != nil {
-		config.ErrorLogger.Printf("Error encoding custom migration response for %s: %v", r.RemoteAddr, err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		config.ErrorLogger.Printf("Error encoding resume job response for %s: %v", r.RemoteAddr, err)
 		return
 	}
 
-	config.InfoLogger.Printf("Successfully processed custom migration for %s. Success: %t", r.RemoteAddr, finalResponse.Success)
+	config.InfoLogger.Printf("Started resume job %s for journal %s.", job.ID, sourceJobID)
 }
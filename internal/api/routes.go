@@ -35,20 +35,73 @@ func Router(router chi.Router) {
 	router.Post("/verify-cookie", auth.VerifyTokenResponse)
 	config.InfoLogger.Println("Registered /api/verify-cookie POST endpoint")
 
+	router.Post("/import-cookies", ImportCookiesHandler)
+	config.InfoLogger.Println("Registered /api/import-cookies POST endpoint")
+
 	// Data fetching endpoints
 	router.Post("/subreddits", SubredditsHandler)
 	config.InfoLogger.Println("Registered /api/subreddits POST endpoint")
 
+	router.Post("/multireddits", MultiredditsHandler)
+	config.InfoLogger.Println("Registered /api/multireddits POST endpoint")
+
+	router.Post("/prefs", PrefsHandler)
+	config.InfoLogger.Println("Registered /api/prefs POST endpoint")
+
 	router.Post("/saved-posts", SavedPostsHandler)
 	config.InfoLogger.Println("Registered /api/saved-posts POST endpoint")
 
 	router.Post("/account-counts", AccountCountsHandler)
 	config.InfoLogger.Println("Registered /api/account-counts POST endpoint")
 
+	router.Post("/export", ExportHandler)
+	config.InfoLogger.Println("Registered /api/export POST endpoint")
+
 	// Migration endpoints
 	router.Post("/migrate", migration.MigrationHandler)
 	config.InfoLogger.Println("Registered /api/migrate POST endpoint")
 
 	router.Post("/migrate-custom", CustomMigrationHandler)
 	config.InfoLogger.Println("Registered /api/migrate-custom POST endpoint")
+
+	router.Post("/import", ImportHandler)
+	config.InfoLogger.Println("Registered /api/import POST endpoint")
+
+	// Background job endpoints
+	router.Get("/jobs", ListJobsHandler)
+	config.InfoLogger.Println("Registered /api/jobs GET endpoint")
+
+	router.Get("/jobs/{id}", GetJobHandler)
+	config.InfoLogger.Println("Registered /api/jobs/{id} GET endpoint")
+
+	router.Delete("/jobs/{id}", CancelJobHandler)
+	config.InfoLogger.Println("Registered /api/jobs/{id} DELETE endpoint")
+
+	router.Get("/jobs/{id}/events", JobEventsHandler)
+	config.InfoLogger.Println("Registered /api/jobs/{id}/events GET endpoint")
+
+	router.Post("/jobs/{id}/resume", ResumeMigrationHandler)
+	config.InfoLogger.Println("Registered /api/jobs/{id}/resume POST endpoint")
+
+	router.Get("/journals", ListJournalsHandler)
+	config.InfoLogger.Println("Registered /api/journals GET endpoint")
+
+	// Credential vault endpoints
+	router.Get("/vault", VaultStatusHandler)
+	config.InfoLogger.Println("Registered /api/vault GET endpoint")
+
+	router.Post("/vault/unlock", UnlockVaultHandler)
+	config.InfoLogger.Println("Registered /api/vault/unlock POST endpoint")
+
+	router.Post("/vault/lock", LockVaultHandler)
+	config.InfoLogger.Println("Registered /api/vault/lock POST endpoint")
+
+	router.Post("/vault/profiles", SaveProfileHandler)
+	config.InfoLogger.Println("Registered /api/vault/profiles POST endpoint")
+
+	router.Delete("/vault/profiles/{name}", DeleteProfileHandler)
+	config.InfoLogger.Println("Registered /api/vault/profiles/{name} DELETE endpoint")
+
+	router.Post("/vault/profiles/{name}/login", ProfileLoginHandler)
+	config.InfoLogger.Println("Registered /api/vault/profiles/{name}/login POST endpoint")
 }
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/nileshnk/reddit-migrate/internal/auth"
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+	"github.com/nileshnk/reddit-migrate/internal/vault"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// VaultStatusHandler handles GET /api/vault and reports whether the credential vault exists and is unlocked,
+// listing its profiles without their secrets while it is.
+func VaultStatusHandler(w http.ResponseWriter, r *http.Request) {
+	config.DebugLogger.Printf("Received vault status request from %s", r.RemoteAddr)
+	sendVaultStatus(w, r, "Vault status fetched successfully")
+}
+
+// UnlockVaultHandler handles POST /api/vault/unlock and unlocks the vault with a passphrase for this server.
+func UnlockVaultHandler(w http.ResponseWriter, r *http.Request) {
+	config.InfoLogger.Printf("Received vault unlock request from %s", r.RemoteAddr)
+
+	if !ValidateContentType(r) {
+		SendErrorResponse(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+	var req types.VaultUnlockRequest
+	if err := DecodeJSONRequest(r, &req); err != nil {
+		SendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Passphrase == "" {
+		SendErrorResponse(w, "Passphrase is required", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := vault.Unlock(req.Passphrase); err != nil {
+		if errors.Is(err, vault.ErrWrongPassphrase) {
+			SendErrorResponse(w, "Wrong passphrase", http.StatusUnauthorized)
+			return
+		}
+		config.ErrorLogger.Printf("Error unlocking vault: %v", err)
+		SendErrorResponse(w, "Failed to unlock vault", http.StatusInternalServerError)
+		return
+	}
+	sendVaultStatus(w, r, "Vault unlocked")
+}
+
+// LockVaultHandler handles POST /api/vault/lock and forgets the vault key until it is unlocked again.
+func LockVaultHandler(w http.ResponseWriter, r *http.Request) {
+	config.InfoLogger.Printf("Received vault lock request from %s", r.RemoteAddr)
+	vault.Lock()
+	sendVaultStatus(w, r, "Vault locked")
+}
+
+// SaveProfileHandler handles POST /api/vault/profiles and saves an authenticated account under a profile name,
+// replacing any profile with the same name. OAuth accounts are saved by their refresh token, so they must have been
+// connected through the OAuth flow of this server.
+func SaveProfileHandler(w http.ResponseWriter, r *http.Request) {
+	config.InfoLogger.Printf("Received save profile request from %s", r.RemoteAddr)
+
+	if !ValidateContentType(r) {
+		SendErrorResponse(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+	var req types.SaveProfileRequest
+	if err := DecodeJSONRequest(r, &req); err != nil {
+		SendErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if (req.AccessToken == "") == (req.Cookie == "") {
+		SendErrorResponse(w, "Exactly one of access_token and cookie is required", http.StatusBadRequest)
+		return
+	}
+
+	v, err := vault.Current()
+	if err != nil {
+		SendErrorResponse(w, "Unlock the vault first", http.StatusForbidden)
+		return
+	}
+
+	var profile vault.Profile
+	if req.AccessToken != "" {
+		profile, err = auth.ProfileFromToken(req.Name, req.AccessToken)
+	} else {
+		profile, err = auth.ProfileFromCookie(req.Name, req.Cookie)
+	}
+	if err != nil {
+		config.ErrorLogger.Printf("Error building profile %s: %v", req.Name, err)
+		SendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := profile.Validate(); err != nil {
+		SendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := v.Put(profile); err != nil {
+		config.ErrorLogger.Printf("Error saving profile %s: %v", req.Name, err)
+		SendErrorResponse(w, "Failed to save profile", http.StatusInternalServerError)
+		return
+	}
+	config.InfoLogger.Printf("Saved profile %s for %s.", profile.Name, profile.Username)
+	sendVaultStatus(w, r, "Profile saved")
+}
+
+// DeleteProfileHandler handles DELETE /api/vault/profiles/{name}.
+func DeleteProfileHandler(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	config.InfoLogger.Printf("Received delete request for profile %s from %s", name, r.RemoteAddr)
+
+	v, err := vault.Current()
+	if err != nil {
+		SendErrorResponse(w, "Unlock the vault first", http.StatusForbidden)
+		return
+	}
+	if err := v.Delete(name); err != nil {
+		if errors.Is(err, vault.ErrNotFound) {
+			SendErrorResponse(w, "Profile not found", http.StatusNotFound)
+			return
+		}
+		config.ErrorLogger.Printf("Error deleting profile %s: %v", name, err)
+		SendErrorResponse(w, "Failed to delete profile", http.StatusInternalServerError)
+		return
+	}
+	sendVaultStatus(w, r, "Profile deleted")
+}
+
+// ProfileLoginHandler handles POST /api/vault/profiles/{name}/login and returns a fresh access token and the username
+// of a saved profile, which the client then uses like a token from the OAuth flow.
+func ProfileLoginHandler(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	config.InfoLogger.Printf("Received login request for profile %s from %s", name, r.RemoteAddr)
+
+	token, username, err := auth.LoginProfile(r.Context(), name)
+	switch {
+	case errors.Is(err, vault.ErrLocked):
+		SendErrorResponse(w, "Unlock the vault first", http.StatusForbidden)
+		return
+	case errors.Is(err, vault.ErrNotFound):
+		SendErrorResponse(w, "Profile not found", http.StatusNotFound)
+		return
+	case err != nil:
+		config.ErrorLogger.Printf("Error logging in with profile %s: %v", name, err)
+		SendErrorResponse(w, "Failed to log in with profile: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	response := types.ProfileLoginResponseType{
+		Success:     true,
+		Message:     "Logged in with profile",
+		Username:    username,
+		AccessToken: token,
+	}
+	if err := SendJSONResponse(w, response); err != nil {
+		config.ErrorLogger.Printf("Error encoding profile login response for %s: %v", r.RemoteAddr, err)
+	}
+}
+
+// sendVaultStatus writes the vault status response shared by the vault endpoints.
+func sendVaultStatus(w http.ResponseWriter, r *http.Request, message string) {
+	response := types.VaultStatusResponseType{
+		Success:  true,
+		Message:  message,
+		Exists:   vault.Exists(),
+		Profiles: []types.VaultProfileSummary{},
+	}
+	if v, err := vault.Current(); err == nil {
+		response.Unlocked = true
+		for _, profile := range v.Profiles() {
+			response.Profiles = append(response.Profiles, types.VaultProfileSummary{
+				Name:       profile.Name,
+				Username:   profile.Username,
+				AuthMethod: profile.AuthMethod,
+				UpdatedAt:  profile.UpdatedAt,
+			})
+		}
+	}
+
+	if err := SendJSONResponse(w, response); err != nil {
+		config.ErrorLogger.Printf("Error encoding vault status response for %s: %v", r.RemoteAddr, err)
+	}
+}
@@ -1,14 +1,15 @@
 package auth
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
 
 	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/reddit"
 	"github.com/nileshnk/reddit-migrate/internal/types"
 )
 
@@ -61,8 +62,17 @@ func VerifyTokenResponse(w http.ResponseWriter, r *http.Request) {
 func VerifyCookieAndGetResponse(cookieStr string) types.TokenResponseType {
 	var finalResponse types.TokenResponseType
 
+	// Every API request authenticates with token_v2, so a cookie without it is useless even if Reddit accepts it.
+	if ParseTokenFromCookie(cookieStr) == "" {
+		config.ErrorLogger.Printf("Cookie (ends ...%s) has no token_v2 value", SafeSuffix(cookieStr, 6))
+		finalResponse.Success = false
+		finalResponse.Message = "The cookie has no token_v2. Log in to Reddit again and copy or export the cookies of reddit.com."
+		return finalResponse
+	}
+
 	// Make request to Reddit's /api/me.json
-	req, err := http.NewRequest(http.MethodGet, "https://www.reddit.com/api/me.json", nil)
+	client := reddit.DefaultClient()
+	req, err := client.NewRequest(context.Background(), http.MethodGet, client.WebURL("/api/me.json"), "", nil)
 	if err != nil {
 		config.ErrorLogger.Printf("Error creating request for /api/me.json: %v", err)
 		finalResponse.Success = false
@@ -70,28 +80,17 @@ func VerifyCookieAndGetResponse(cookieStr string) types.TokenResponseType {
 		return finalResponse
 	}
 
-	req.Header = http.Header{
-		"Cookie":     {cookieStr},
-		"User-Agent": {config.UserAgent},
-	}
+	req.Header.Set("Cookie", cookieStr)
 
 	config.DebugLogger.Printf("Sending request to /api/me.json to verify cookie (ends ...%s)", SafeSuffix(cookieStr, 6))
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		config.ErrorLogger.Printf("Error sending request to /api/me.json: %v", err)
 		finalResponse.Success = false
 		finalResponse.Message = "Error contacting Reddit to verify cookie."
 		return finalResponse
 	}
-	defer resp.Body.Close()
-
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		config.ErrorLogger.Printf("Error reading response body from /api/me.json (status %d): %v", resp.StatusCode, err)
-		finalResponse.Success = false
-		finalResponse.Message = "Error reading Reddit's response."
-		return finalResponse
-	}
+	bodyBytes := resp.Body
 	config.DebugLogger.Printf("/api/me.json response (Status: %d): %s", resp.StatusCode, string(bodyBytes))
 
 	if resp.StatusCode != http.StatusOK {
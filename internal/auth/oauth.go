@@ -3,26 +3,29 @@ package auth
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
 	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/reddit"
 	"github.com/nileshnk/reddit-migrate/internal/types"
 )
 
-// OAuthConfig holds the Reddit OAuth configuration
+// OAuthConfig describes the Reddit app an account is authorized with. Source and destination accounts may use
+// different apps, so a config is carried by each OAuth flow, token source and profile instead of being global.
 type OAuthConfig struct {
-	ClientID     string
-	ClientSecret string
-	RedirectURI  string
-	Scopes       []string
-	UserAgent    string
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret,omitempty"` // Empty for installed apps
+	RedirectURI  string   `json:"redirect_uri"`
+	Scopes       []string `json:"scopes"`
+	UserAgent    string   `json:"user_agent"`
 }
 
 // OAuthToken represents an OAuth token response from Reddit
@@ -37,7 +40,17 @@ type OAuthToken struct {
 
 // IsExpired checks if the token has expired
 func (t *OAuthToken) IsExpired() bool {
-	return time.Now().After(t.CreatedAt.Add(time.Duration(t.ExpiresIn) * time.Second))
+	return t.expiresWithin(0)
+}
+
+// expiresWithin reports whether the token expires within d from now.
+func (t *OAuthToken) expiresWithin(d time.Duration) bool {
+	return time.Now().Add(d).After(t.expiresAt())
+}
+
+// expiresAt returns when the token expires.
+func (t *OAuthToken) expiresAt() time.Time {
+	return t.CreatedAt.Add(time.Duration(t.ExpiresIn) * time.Second)
 }
 
 // OAuthState stores temporary state for OAuth flow
@@ -47,12 +60,10 @@ type OAuthState struct {
 	CallbackURL string
 }
 
-// Global OAuth configuration (should be initialized from config)
-var redditOAuth *OAuthConfig
-
-// Initialize OAuth configuration
-func InitOAuth(clientID, clientSecret, redirectURI string) {
-	redditOAuth = &OAuthConfig{
+// NewOAuthConfig returns the configuration of the Reddit app with the given credentials, requesting every scope
+// a migration needs.
+func NewOAuthConfig(clientID, clientSecret, redirectURI string) OAuthConfig {
+	return OAuthConfig{
 		ClientID:     clientID,
 		ClientSecret: clientSecret,
 		RedirectURI:  redirectURI,
@@ -65,6 +76,8 @@ func InitOAuth(clientID, clientSecret, redirectURI string) {
 			"vote",         // Vote on posts and comments
 			"mysubreddits", // Access user's subreddits
 			"history",      // Access user's voting history
+			"report",       // Hide and unhide posts
+			"account",      // Block users and update preferences
 		},
 		UserAgent: config.UserAgent,
 	}
@@ -79,59 +92,68 @@ func GenerateState() (string, error) {
 	return base64.URLEncoding.EncodeToString(b), nil
 }
 
-// GetAuthorizationURL returns the Reddit OAuth authorization URL
-func GetAuthorizationURL(state string) string {
-	if redditOAuth == nil {
-		config.ErrorLogger.Println("OAuth not initialized")
-		return ""
+// GeneratePKCE creates a PKCE code verifier and its S256 code challenge (RFC 7636). The challenge is sent with the
+// authorization request and the verifier with the code exchange, so an intercepted authorization code is useless
+// on its own. This is what protects the flow of installed apps, which have no client secret.
+func GeneratePKCE() (verifier, challenge string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
 	}
+	verifier = base64.RawURLEncoding.EncodeToString(b)
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
 
+// GetAuthorizationURL returns the Reddit OAuth authorization URL for the app described by oauthConfig.
+// A non-empty codeChallenge from GeneratePKCE is added as an S256 PKCE challenge.
+func GetAuthorizationURL(oauthConfig OAuthConfig, state, codeChallenge string) string {
 	params := url.Values{
-		"client_id":     {redditOAuth.ClientID},
+		"client_id":     {oauthConfig.ClientID},
 		"response_type": {"code"},
 		"state":         {state},
-		"redirect_uri":  {redditOAuth.RedirectURI},
+		"redirect_uri":  {oauthConfig.RedirectURI},
 		"duration":      {"permanent"}, // Request refresh token
-		"scope":         {strings.Join(redditOAuth.Scopes, " ")},
+		"scope":         {strings.Join(oauthConfig.Scopes, " ")},
+	}
+	if codeChallenge != "" {
+		params.Set("code_challenge", codeChallenge)
+		params.Set("code_challenge_method", "S256")
 	}
-	fmt.Println(params.Encode())
 
-	return fmt.Sprintf("https://www.reddit.com/api/v1/authorize?%s", params.Encode())
+	return fmt.Sprintf("%s?%s", reddit.DefaultClient().WebURL("/api/v1/authorize"), params.Encode())
 }
 
-// ExchangeCodeForToken exchanges an authorization code for an access token
-func ExchangeCodeForToken(code string) (*OAuthToken, error) {
-	if redditOAuth == nil {
-		return nil, fmt.Errorf("OAuth not initialized")
-	}
-
+// ExchangeCodeForToken exchanges an authorization code for an access token.
+// codeVerifier is the PKCE verifier whose challenge was sent with the authorization request, or empty if none was.
+// Installed apps authenticate with their client ID and an empty secret. oauthConfig must describe the app the
+// authorization URL was made for.
+func ExchangeCodeForToken(oauthConfig OAuthConfig, code, codeVerifier string) (*OAuthToken, error) {
 	data := url.Values{
 		"grant_type":   {"authorization_code"},
 		"code":         {code},
-		"redirect_uri": {redditOAuth.RedirectURI},
+		"redirect_uri": {oauthConfig.RedirectURI},
+	}
+	if codeVerifier != "" {
+		data.Set("code_verifier", codeVerifier)
 	}
 
-	req, err := http.NewRequest("POST", "https://www.reddit.com/api/v1/access_token", strings.NewReader(data.Encode()))
+	client := reddit.DefaultClient()
+	req, err := client.NewFormRequest(context.Background(), http.MethodPost, client.WebURL("/api/v1/access_token"), "", data)
 	if err != nil {
 		return nil, fmt.Errorf("error creating token request: %w", err)
 	}
 
-	req.SetBasicAuth(redditOAuth.ClientID, redditOAuth.ClientSecret)
-	req.Header.Set("User-Agent", redditOAuth.UserAgent)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(oauthConfig.ClientID, oauthConfig.ClientSecret)
+	req.Header.Set("User-Agent", oauthConfig.UserAgent)
 
 	config.DebugLogger.Printf("Exchanging authorization code for token")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error sending token request: %w", err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading token response: %w", err)
-	}
+	body := resp.Body
 
 	if resp.StatusCode != http.StatusOK {
 		config.ErrorLogger.Printf("Token exchange failed with status %d: %s", resp.StatusCode, string(body))
@@ -145,42 +167,34 @@ func ExchangeCodeForToken(code string) (*OAuthToken, error) {
 
 	token.CreatedAt = time.Now()
 	config.InfoLogger.Printf("Successfully exchanged code for token. Expires in %d seconds", token.ExpiresIn)
+	rememberToken(oauthConfig, &token)
 
 	return &token, nil
 }
 
-// RefreshAccessToken uses a refresh token to get a new access token
-func RefreshAccessToken(refreshToken string) (*OAuthToken, error) {
-	if redditOAuth == nil {
-		return nil, fmt.Errorf("OAuth not initialized")
-	}
-
+// RefreshAccessToken uses a refresh token issued to the app described by oauthConfig to get a new access token.
+func RefreshAccessToken(ctx context.Context, oauthConfig OAuthConfig, refreshToken string) (*OAuthToken, error) {
 	data := url.Values{
 		"grant_type":    {"refresh_token"},
 		"refresh_token": {refreshToken},
 	}
 
-	req, err := http.NewRequest("POST", "https://www.reddit.com/api/v1/access_token", strings.NewReader(data.Encode()))
+	client := reddit.DefaultClient()
+	req, err := client.NewFormRequest(ctx, http.MethodPost, client.WebURL("/api/v1/access_token"), "", data)
 	if err != nil {
 		return nil, fmt.Errorf("error creating refresh request: %w", err)
 	}
 
-	req.SetBasicAuth(redditOAuth.ClientID, redditOAuth.ClientSecret)
-	req.Header.Set("User-Agent", redditOAuth.UserAgent)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(oauthConfig.ClientID, oauthConfig.ClientSecret)
+	req.Header.Set("User-Agent", oauthConfig.UserAgent)
 
 	config.DebugLogger.Printf("Refreshing access token")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error sending refresh request: %w", err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading refresh response: %w", err)
-	}
+	body := resp.Body
 
 	if resp.StatusCode != http.StatusOK {
 		config.ErrorLogger.Printf("Token refresh failed with status %d: %s", resp.StatusCode, string(body))
@@ -201,26 +215,19 @@ func RefreshAccessToken(refreshToken string) (*OAuthToken, error) {
 
 // GetUserInfoWithToken fetches user information using an OAuth token
 func GetUserInfoWithToken(accessToken string) (*types.ProfileResponseType, error) {
-	req, err := http.NewRequest("GET", "https://oauth.reddit.com/api/v1/me", nil)
+	client := reddit.DefaultClient()
+	req, err := client.NewRequest(context.Background(), http.MethodGet, client.APIURL("/api/v1/me"), accessToken, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating user info request: %w", err)
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
-	req.Header.Set("User-Agent", config.UserAgent)
-
-	config.DebugLogger.Printf("Fetching user info from Reddit API with token: %s...", accessToken[:10])
+	config.DebugLogger.Printf("Fetching user info from Reddit API with token ending: ...%s", SafeSuffix(accessToken, 6))
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error sending user info request: %w", err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading user info response: %w", err)
-	}
+	body := resp.Body
 
 	config.DebugLogger.Printf("Reddit API /api/v1/me response status: %d", resp.StatusCode)
 	config.DebugLogger.Printf("Reddit API /api/v1/me response body: %s", string(body))
@@ -236,40 +243,45 @@ func GetUserInfoWithToken(accessToken string) (*types.ProfileResponseType, error
 		return nil, fmt.Errorf("error parsing user info response: %w", err)
 	}
 
-	// Populate the Data field for backward compatibility
-	// userInfo.Data.Name = userInfo.Name
-	// userInfo.Data.IsEmployee = userInfo.IsEmployee
-	// userInfo.Data.IsFriend = userInfo.IsFriend
+	// Populate the Data field for backward compatibility.
+	// /api/v1/me returns the account fields at the top level, unlike /api/me.json which wraps them in "data".
+	if userInfo.Data.Name == "" {
+		var flat struct {
+			Name       string `json:"name"`
+			IsEmployee bool   `json:"is_employee"`
+			IsFriend   bool   `json:"is_friend"`
+		}
+		if err := json.Unmarshal(body, &flat); err == nil {
+			userInfo.Data.Name = flat.Name
+			userInfo.Data.IsEmployee = flat.IsEmployee
+			userInfo.Data.IsFriend = flat.IsFriend
+		}
+	}
 
 	config.DebugLogger.Printf("Parsed user info - Username: %s", userInfo.Data.Name)
 
 	return &userInfo, nil
 }
 
-// OAuthLoginHandler handles the OAuth login initiation
+// OAuthLoginHandler starts the OAuth flow of an installed app by redirecting the browser to Reddit, for links that
+// cannot POST to /api/oauth/init. It takes client_id and account_type query parameters; a client secret is never
+// accepted in a URL. The flow finishes at EnhancedOAuthCallbackHandler like one started with OAuthInitHandler.
 func OAuthLoginHandler(w http.ResponseWriter, r *http.Request) {
-	state, err := GenerateState()
-	if err != nil {
-		config.ErrorLogger.Printf("Error generating OAuth state: %v", err)
-		errorResponse(w, "Error initiating OAuth login", http.StatusInternalServerError)
+	clientID := r.URL.Query().Get("client_id")
+	accountType := r.URL.Query().Get("account_type")
+	if clientID == "" {
+		errorResponse(w, "Client ID is required", http.StatusBadRequest)
+		return
+	}
+	if accountType != "source" && accountType != "dest" {
+		errorResponse(w, "Account type must be 'source' or 'dest'", http.StatusBadRequest)
 		return
 	}
 
-	// Store state in session or temporary storage (implementation depends on your session management)
-	// For now, we'll set it as a secure cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "oauth_state",
-		Value:    state,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteLaxMode,
-		MaxAge:   600, // 10 minutes
-	})
-
-	authURL := GetAuthorizationURL(state)
-	if authURL == "" {
-		errorResponse(w, "OAuth not properly configured", http.StatusInternalServerError)
+	authURL, err := startOAuthFlow(w, r, NewOAuthConfig(clientID, "", config.RedditOauthRedirectUri), accountType)
+	if err != nil {
+		config.ErrorLogger.Printf("Error starting OAuth flow: %v", err)
+		errorResponse(w, "Error initiating OAuth login", http.StatusInternalServerError)
 		return
 	}
 
@@ -277,131 +289,42 @@ func OAuthLoginHandler(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
 }
 
-// OAuthCallbackHandler handles the OAuth callback from Reddit
-func OAuthCallbackHandler(w http.ResponseWriter, r *http.Request) {
-	config.DebugLogger.Printf("Received OAuth callback from %s", r.RemoteAddr)
-
-	// Verify state parameter
-	stateCookie, err := r.Cookie("oauth_state")
+// startOAuthFlow stores a new flow for accountType with the app described by oauthConfig in the browser's session,
+// replacing an unfinished flow for the same account, and returns the URL to send the browser to.
+func startOAuthFlow(w http.ResponseWriter, r *http.Request, oauthConfig OAuthConfig, accountType string) (string, error) {
+	state, err := GenerateState()
 	if err != nil {
-		config.ErrorLogger.Printf("OAuth state cookie not found: %v", err)
-		errorResponse(w, "Invalid OAuth state", http.StatusBadRequest)
-		return
-	}
-
-	state := r.URL.Query().Get("state")
-	if state == "" || state != stateCookie.Value {
-		config.ErrorLogger.Printf("OAuth state mismatch. Expected: %s, Got: %s", stateCookie.Value, state)
-		errorResponse(w, "Invalid OAuth state", http.StatusBadRequest)
-		return
+		return "", fmt.Errorf("error generating OAuth state: %w", err)
 	}
-
-	// Clear the state cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:   "oauth_state",
-		Value:  "",
-		Path:   "/",
-		MaxAge: -1,
-	})
-
-	// Check for errors from Reddit
-	if errCode := r.URL.Query().Get("error"); errCode != "" {
-		config.ErrorLogger.Printf("OAuth error from Reddit: %s", errCode)
-		errorResponse(w, fmt.Sprintf("OAuth authorization denied: %s", errCode), http.StatusBadRequest)
-		return
-	}
-
-	// Get authorization code
-	code := r.URL.Query().Get("code")
-	if code == "" {
-		config.ErrorLogger.Printf("No authorization code in OAuth callback")
-		errorResponse(w, "No authorization code received", http.StatusBadRequest)
-		return
-	}
-
-	// Exchange code for token
-	token, err := ExchangeCodeForToken(code)
+	codeVerifier, codeChallenge, err := GeneratePKCE()
 	if err != nil {
-		config.ErrorLogger.Printf("Error exchanging code for token: %v", err)
-		errorResponse(w, "Error obtaining access token", http.StatusInternalServerError)
-		return
+		return "", fmt.Errorf("error generating PKCE code verifier: %w", err)
 	}
-
-	// Get user information
-	userInfo, err := GetUserInfoWithToken(token.AccessToken)
+	sessionID, err := ensureOAuthSessionID(w, r)
 	if err != nil {
-		config.ErrorLogger.Printf("Error fetching user info: %v", err)
-		errorResponse(w, "Error fetching user information", http.StatusInternalServerError)
-		return
-	}
-
-	config.InfoLogger.Printf("OAuth login successful for user: %s", userInfo.Data.Name)
-
-	// Return token and user info to client
-	response := struct {
-		Success bool `json:"success"`
-		Data    struct {
-			Username     string `json:"username"`
-			AccessToken  string `json:"access_token"`
-			RefreshToken string `json:"refresh_token"`
-			ExpiresIn    int    `json:"expires_in"`
-		} `json:"data"`
-		Message string `json:"message"`
-	}{
-		Success: true,
-		Message: "OAuth authentication successful",
+		return "", fmt.Errorf("error generating OAuth session ID: %w", err)
 	}
-	response.Data.Username = userInfo.Data.Name
-	response.Data.AccessToken = token.AccessToken
-	response.Data.RefreshToken = token.RefreshToken
-	response.Data.ExpiresIn = token.ExpiresIn
-
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		config.ErrorLogger.Printf("Error encoding OAuth response: %v", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-	}
-}
 
-// CreateOAuthClient creates an HTTP client that automatically adds OAuth authentication
-func CreateOAuthClient(accessToken string) *http.Client {
-	return &http.Client{
-		Transport: &OAuthTransport{
-			Token:     accessToken,
-			UserAgent: config.UserAgent,
-			Base:      http.DefaultTransport,
-		},
-	}
-}
-
-// OAuthTransport is an http.RoundTripper that adds OAuth headers to requests
-type OAuthTransport struct {
-	Token     string
-	UserAgent string
-	Base      http.RoundTripper
-}
-
-// RoundTrip implements the http.RoundTripper interface
-func (t *OAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	// Clone the request to avoid modifying the original
-	req2 := req.Clone(context.Background())
-	req2.Header.Set("Authorization", fmt.Sprintf("Bearer %s", t.Token))
-	req2.Header.Set("User-Agent", t.UserAgent)
-
-	// Update the host for OAuth endpoints
-	if strings.Contains(req2.URL.Host, "reddit.com") && !strings.HasPrefix(req2.URL.Host, "oauth.") {
-		req2.URL.Host = "oauth.reddit.com"
-		req2.URL.Scheme = "https"
+	err = DefaultSessionStore.Update(sessionID, func(session *OAuthSession) error {
+		session.Flows[accountType] = OAuthFlow{
+			Config:       oauthConfig,
+			CodeVerifier: codeVerifier,
+			State:        state,
+			CreatedAt:    time.Now(),
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("error storing OAuth session: %w", err)
 	}
-
-	return t.Base.RoundTrip(req2)
+	return GetAuthorizationURL(oauthConfig, state, codeChallenge), nil
 }
 
 // OAuthInitRequest represents the request to initialize OAuth
 type OAuthInitRequest struct {
 	ClientID     string `json:"client_id"`
-	ClientSecret string `json:"client_secret"`
-	AccountType  string `json:"account_type"` // "source" or "dest"
+	ClientSecret string `json:"client_secret"` // Empty for installed apps
+	AccountType  string `json:"account_type"`  // "source" or "dest"
 }
 
 // OAuthInitResponse represents the response from OAuth initialization
@@ -419,19 +342,6 @@ type OAuthStatusResponse struct {
 	Message     string `json:"message"`
 }
 
-// OAuth session storage - in production, use a proper session store
-var oauthSessions = make(map[string]*OAuthSession)
-
-type OAuthSession struct {
-	ClientID     string
-	ClientSecret string
-	AccountType  string
-	State        string
-	AccessToken  string
-	Username     string
-	CreatedAt    time.Time
-}
-
 // OAuthInitHandler handles OAuth initialization requests
 func OAuthInitHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -445,8 +355,8 @@ func OAuthInitHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.ClientID == "" || req.ClientSecret == "" {
-		errorResponse(w, "Client ID and Client Secret are required", http.StatusBadRequest)
+	if req.ClientID == "" {
+		errorResponse(w, "Client ID is required", http.StatusBadRequest)
 		return
 	}
 
@@ -455,31 +365,12 @@ func OAuthInitHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Initialize OAuth with provided credentials
-	// TODO: Make this dynamic based on server address
-	InitOAuth(req.ClientID, req.ClientSecret, config.RedditOauthRedirectUri)
-
-	// Generate state for this OAuth session
-	state, err := GenerateState()
+	// TODO: Make the redirect URI dynamic based on server address
+	oauthConfig := NewOAuthConfig(req.ClientID, req.ClientSecret, config.RedditOauthRedirectUri)
+	authURL, err := startOAuthFlow(w, r, oauthConfig, req.AccountType)
 	if err != nil {
-		config.ErrorLogger.Printf("Error generating OAuth state: %v", err)
-		errorResponse(w, "Error generating OAuth state", http.StatusInternalServerError)
-		return
-	}
-
-	// Store session
-	sessionKey := fmt.Sprintf("%s_%s", req.AccountType, state)
-	oauthSessions[sessionKey] = &OAuthSession{
-		ClientID:     req.ClientID,
-		ClientSecret: req.ClientSecret,
-		AccountType:  req.AccountType,
-		State:        state,
-		CreatedAt:    time.Now(),
-	}
-
-	authURL := GetAuthorizationURL(state)
-	if authURL == "" {
-		errorResponse(w, "Failed to generate authorization URL", http.StatusInternalServerError)
+		config.ErrorLogger.Printf("Error starting OAuth flow: %v", err)
+		errorResponse(w, "Error initializing OAuth", http.StatusInternalServerError)
 		return
 	}
 
@@ -506,44 +397,25 @@ func OAuthStatusHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Find the session for this account type
-	var session *OAuthSession
-	var sessionKey string
-	for key, sess := range oauthSessions {
-		if sess.AccountType == accountType && sess.AccessToken != "" {
-			session = sess
-			sessionKey = key
-			break
-		}
-	}
-
-	if session == nil {
-		response := OAuthStatusResponse{
-			Success: false,
-			Message: "OAuth session not found or not completed",
-		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
+	// Find the flow for this account type in the browser's session
+	response := OAuthStatusResponse{
+		Success: false,
+		Message: "OAuth session not found or not completed",
+	}
+	session, err := DefaultSessionStore.Get(oauthSessionID(r))
+	if errors.Is(err, ErrSessionNotFound) {
+		response.Message = "OAuth session not found or expired"
+	} else if err != nil {
+		config.ErrorLogger.Printf("Error reading OAuth session: %v", err)
+		errorResponse(w, "Error reading OAuth session", http.StatusInternalServerError)
 		return
-	}
-
-	// Clean up old sessions (optional)
-	if time.Since(session.CreatedAt) > 10*time.Minute {
-		delete(oauthSessions, sessionKey)
-		response := OAuthStatusResponse{
-			Success: false,
-			Message: "OAuth session expired",
+	} else if flow := session.Flows[accountType]; flow.AccessToken != "" {
+		response = OAuthStatusResponse{
+			Success:     true,
+			AccessToken: flow.AccessToken,
+			Username:    flow.Username,
+			Message:     "OAuth completed successfully",
 		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
-		return
-	}
-
-	response := OAuthStatusResponse{
-		Success:     true,
-		AccessToken: session.AccessToken,
-		Username:    session.Username,
-		Message:     "OAuth completed successfully",
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -562,30 +434,37 @@ func EnhancedOAuthCallbackHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Find the session for this state
-	var session *OAuthSession
-	var sessionKey string
-	for key, sess := range oauthSessions {
-		if sess.State == state {
-			session = sess
-			sessionKey = key
-			break
-		}
+	// Find the flow for this state in the browser's session. A state from another browser is never found,
+	// so a callback link cannot be used to log someone else's browser in.
+	sessionID := oauthSessionID(r)
+	session, err := DefaultSessionStore.Get(sessionID)
+	if err != nil {
+		config.ErrorLogger.Printf("OAuth session not found for callback: %v", err)
+		errorResponse(w, "OAuth session not found or expired", http.StatusBadRequest)
+		return
 	}
-
-	if session == nil {
-		config.ErrorLogger.Printf("OAuth session not found for state: %s", state)
+	accountType, flow, ok := session.flowByState(state)
+	if !ok {
+		config.ErrorLogger.Printf("OAuth state does not match any flow of the session")
 		errorResponse(w, "Invalid OAuth state", http.StatusBadRequest)
 		return
 	}
-
-	// Initialize OAuth with session credentials
-	InitOAuth(session.ClientID, session.ClientSecret, config.RedditOauthRedirectUri)
+	endFlow := func() {
+		err := DefaultSessionStore.Update(sessionID, func(session *OAuthSession) error {
+			if session.Flows[accountType].State == state {
+				delete(session.Flows, accountType)
+			}
+			return nil
+		})
+		if err != nil {
+			config.ErrorLogger.Printf("Error removing OAuth flow: %v", err)
+		}
+	}
 
 	// Check for errors from Reddit
 	if errCode := r.URL.Query().Get("error"); errCode != "" {
 		config.ErrorLogger.Printf("OAuth error from Reddit: %s", errCode)
-		delete(oauthSessions, sessionKey)
+		endFlow()
 		errorResponse(w, fmt.Sprintf("OAuth authorization denied: %s", errCode), http.StatusBadRequest)
 		return
 	}
@@ -594,16 +473,16 @@ func EnhancedOAuthCallbackHandler(w http.ResponseWriter, r *http.Request) {
 	code := r.URL.Query().Get("code")
 	if code == "" {
 		config.ErrorLogger.Printf("No authorization code in OAuth callback")
-		delete(oauthSessions, sessionKey)
+		endFlow()
 		errorResponse(w, "No authorization code received", http.StatusBadRequest)
 		return
 	}
 
 	// Exchange code for token
-	token, err := ExchangeCodeForToken(code)
+	token, err := ExchangeCodeForToken(flow.Config, code, flow.CodeVerifier)
 	if err != nil {
 		config.ErrorLogger.Printf("Error exchanging code for token: %v", err)
-		delete(oauthSessions, sessionKey)
+		endFlow()
 		errorResponse(w, "Error obtaining access token", http.StatusInternalServerError)
 		return
 	}
@@ -612,17 +491,29 @@ func EnhancedOAuthCallbackHandler(w http.ResponseWriter, r *http.Request) {
 	userInfo, err := GetUserInfoWithToken(token.AccessToken)
 	if err != nil {
 		config.ErrorLogger.Printf("Error fetching user info: %v", err)
-		delete(oauthSessions, sessionKey)
+		endFlow()
 		errorResponse(w, "Error fetching user information", http.StatusInternalServerError)
 		return
 	}
 
-	// Update session with token and user info
-	session.AccessToken = token.AccessToken
-	session.Username = userInfo.Data.Name
-	oauthSessions[sessionKey] = session
+	// Update the flow with token and user info, unless it was restarted in the meantime
+	err = DefaultSessionStore.Update(sessionID, func(session *OAuthSession) error {
+		current, ok := session.Flows[accountType]
+		if !ok || current.State != state {
+			return ErrSessionNotFound
+		}
+		current.AccessToken = token.AccessToken
+		current.Username = userInfo.Data.Name
+		session.Flows[accountType] = current
+		return nil
+	})
+	if err != nil {
+		config.ErrorLogger.Printf("Error storing OAuth result: %v", err)
+		errorResponse(w, "OAuth session not found or expired", http.StatusBadRequest)
+		return
+	}
 
-	config.InfoLogger.Printf("OAuth login successful for user: %s (account type: %s)", userInfo.Data.Name, session.AccountType)
+	config.InfoLogger.Printf("OAuth login successful for user: %s (account type: %s)", userInfo.Data.Name, accountType)
 
 	// Close the popup window
 	w.Header().Set("Content-Type", "text/html")
@@ -719,27 +610,21 @@ func DirectAuthenticate(clientID, clientSecret, username, password string) (*OAu
 		"password":   {password},
 	}
 
-	req, err := http.NewRequest("POST", "https://www.reddit.com/api/v1/access_token", strings.NewReader(data.Encode()))
+	client := reddit.DefaultClient()
+	req, err := client.NewFormRequest(context.Background(), http.MethodPost, client.WebURL("/api/v1/access_token"), "", data)
 	if err != nil {
 		return nil, fmt.Errorf("error creating direct auth request: %w", err)
 	}
 
 	req.SetBasicAuth(clientID, clientSecret)
-	req.Header.Set("User-Agent", config.UserAgent)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	config.DebugLogger.Printf("Performing direct authentication for user: %s", username)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error sending direct auth request: %w", err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading direct auth response: %w", err)
-	}
+	body := resp.Body
 
 	if resp.StatusCode != http.StatusOK {
 		config.ErrorLogger.Printf("Direct authentication failed with status %d: %s", resp.StatusCode, string(body))
@@ -0,0 +1,372 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/reddit"
+	"github.com/nileshnk/reddit-migrate/internal/reddittest"
+	"github.com/nileshnk/reddit-migrate/internal/vault"
+)
+
+func TestMain(m *testing.M) {
+	config.InfoLogger = log.New(io.Discard, "", 0)
+	config.ErrorLogger = log.New(io.Discard, "", 0)
+	config.DebugLogger = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+func newTestServer(t *testing.T) *reddittest.Server {
+	t.Helper()
+	srv := reddittest.NewServer()
+	t.Cleanup(srv.Close)
+	reddit.SetDefaultClient(srv.Client())
+	t.Cleanup(func() { reddit.SetDefaultClient(nil) })
+	srv.AddAccount(&reddittest.Account{
+		Name:         "alice",
+		Password:     "hunter2",
+		Token:        "access-" + t.Name(),
+		RefreshToken: "refresh-" + t.Name(),
+		Code:         "code-" + t.Name(),
+	})
+	return srv
+}
+
+func TestTokenGrants(t *testing.T) {
+	newTestServer(t)
+	oauthConfig := NewOAuthConfig("client", "secret", "http://localhost/callback")
+	want := "access-" + t.Name()
+
+	token, err := DirectAuthenticate("client", "secret", "alice", "hunter2")
+	if err != nil || token.AccessToken != want {
+		t.Fatalf("DirectAuthenticate() = %+v, %v; want access token %q", token, err, want)
+	}
+	if _, err := DirectAuthenticate("client", "secret", "alice", "wrong"); err == nil {
+		t.Fatal("DirectAuthenticate() accepted a wrong password")
+	}
+
+	token, err = ExchangeCodeForToken(oauthConfig, "code-"+t.Name(), "")
+	if err != nil || token.AccessToken != want {
+		t.Fatalf("ExchangeCodeForToken() = %+v, %v; want access token %q", token, err, want)
+	}
+
+	token, err = RefreshAccessToken(context.Background(), oauthConfig, "refresh-"+t.Name())
+	if err != nil || token.AccessToken != want || token.RefreshToken != "refresh-"+t.Name() {
+		t.Fatalf("RefreshAccessToken() = %+v, %v", token, err)
+	}
+}
+
+// oauthBrowser drives the OAuth handlers like a browser, keeping the session cookie between requests.
+type oauthBrowser struct {
+	t       *testing.T
+	cookies []*http.Cookie
+}
+
+func (b *oauthBrowser) request(method, target, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	for _, cookie := range b.cookies {
+		r.AddCookie(cookie)
+	}
+	return r
+}
+
+// start runs OAuthInitHandler and returns the state and the PKCE challenge of the authorization URL.
+func (b *oauthBrowser) start(clientID, clientSecret, accountType string) (state, challenge string) {
+	b.t.Helper()
+	body, _ := json.Marshal(OAuthInitRequest{ClientID: clientID, ClientSecret: clientSecret, AccountType: accountType})
+	rec := httptest.NewRecorder()
+	OAuthInitHandler(rec, b.request(http.MethodPost, "/api/oauth/init", string(body)))
+	var init OAuthInitResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &init); err != nil || !init.Success {
+		b.t.Fatalf("OAuthInitHandler() = %d %s", rec.Code, rec.Body)
+	}
+	if cookies := rec.Result().Cookies(); len(cookies) > 0 {
+		b.cookies = cookies
+	}
+	authURL, err := url.Parse(init.AuthorizationURL)
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	query := authURL.Query()
+	if query.Get("client_id") != clientID || query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256" {
+		b.t.Fatalf("authorization URL %s lacks the client ID or an S256 code challenge", authURL)
+	}
+	return query.Get("state"), query.Get("code_challenge")
+}
+
+// callback runs EnhancedOAuthCallbackHandler as Reddit's redirect would and returns its status code.
+func (b *oauthBrowser) callback(state, code string) int {
+	rec := httptest.NewRecorder()
+	query := url.Values{"state": {state}, "code": {code}}.Encode()
+	EnhancedOAuthCallbackHandler(rec, b.request(http.MethodGet, "/api/oauth/callback?"+query, ""))
+	return rec.Code
+}
+
+// status runs OAuthStatusHandler for accountType.
+func (b *oauthBrowser) status(accountType string) OAuthStatusResponse {
+	b.t.Helper()
+	rec := httptest.NewRecorder()
+	OAuthStatusHandler(rec, b.request(http.MethodGet, "/api/oauth/status?account_type="+accountType, ""))
+	var status OAuthStatusResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		b.t.Fatalf("OAuthStatusHandler() = %d %s", rec.Code, rec.Body)
+	}
+	return status
+}
+
+func TestAuthorizationURLScopes(t *testing.T) {
+	newTestServer(t)
+	authURL, err := url.Parse(GetAuthorizationURL(NewOAuthConfig("id", "secret", "http://localhost/callback"), "state", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	granted := make(map[string]bool)
+	for _, scope := range strings.Fields(authURL.Query().Get("scope")) {
+		granted[scope] = true
+	}
+	// The scopes of the endpoints every migration phase calls. Reddit answers 403 to a token without them.
+	for _, scope := range []string{
+		"identity",     // /api/v1/me
+		"mysubreddits", // /subreddits/mine
+		"subscribe",    // /api/subscribe, /api/multi, /api/v1/me/friends
+		"history",      // /user/{username}/saved, upvoted, downvoted and hidden
+		"save",         // /api/save, /api/unsave
+		"vote",         // /api/vote
+		"report",       // /api/hide, /api/unhide
+		"read",         // /api/multi/mine, /prefs/blocked
+		"account",      // /api/block_user, PATCH /api/v1/me/prefs
+	} {
+		if !granted[scope] {
+			t.Errorf("authorization URL does not request the %q scope: %s", scope, authURL.Query().Get("scope"))
+		}
+	}
+}
+
+func TestInstalledAppFlow(t *testing.T) {
+	srv := newTestServer(t)
+	DefaultSessionStore = NewMemorySessionStore(OAuthSessionTTL)
+	browser := &oauthBrowser{t: t}
+
+	// An installed app has no secret. An intercepted code is useless without the verifier of the challenge it was
+	// requested with.
+	state, _ := browser.start("installed", "", "source")
+	srv.SetCodeChallenge("alice", "challenge-of-another-flow")
+	if code := browser.callback(state, "code-"+t.Name()); code == http.StatusOK {
+		t.Fatal("callback succeeded with a code verifier that does not match the challenge")
+	}
+
+	state, challenge := browser.start("installed", "", "source")
+	srv.SetCodeChallenge("alice", challenge)
+	if code := browser.callback(state, "code-"+t.Name()); code != http.StatusOK {
+		t.Fatalf("callback = %d, want 200", code)
+	}
+	if status := browser.status("source"); !status.Success || status.AccessToken != "access-"+t.Name() || status.Username != "alice" {
+		t.Fatalf("OAuthStatusHandler() = %+v", status)
+	}
+
+	// Another browser has no session cookie and sees nothing.
+	if status := (&oauthBrowser{t: t}).status("source"); status.Success {
+		t.Fatalf("OAuthStatusHandler() without session cookie = %+v", status)
+	}
+}
+
+func TestOAuthFlowsWithDifferentApps(t *testing.T) {
+	srv := newTestServer(t)
+	DefaultSessionStore = NewMemorySessionStore(OAuthSessionTTL)
+	srv.AddAccount(&reddittest.Account{
+		Name:         "bob",
+		Token:        "bob-access-" + t.Name(),
+		RefreshToken: "bob-refresh-" + t.Name(),
+		Code:         "bob-code-" + t.Name(),
+		ClientID:     "dest-app",
+	})
+	browser := &oauthBrowser{t: t}
+
+	// Both flows are started before either finishes; each must keep its own app.
+	sourceState, _ := browser.start("source-app", "source-secret", "source")
+	destState, _ := browser.start("dest-app", "", "dest")
+	if code := browser.callback(destState, "bob-code-"+t.Name()); code != http.StatusOK {
+		t.Fatalf("dest callback = %d, want 200", code)
+	}
+	if code := browser.callback(sourceState, "code-"+t.Name()); code != http.StatusOK {
+		t.Fatalf("source callback = %d, want 200", code)
+	}
+	source, dest := browser.status("source"), browser.status("dest")
+	if source.Username != "alice" || dest.Username != "bob" {
+		t.Fatalf("statuses = %+v, %+v", source, dest)
+	}
+
+	// The destination token must still be refreshed with the destination app after the source flow finished.
+	srv.ExpireToken("bob", "bob-renewed-"+t.Name())
+	tokenSource := TokenSourceFor(dest.AccessToken)
+	if tokenSource == nil {
+		t.Fatal("no token source for the destination token")
+	}
+	if got, err := tokenSource.Refresh(context.Background(), dest.AccessToken); err != nil || got != "bob-renewed-"+t.Name() {
+		t.Fatalf("Refresh() = %q, %v", got, err)
+	}
+}
+
+func TestGetUserInfoWithToken(t *testing.T) {
+	newTestServer(t)
+
+	info, err := GetUserInfoWithToken("access-" + t.Name())
+	if err != nil || info.Data.Name != "alice" {
+		t.Fatalf("GetUserInfoWithToken() = %+v, %v; want alice", info, err)
+	}
+	if _, err := GetUserInfoWithToken("invalid"); err == nil {
+		t.Fatal("GetUserInfoWithToken() accepted an invalid token")
+	}
+}
+
+func TestGetUsernameFromCookie(t *testing.T) {
+	newTestServer(t)
+
+	name, err := GetUsernameFromCookie("loid=1; token_v2=access-" + t.Name())
+	if err != nil || name != "alice" {
+		t.Fatalf("GetUsernameFromCookie() = %q, %v; want alice", name, err)
+	}
+	if _, err := GetUsernameFromCookie("token_v2=invalid"); err == nil {
+		t.Fatal("GetUsernameFromCookie() accepted an invalid cookie")
+	}
+}
+
+func TestTokenSourceRefreshesBeforeExpiry(t *testing.T) {
+	srv := newTestServer(t)
+	token, err := ExchangeCodeForToken(NewOAuthConfig("client", "secret", "http://localhost/callback"), "code-"+t.Name(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := TokenSourceFor(token.AccessToken)
+	if source == nil {
+		t.Fatal("no token source for a token with a refresh token")
+	}
+	srv.ExpireToken("alice", "renewed-"+t.Name())
+
+	ctx := context.Background()
+	if got, err := source.Token(ctx); err != nil || got != token.AccessToken {
+		t.Fatalf("Token() = %q, %v; want the unexpired original", got, err)
+	}
+
+	source.mu.Lock()
+	source.token.CreatedAt = time.Now().Add(-time.Hour)
+	source.mu.Unlock()
+	if got, err := source.Token(ctx); err != nil || got != "renewed-"+t.Name() {
+		t.Fatalf("Token() = %q, %v; want the refreshed token", got, err)
+	}
+	if TokenSourceFor("renewed-"+t.Name()) != source {
+		t.Fatal("refreshed token does not map to its source")
+	}
+
+	// A 401 for the replaced token must not refresh again.
+	requests := srv.Requests("/api/v1/access_token")
+	if got, err := source.Refresh(ctx, token.AccessToken); err != nil || got != "renewed-"+t.Name() {
+		t.Fatalf("Refresh() = %q, %v", got, err)
+	}
+	if n := srv.Requests("/api/v1/access_token"); n != requests {
+		t.Fatalf("stale Refresh() sent %d token requests", n-requests)
+	}
+}
+
+func TestTokenSourcesForgetReplacedAndExpiredTokens(t *testing.T) {
+	srv := newTestServer(t)
+	oauthConfig := NewOAuthConfig("client", "secret", "http://localhost/callback")
+	token, err := ExchangeCodeForToken(oauthConfig, "code-"+t.Name(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := TokenSourceFor(token.AccessToken)
+	ctx := context.Background()
+
+	for _, renewed := range []string{"renewed-" + t.Name(), "renewed-again-" + t.Name()} {
+		srv.ExpireToken("alice", renewed)
+		stale, _ := source.Token(ctx)
+		if got, err := source.Refresh(ctx, stale); err != nil || got != renewed {
+			t.Fatalf("Refresh() = %q, %v; want %q", got, err, renewed)
+		}
+	}
+	// The token handed out by the OAuth flow and the latest one are still known; the one in between is not.
+	if TokenSourceFor(token.AccessToken) != source || TokenSourceFor("renewed-again-"+t.Name()) != source {
+		t.Fatal("source not found by its original or latest token")
+	}
+	if TokenSourceFor("renewed-"+t.Name()) != nil {
+		t.Fatal("replaced token still maps to its source")
+	}
+
+	// Once its latest token has expired unused, the source is pruned when another token is remembered.
+	tokenSources.mu.Lock()
+	source.expires = time.Now().Add(-time.Second)
+	tokenSources.mu.Unlock()
+	rememberToken(oauthConfig, &OAuthToken{AccessToken: "other", RefreshToken: "other-refresh", ExpiresIn: 3600, CreatedAt: time.Now()})
+	if TokenSourceFor(token.AccessToken) != nil || TokenSourceFor("renewed-again-"+t.Name()) != nil {
+		t.Fatal("expired source was not pruned")
+	}
+	if TokenSourceFor("other") == nil {
+		t.Fatal("newly remembered token has no source")
+	}
+}
+
+func TestLoginProfile(t *testing.T) {
+	srv := newTestServer(t)
+	config.VaultPath = filepath.Join(t.TempDir(), "vault.json")
+	v, err := vault.Unlock("passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(vault.Lock)
+
+	ctx := context.Background()
+	profile, err := ProfileFromRefreshToken(ctx, "main", "client", "secret", "refresh-"+t.Name())
+	if err != nil || profile.Username != "alice" {
+		t.Fatalf("ProfileFromRefreshToken() = %+v, %v; want alice", profile, err)
+	}
+	if err := v.Put(profile); err != nil {
+		t.Fatal(err)
+	}
+	cookieProfile, err := ProfileFromCookie("cookie", "token_v2=access-"+t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Put(cookieProfile); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := srv.Requests("/api/v1/access_token")
+	token, username, err := LoginProfile(ctx, "main")
+	if err != nil || token != "access-"+t.Name() || username != "alice" {
+		t.Fatalf("LoginProfile() = %q, %q, %v", token, username, err)
+	}
+	if TokenSourceFor(token) == nil {
+		t.Fatal("profile token has no token source")
+	}
+	// The refreshed token is reused until it nears expiry.
+	if _, _, err := LoginProfile(ctx, "main"); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.Requests("/api/v1/access_token") - requests; n != 1 {
+		t.Errorf("two logins sent %d token requests, want 1", n)
+	}
+
+	if token, username, err := LoginProfile(ctx, "cookie"); err != nil || token != "access-"+t.Name() || username != "alice" {
+		t.Fatalf("LoginProfile() with a cookie profile = %q, %q, %v", token, username, err)
+	}
+	if _, _, err := LoginProfile(ctx, "missing"); !errors.Is(err, vault.ErrNotFound) {
+		t.Fatalf("LoginProfile() of a missing profile = %v, want ErrNotFound", err)
+	}
+	vault.Lock()
+	if _, _, err := LoginProfile(ctx, "main"); !errors.Is(err, vault.ErrLocked) {
+		t.Fatalf("LoginProfile() with a locked vault = %v, want ErrLocked", err)
+	}
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/vault"
+)
+
+// profileSources caches the TokenSource of each OAuth profile, so logging in with a profile again reuses its
+// current access token instead of refreshing on every use.
+var profileSources = struct {
+	mu      sync.Mutex
+	sources map[string]*TokenSource
+}{sources: make(map[string]*TokenSource)}
+
+// ProfileFromToken builds a profile for the account of accessToken. The token must have been obtained through this
+// server's OAuth flow with a refresh token, since only the refresh token and app credentials are stored.
+func ProfileFromToken(name, accessToken string) (vault.Profile, error) {
+	source := TokenSourceFor(accessToken)
+	if source == nil {
+		return vault.Profile{}, fmt.Errorf("this OAuth token has no refresh token and cannot be saved; connect the account through the OAuth flow first")
+	}
+	userInfo, err := GetUserInfoWithToken(accessToken)
+	if err != nil {
+		return vault.Profile{}, fmt.Errorf("failed to verify OAuth token: %w", err)
+	}
+
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	return vault.Profile{
+		Name:         name,
+		Username:     userInfo.Data.Name,
+		AuthMethod:   vault.AuthOAuth,
+		ClientID:     source.oauthConfig.ClientID,
+		ClientSecret: source.oauthConfig.ClientSecret,
+		RefreshToken: source.token.RefreshToken,
+	}, nil
+}
+
+// ProfileFromRefreshToken builds a profile from the credentials of a Reddit app and a refresh token it issued,
+// checking that they work by refreshing once.
+func ProfileFromRefreshToken(ctx context.Context, name, clientID, clientSecret, refreshToken string) (vault.Profile, error) {
+	oauthConfig := NewOAuthConfig(clientID, clientSecret, config.RedditOauthRedirectUri)
+	token, err := RefreshAccessToken(ctx, oauthConfig, refreshToken)
+	if err != nil {
+		return vault.Profile{}, err
+	}
+	rememberToken(oauthConfig, token)
+	userInfo, err := GetUserInfoWithToken(token.AccessToken)
+	if err != nil {
+		return vault.Profile{}, fmt.Errorf("failed to verify refreshed token: %w", err)
+	}
+	return vault.Profile{
+		Name:         name,
+		Username:     userInfo.Data.Name,
+		AuthMethod:   vault.AuthOAuth,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// ProfileFromCookie builds a profile for the account a full Reddit cookie string belongs to.
+func ProfileFromCookie(name, cookie string) (vault.Profile, error) {
+	username, err := GetUsernameFromCookie(cookie)
+	if err != nil {
+		return vault.Profile{}, err
+	}
+	return vault.Profile{Name: name, Username: username, AuthMethod: vault.AuthCookie, Cookie: cookie}, nil
+}
+
+// LoginProfile returns an access token and the username for the profile called name in the unlocked vault.
+// Tokens of OAuth profiles are refreshed from the stored refresh token and stay known to TokenSourceFor, so
+// migrations started with them keep them fresh like tokens from the OAuth flow.
+func LoginProfile(ctx context.Context, name string) (token, username string, err error) {
+	v, err := vault.Current()
+	if err != nil {
+		return "", "", err
+	}
+	profile, err := v.Profile(name)
+	if err != nil {
+		return "", "", err
+	}
+
+	if profile.AuthMethod == vault.AuthCookie {
+		username, err := GetUsernameFromCookie(profile.Cookie)
+		if err != nil {
+			return "", "", fmt.Errorf("profile %s: %w", name, err)
+		}
+		token := ParseTokenFromCookie(profile.Cookie)
+		if token == "" {
+			return "", "", fmt.Errorf("profile %s: cookie has no token_v2", name)
+		}
+		return token, username, nil
+	}
+
+	token, err = profileSource(profile).Token(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("profile %s: %w", name, err)
+	}
+	username = profile.Username
+	if username == "" {
+		userInfo, err := GetUserInfoWithToken(token)
+		if err != nil {
+			return "", "", fmt.Errorf("profile %s: %w", name, err)
+		}
+		username = userInfo.Data.Name
+	}
+	config.InfoLogger.Printf("Logged in with profile %s as %s.", name, username)
+	return token, username, nil
+}
+
+// profileSource returns the cached TokenSource of an OAuth profile, replacing it when the profile's credentials changed.
+// A new source has no access token yet, so its first Token call refreshes.
+func profileSource(profile vault.Profile) *TokenSource {
+	profileSources.mu.Lock()
+	defer profileSources.mu.Unlock()
+	if source, ok := profileSources.sources[profile.Name]; ok {
+		source.mu.Lock()
+		same := source.oauthConfig.ClientID == profile.ClientID && source.oauthConfig.ClientSecret == profile.ClientSecret &&
+			source.token.RefreshToken == profile.RefreshToken
+		source.mu.Unlock()
+		if same {
+			return source
+		}
+	}
+	source := &TokenSource{
+		oauthConfig: NewOAuthConfig(profile.ClientID, profile.ClientSecret, config.RedditOauthRedirectUri),
+		token:       OAuthToken{RefreshToken: profile.RefreshToken},
+	}
+	profileSources.sources[profile.Name] = source
+	return source
+}
@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrSessionNotFound is returned for unknown and expired OAuth sessions.
+var ErrSessionNotFound = errors.New("OAuth session not found or expired")
+
+// OAuthSessionTTL is how long an OAuth session is kept after it was last changed.
+// It bounds the time a user has to approve the app on Reddit.
+const OAuthSessionTTL = 10 * time.Minute
+
+// oauthSessionCookie holds the opaque ID of the browser's OAuth session.
+const oauthSessionCookie = "oauth_session"
+
+// OAuthFlow is the state of one authorization of the source or destination account.
+type OAuthFlow struct {
+	Config       OAuthConfig `json:"config"`        // App the account is being authorized with
+	CodeVerifier string      `json:"code_verifier"` // PKCE verifier of the authorization request
+	State        string      `json:"state"`
+	AccessToken  string      `json:"access_token,omitempty"` // Set once Reddit redirected back with a code
+	Username     string      `json:"username,omitempty"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+// OAuthSession is the OAuth state of one browser, identified by the opaque ID in its session cookie.
+type OAuthSession struct {
+	Flows     map[string]OAuthFlow `json:"flows"` // By account type, "source" or "dest"
+	ExpiresAt time.Time            `json:"expires_at"`
+}
+
+// flowByState returns the account type and flow whose authorization request was made with state.
+func (s OAuthSession) flowByState(state string) (string, OAuthFlow, bool) {
+	for accountType, flow := range s.Flows {
+		if state != "" && flow.State == state {
+			return accountType, flow, true
+		}
+	}
+	return "", OAuthFlow{}, false
+}
+
+// SessionStore keeps OAuth sessions between the requests of a flow. Implementations must be safe for concurrent use.
+type SessionStore interface {
+	// Get returns a copy of the session with the given ID, or ErrSessionNotFound.
+	Get(id string) (OAuthSession, error)
+	// Update calls update with the session with the given ID, which is empty if there is none yet, and stores the
+	// result unless update fails. Updates of one store are serialized, and each restarts the session's TTL.
+	Update(id string, update func(session *OAuthSession) error) error
+	// Delete removes the session with the given ID, if any.
+	Delete(id string) error
+}
+
+// DefaultSessionStore holds the OAuth sessions of the HTTP handlers. It keeps them in memory unless replaced at startup.
+var DefaultSessionStore SessionStore = NewMemorySessionStore(OAuthSessionTTL)
+
+// ttlSessionStore is a mutex-protected map of sessions that drops sessions ttl after their last update.
+// When save is set, the sessions are written through to it after every change.
+type ttlSessionStore struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	sessions map[string]OAuthSession
+	save     func(sessions map[string]OAuthSession) error
+}
+
+// NewMemorySessionStore creates an in-memory session store whose sessions expire ttl after their last update.
+func NewMemorySessionStore(ttl time.Duration) SessionStore {
+	return &ttlSessionStore{ttl: ttl, sessions: make(map[string]OAuthSession)}
+}
+
+// NewFileSessionStore creates a session store that is also written to path, so OAuth flows survive a restart of the
+// server. The file holds client secrets and access tokens and is only readable by the current user.
+func NewFileSessionStore(path string, ttl time.Duration) (SessionStore, error) {
+	store := &ttlSessionStore{ttl: ttl, sessions: make(map[string]OAuthSession)}
+	content, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("error reading OAuth sessions: %w", err)
+	default:
+		if err := json.Unmarshal(content, &store.sessions); err != nil {
+			return nil, fmt.Errorf("error parsing OAuth sessions %s: %w", path, err)
+		}
+		store.pruneLocked()
+	}
+	store.save = func(sessions map[string]OAuthSession) error { return saveSessions(path, sessions) }
+	return store, nil
+}
+
+func (s *ttlSessionStore) Get(id string) (OAuthSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked()
+	session, ok := s.sessions[id]
+	if !ok {
+		return OAuthSession{}, ErrSessionNotFound
+	}
+	return session.clone(), nil
+}
+
+func (s *ttlSessionStore) Update(id string, update func(session *OAuthSession) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked()
+	session := s.sessions[id].clone()
+	if err := update(&session); err != nil {
+		return err
+	}
+	session.ExpiresAt = time.Now().Add(s.ttl)
+	s.sessions[id] = session
+	return s.saveLocked()
+}
+
+func (s *ttlSessionStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[id]; !ok {
+		return nil
+	}
+	delete(s.sessions, id)
+	return s.saveLocked()
+}
+
+// pruneLocked drops expired sessions. The caller must hold s.mu.
+func (s *ttlSessionStore) pruneLocked() {
+	now := time.Now()
+	for id, session := range s.sessions {
+		if now.After(session.ExpiresAt) {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+// saveLocked writes the sessions through to the file of a file-backed store. The caller must hold s.mu.
+func (s *ttlSessionStore) saveLocked() error {
+	if s.save == nil {
+		return nil
+	}
+	return s.save(s.sessions)
+}
+
+// clone returns a copy of s that shares no map with it, with an empty flow map for the zero session.
+func (s OAuthSession) clone() OAuthSession {
+	flows := make(map[string]OAuthFlow, len(s.Flows))
+	for accountType, flow := range s.Flows {
+		flows[accountType] = flow
+	}
+	s.Flows = flows
+	return s
+}
+
+// saveSessions atomically replaces the session file at path.
+func saveSessions(path string, sessions map[string]OAuthSession) error {
+	content, err := json.Marshal(sessions)
+	if err != nil {
+		return fmt.Errorf("error encoding OAuth sessions: %w", err)
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("error creating OAuth session directory %s: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, ".oauth-sessions-*.tmp")
+	if err != nil {
+		return fmt.Errorf("error writing OAuth sessions: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed.
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing OAuth sessions: %w", err)
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing OAuth sessions: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing OAuth sessions: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error writing OAuth sessions %s: %w", path, err)
+	}
+	return nil
+}
+
+// oauthSessionID returns the ID in the request's session cookie, or "" if there is none.
+func oauthSessionID(r *http.Request) string {
+	cookie, err := r.Cookie(oauthSessionCookie)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// ensureOAuthSessionID returns the ID of the request's session when the store knows it, and otherwise issues a
+// new random ID in the session cookie. IDs are never taken from a cookie the server did not recently issue.
+func ensureOAuthSessionID(w http.ResponseWriter, r *http.Request) (string, error) {
+	if id := oauthSessionID(r); id != "" {
+		if _, err := DefaultSessionStore.Get(id); err == nil {
+			return id, nil
+		}
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	id := base64.RawURLEncoding.EncodeToString(b)
+	// Lax, so the cookie comes along when Reddit redirects the browser back to the callback.
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthSessionCookie,
+		Value:    id,
+		Path:     "/api/oauth",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return id, nil
+}
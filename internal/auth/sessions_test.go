@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMemorySessionStoreExpires(t *testing.T) {
+	store := NewMemorySessionStore(50 * time.Millisecond)
+	err := store.Update("a", func(session *OAuthSession) error {
+		session.Flows["source"] = OAuthFlow{State: "state-a"}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := store.Get("a")
+	if err != nil || session.Flows["source"].State != "state-a" {
+		t.Fatalf("Get() = %+v, %v", session, err)
+	}
+	// Get returns a copy that does not change the stored session.
+	delete(session.Flows, "source")
+	if session, _ := store.Get("a"); session.Flows["source"].State != "state-a" {
+		t.Fatal("changing the result of Get() changed the stored session")
+	}
+
+	// A failing update leaves the session alone.
+	failed := errors.New("failed")
+	err = store.Update("a", func(session *OAuthSession) error {
+		session.Flows["dest"] = OAuthFlow{State: "state-b"}
+		return failed
+	})
+	if session, _ := store.Get("a"); !errors.Is(err, failed) || len(session.Flows) != 1 {
+		t.Fatalf("Update() = %v, session after it = %+v", err, session)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if _, err := store.Get("a"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("Get() after the TTL = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestFileSessionStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	store, err := NewFileSessionStore(path, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"a", "b"} {
+		err := store.Update(id, func(session *OAuthSession) error {
+			session.Flows["dest"] = OAuthFlow{Config: NewOAuthConfig("client-"+id, "", "")}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Delete("b"); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("session file: %v, %v; want mode 0600", info, err)
+	}
+
+	reopened, err := NewFileSessionStore(path, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session, err := reopened.Get("a"); err != nil || session.Flows["dest"].Config.ClientID != "client-a" {
+		t.Fatalf("Get() after reopening = %+v, %v", session, err)
+	}
+	if _, err := reopened.Get("b"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("Get() of a deleted session = %v, want ErrSessionNotFound", err)
+	}
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+)
+
+// tokenRefreshMargin is how long before its expiry a token is refreshed, so that a request waiting on the rate limiter
+// does not go out with a token that has expired in the meantime.
+const tokenRefreshMargin = 5 * time.Minute
+
+// TokenSource keeps the OAuth token of one account fresh using its refresh token. It implements reddit.TokenSource.
+type TokenSource struct {
+	mu          sync.Mutex
+	oauthConfig OAuthConfig // App the token was issued to; refreshing needs the same client credentials.
+	token       OAuthToken
+
+	// Guarded by tokenSources.mu.
+	issued  string    // Access token the source was remembered with, which callers keep presenting
+	current string    // Access token after the latest refresh
+	expires time.Time // When current expires
+}
+
+// tokenSources holds a TokenSource for every access token obtained together with a refresh token, so that a migration
+// started with the access token can keep it fresh. A source is found by the token it was remembered with and by its
+// latest refreshed token; tokens in between are forgotten as they are replaced. Sources whose latest token has
+// expired unused are pruned whenever a new token is remembered.
+var tokenSources = struct {
+	mu      sync.Mutex
+	sources map[string]*TokenSource
+}{sources: make(map[string]*TokenSource)}
+
+// rememberToken records token, issued to the app described by oauthConfig, for TokenSourceFor.
+// Tokens without a refresh token cannot be renewed and are not recorded.
+func rememberToken(oauthConfig OAuthConfig, token *OAuthToken) {
+	if token.RefreshToken == "" || token.AccessToken == "" {
+		return
+	}
+	source := &TokenSource{
+		oauthConfig: oauthConfig,
+		token:       *token,
+		issued:      token.AccessToken,
+		current:     token.AccessToken,
+		expires:     token.expiresAt(),
+	}
+	tokenSources.mu.Lock()
+	defer tokenSources.mu.Unlock()
+	now := time.Now()
+	for accessToken, other := range tokenSources.sources {
+		if now.After(other.expires) {
+			delete(tokenSources.sources, accessToken)
+		}
+	}
+	tokenSources.sources[token.AccessToken] = source
+}
+
+// TokenSourceFor returns the source that keeps accessToken fresh, or nil if accessToken was not obtained through
+// this server's OAuth flow with a refresh token (e.g. it was pasted in or taken from a cookie).
+func TokenSourceFor(accessToken string) *TokenSource {
+	tokenSources.mu.Lock()
+	defer tokenSources.mu.Unlock()
+	return tokenSources.sources[accessToken]
+}
+
+// Token returns the current access token, refreshing it first if it expires within tokenRefreshMargin or there is
+// none yet. If that refresh fails, the current token is still returned as long as it has not expired.
+func (s *TokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token.AccessToken == "" || (s.token.ExpiresIn > 0 && s.token.expiresWithin(tokenRefreshMargin)) {
+		config.InfoLogger.Printf("Access token ending ...%s expires soon. Refreshing it.", SafeSuffix(s.token.AccessToken, 6))
+		if err := s.refreshLocked(ctx); err != nil {
+			if s.token.IsExpired() {
+				return "", err
+			}
+			config.ErrorLogger.Printf("Could not refresh access token, using it until it expires: %v", err)
+		}
+	}
+	return s.token.AccessToken, nil
+}
+
+// Refresh renews the access token after Reddit rejected stale, unless stale has already been replaced.
+func (s *TokenSource) Refresh(ctx context.Context, stale string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token.AccessToken != stale {
+		return s.token.AccessToken, nil
+	}
+	if err := s.refreshLocked(ctx); err != nil {
+		return "", err
+	}
+	return s.token.AccessToken, nil
+}
+
+// refreshLocked replaces the token with a refreshed one. s.mu must be held.
+func (s *TokenSource) refreshLocked(ctx context.Context) error {
+	token, err := RefreshAccessToken(ctx, s.oauthConfig, s.token.RefreshToken)
+	if err != nil {
+		return err
+	}
+	s.token = *token
+
+	tokenSources.mu.Lock()
+	defer tokenSources.mu.Unlock()
+	if s.current != s.issued && tokenSources.sources[s.current] == s {
+		delete(tokenSources.sources, s.current)
+	}
+	s.current, s.expires = token.AccessToken, token.expiresAt()
+	tokenSources.sources[token.AccessToken] = s
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log" // Using standard log for now, actual logger injection TBD
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -53,6 +54,17 @@ func getEnvOrDefaultDuration(key string, defaultValue time.Duration) time.Durati
 	return defaultValue
 }
 
+// getEnvList retrieves a comma-separated environment variable as a list, skipping empty entries.
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 // getServerAddress determines the server address based on environment variables,
 // command-line arguments, or a default value.
 func GetServerAddress() string {
@@ -91,13 +103,27 @@ var (
 	DefaultSubredditChunkSize int
 	MaxSubredditRetryAttempts int
 	DefaultPostConcurrency    int
-	DefaultAPITimeout         time.Duration // General API client timeout
-	TestAPITimeout            time.Duration // Timeout for testRedditAPI in saved_posts.go and similar tests
+	DefaultAPITimeout         time.Duration // Timeout of a single request to Reddit
+	MaxAPIRetries             int           // Retries of a request to Reddit after a network error or a 502/503/504
 
 	// Rate Limiter settings for saved_posts.go
-	RateLimitSleepInterval time.Duration // Derived from RATE_LIMIT_SLEEP_INTERVAL_MINUTES
-	RateLimitInterval      time.Duration // Derived from RATE_LIMIT_INTERVAL_MINUTES
+	RateLimitSleepInterval time.Duration // Wait after a 429 that carries no X-Ratelimit-Reset header. Derived from RATE_LIMIT_SLEEP_INTERVAL_SECONDS
+	RateLimitInterval      time.Duration // Fallback window until Reddit reports one. Derived from RATE_LIMIT_INTERVAL_SECONDS
 	MaxTokensPerInterval   int           // MAX_TOKENS_PER_INTERVAL
+
+	// Background job settings
+	JobRetention time.Duration // How long finished jobs are kept for status queries
+	JournalDir   string        // Directory holding per-job checkpoint journals used to resume migrations
+
+	// Credential vault settings
+	VaultPath       string // Encrypted file holding saved account profiles
+	VaultPassphrase string // Unlocks the vault at startup when set. Never logged
+
+	// OAuth settings
+	OAuthSessionFile string // Keeps unfinished OAuth flows across restarts when set; they are held in memory otherwise
+
+	// Web UI settings
+	AllowedHosts []string // Host names the web UI answers to besides localhost and the host of the listen address
 )
 
 // LoadConfig loads configuration from environment variables.
@@ -121,7 +147,7 @@ func LoadConfig() {
 
 	// Durations from env are expected in seconds
 	DefaultAPITimeout = getEnvOrDefaultDuration("DEFAULT_API_TIMEOUT_SECONDS", 30*time.Second)
-	TestAPITimeout = getEnvOrDefaultDuration("TEST_API_TIMEOUT_SECONDS", 15*time.Second)
+	MaxAPIRetries = getEnvOrDefaultInt("MAX_API_RETRIES", 2)
 
 	// Rate Limiter settings
 	// Store them as time.Duration directly where applicable
@@ -132,6 +158,12 @@ func LoadConfig() {
 	RateLimitInterval = time.Duration(rateLimitIntervalSeconds) * time.Second // Note: Original code had time.Minute here, might be error. Assuming seconds as per var name.
 
 	MaxTokensPerInterval = getEnvOrDefaultInt("MAX_TOKENS_PER_INTERVAL", 50)
+	JobRetention = getEnvOrDefaultDuration("JOB_RETENTION_SECONDS", time.Hour)
+	JournalDir = getEnvOrDefault("JOURNAL_DIR", defaultJournalDir())
+	VaultPath = getEnvOrDefault("VAULT_PATH", defaultVaultPath())
+	VaultPassphrase = os.Getenv("VAULT_PASSPHRASE")
+	OAuthSessionFile = os.Getenv("OAUTH_SESSION_FILE")
+	AllowedHosts = getEnvList("ALLOWED_HOSTS")
 	ServerAddress = GetServerAddress()
 	RedditOauthRedirectUri = fmt.Sprintf("http://%s/api/oauth/callback", ServerAddress)
 
@@ -143,14 +175,39 @@ func LoadConfig() {
 		DebugLogger.Printf("MaxSubredditRetryAttempts: %d", MaxSubredditRetryAttempts)
 		DebugLogger.Printf("DefaultPostConcurrency: %d", DefaultPostConcurrency)
 		DebugLogger.Printf("DefaultAPITimeout: %v", DefaultAPITimeout)
-		DebugLogger.Printf("TestAPITimeout: %v", TestAPITimeout)
+		DebugLogger.Printf("MaxAPIRetries: %d", MaxAPIRetries)
 		// Corrected logging for duration: originally RATE_LIMIT_SLEEP_INTERVAL_SECONDS was multiplied by time.Minute
 		DebugLogger.Printf("RateLimitSleepInterval: %v (from %d seconds)", RateLimitSleepInterval, rateLimitSleepSeconds)
 		DebugLogger.Printf("RateLimitInterval: %v (from %d seconds)", RateLimitInterval, rateLimitIntervalSeconds)
 		DebugLogger.Printf("MaxTokensPerInterval: %d", MaxTokensPerInterval)
+		DebugLogger.Printf("JobRetention: %v", JobRetention)
+		DebugLogger.Printf("JournalDir: %s", JournalDir)
+		DebugLogger.Printf("VaultPath: %s", VaultPath)
+		DebugLogger.Printf("OAuthSessionFile: %s", OAuthSessionFile)
+		DebugLogger.Printf("AllowedHosts: %v", AllowedHosts)
 	}
 
 	if InfoLogger != nil {
 		InfoLogger.Println("Configuration loaded.")
 	}
 }
+
+// defaultJournalDir returns the journal directory under the user's config directory,
+// falling back to a directory next to the working directory when that cannot be determined.
+func defaultJournalDir() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join(".reddit-migrate", "journals")
+	}
+	return filepath.Join(configDir, "reddit-migrate", "journals")
+}
+
+// defaultVaultPath returns the credential vault file under the user's configuration directory,
+// falling back to a directory next to the working directory when it cannot be determined.
+func defaultVaultPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join(".reddit-migrate", "vault.json")
+	}
+	return filepath.Join(configDir, "reddit-migrate", "vault.json")
+}
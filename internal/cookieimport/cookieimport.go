@@ -0,0 +1,288 @@
+// Package cookieimport extracts the Reddit session cookies from browser cookie exports, so users can upload a file
+// instead of copying the Cookie header out of the browser's developer tools.
+//
+// Supported formats are Netscape cookies.txt files (as written by curl, wget and most "export cookies" browser
+// extensions), HAR files saved from the network tab of the developer tools, and copies of Firefox's cookies.sqlite.
+package cookieimport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnknownFormat is returned when data is not a cookies.txt file, a HAR file or a Firefox cookie database.
+var ErrUnknownFormat = errors.New("unrecognized cookie file: expected a cookies.txt, HAR or Firefox cookies.sqlite file")
+
+// ErrNoRedditCookies is returned when a cookie file holds no unexpired cookies for reddit.com.
+var ErrNoRedditCookies = errors.New("no reddit.com cookies found; log in to Reddit in the browser before exporting")
+
+// ErrNoToken is returned when the reddit.com cookies lack token_v2, which every API request needs.
+var ErrNoToken = errors.New("the reddit.com cookies have no token_v2; log in to Reddit again and export fresh cookies")
+
+// redditDomain is the domain whose cookies are extracted, including its subdomains.
+const redditDomain = "reddit.com"
+
+// Cookie is a single cookie read from an export.
+type Cookie struct {
+	Domain  string
+	Path    string
+	Name    string
+	Value   string
+	Expires time.Time // Zero for session cookies and HAR entries without an expiry
+}
+
+// Format names reported by Parse.
+const (
+	FormatNetscape = "cookies.txt"
+	FormatHAR      = "har"
+	FormatFirefox  = "firefox"
+)
+
+// Parse detects the format of data and returns all cookies in it.
+func Parse(data []byte) (cookies []Cookie, format string, err error) {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(data, []byte(sqliteMagic)):
+		cookies, err = parseFirefox(data)
+		return cookies, FormatFirefox, err
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		cookies, err = parseHAR(trimmed)
+		return cookies, FormatHAR, err
+	default:
+		cookies, err = parseNetscape(data)
+		return cookies, FormatNetscape, err
+	}
+}
+
+// RedditCookieHeader returns the reddit.com cookies of a cookie export as a Cookie header value, the same string
+// users would otherwise copy from the developer tools, together with the detected format. Expired cookies are
+// skipped and, when a name occurs for several reddit.com domains or HAR entries, the last one wins.
+func RedditCookieHeader(data []byte) (header, format string, err error) {
+	cookies, format, err := Parse(data)
+	if err != nil {
+		return "", format, err
+	}
+
+	now := time.Now()
+	values := make(map[string]string)
+	var names []string
+	for _, cookie := range cookies {
+		if !isRedditDomain(cookie.Domain) || cookie.Name == "" {
+			continue
+		}
+		if !cookie.Expires.IsZero() && cookie.Expires.Before(now) {
+			continue
+		}
+		if _, seen := values[cookie.Name]; !seen {
+			names = append(names, cookie.Name)
+		}
+		values[cookie.Name] = cookie.Value
+	}
+	if len(names) == 0 {
+		return "", format, ErrNoRedditCookies
+	}
+	if values["token_v2"] == "" {
+		return "", format, ErrNoToken
+	}
+
+	// token_v2 first, so it stays visible when the header is shown truncated; the rest in a stable order.
+	sort.SliceStable(names, func(i, j int) bool { return names[i] == "token_v2" && names[j] != "token_v2" })
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+"="+values[name])
+	}
+	return strings.Join(pairs, "; "), format, nil
+}
+
+// isRedditDomain reports whether a cookie domain (with or without the leading dot) is reddit.com or a subdomain of it.
+func isRedditDomain(domain string) bool {
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	return domain == redditDomain || strings.HasSuffix(domain, "."+redditDomain)
+}
+
+// parseNetscape reads the tab-separated Netscape cookie file format: domain, include-subdomains flag, path,
+// secure flag, expiry in Unix seconds, name and value. Lines starting with "#HttpOnly_" are cookies; other
+// comments and blank lines are skipped.
+func parseNetscape(data []byte) ([]Cookie, error) {
+	var cookies []Cookie
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		line = strings.TrimPrefix(line, "#HttpOnly_")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, ErrUnknownFormat
+		}
+		cookie := Cookie{Domain: fields[0], Path: fields[2], Name: fields[5], Value: fields[6]}
+		if expires, err := strconv.ParseInt(fields[4], 10, 64); err == nil && expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+		}
+		cookies = append(cookies, cookie)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading cookies.txt: %w", err)
+	}
+	if len(cookies) == 0 {
+		return nil, ErrUnknownFormat
+	}
+	return cookies, nil
+}
+
+// harFile is the part of the HAR 1.2 format holding the cookies sent with and set by each request.
+type harFile struct {
+	Log *struct {
+		Entries []struct {
+			Request struct {
+				URL     string      `json:"url"`
+				Cookies []harCookie `json:"cookies"`
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+			} `json:"request"`
+			Response struct {
+				Cookies []harCookie `json:"cookies"`
+			} `json:"response"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+type harCookie struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Domain  string `json:"domain"`
+	Path    string `json:"path"`
+	Expires string `json:"expires"`
+}
+
+// parseHAR collects the cookies of every entry in a HAR file, in the order the requests were made. Cookies sent
+// with a request belong to the request's host; cookies set by a response use their own domain when they have one.
+// Browsers that leave the request cookie list empty still record the Cookie header, which is used instead.
+func parseHAR(data []byte) ([]Cookie, error) {
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil || har.Log == nil {
+		return nil, ErrUnknownFormat
+	}
+
+	var cookies []Cookie
+	for _, entry := range har.Log.Entries {
+		host := ""
+		if u, err := url.Parse(entry.Request.URL); err == nil {
+			host = u.Hostname()
+		}
+
+		sent := entry.Request.Cookies
+		if len(sent) == 0 {
+			for _, header := range entry.Request.Headers {
+				if strings.EqualFold(header.Name, "cookie") {
+					sent = append(sent, splitCookieHeader(header.Value)...)
+				}
+			}
+		}
+		for _, c := range sent {
+			cookies = append(cookies, Cookie{Domain: host, Path: "/", Name: c.Name, Value: c.Value})
+		}
+
+		for _, c := range entry.Response.Cookies {
+			cookie := Cookie{Domain: c.Domain, Path: c.Path, Name: c.Name, Value: c.Value}
+			if cookie.Domain == "" {
+				cookie.Domain = host
+			}
+			if expires, err := time.Parse(time.RFC3339, c.Expires); err == nil {
+				cookie.Expires = expires
+			}
+			cookies = append(cookies, cookie)
+		}
+	}
+	return cookies, nil
+}
+
+// splitCookieHeader splits a Cookie header value into name/value pairs.
+func splitCookieHeader(header string) []harCookie {
+	var cookies []harCookie
+	for _, part := range strings.Split(header, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok && name != "" {
+			cookies = append(cookies, harCookie{Name: name, Value: value})
+		}
+	}
+	return cookies
+}
+
+// parseFirefox reads the moz_cookies table of a Firefox cookies.sqlite file.
+// Firefox keeps recent changes in cookies.sqlite-wal until it checkpoints them, so the copy should be taken
+// while Firefox is closed for it to contain a fresh login.
+func parseFirefox(data []byte) ([]Cookie, error) {
+	db, err := openSQLite(data)
+	if err != nil {
+		return nil, err
+	}
+	rows, columns, err := db.table("moz_cookies")
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(columns))
+	for i, column := range columns {
+		index[column] = i
+	}
+	for _, column := range []string{"host", "path", "name", "value", "expiry"} {
+		if _, ok := index[column]; !ok {
+			return nil, fmt.Errorf("moz_cookies has no %s column; is this a Firefox cookies.sqlite file?", column)
+		}
+	}
+
+	cookies := make([]Cookie, 0, len(rows))
+	for _, row := range rows {
+		cookie := Cookie{
+			Domain: textValue(row, index["host"]),
+			Path:   textValue(row, index["path"]),
+			Name:   textValue(row, index["name"]),
+			Value:  textValue(row, index["value"]),
+		}
+		if expiry, ok := valueAt(row, index["expiry"]).(int64); ok && expiry > 0 {
+			// Recent Firefox versions store the expiry in milliseconds instead of seconds.
+			if expiry > 1e11 {
+				cookie.Expires = time.UnixMilli(expiry)
+			} else {
+				cookie.Expires = time.Unix(expiry, 0)
+			}
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies, nil
+}
+
+// valueAt returns column i of row, or nil if the row has fewer columns (added to the table after it was written).
+func valueAt(row []any, i int) any {
+	if i < len(row) {
+		return row[i]
+	}
+	return nil
+}
+
+// textValue returns column i of row as a string.
+func textValue(row []any, i int) string {
+	switch v := valueAt(row, i).(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	default:
+		return ""
+	}
+}
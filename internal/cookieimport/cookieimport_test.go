@@ -0,0 +1,112 @@
+package cookieimport
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRedditCookieHeaderNetscape(t *testing.T) {
+	data := strings.Join([]string{
+		"# Netscape HTTP Cookie File",
+		"",
+		".example.com\tTRUE\t/\tFALSE\t4102444800\ttoken_v2\tnot-reddit",
+		".reddit.com\tTRUE\t/\tTRUE\t4102444800\tloid\tloid-value",
+		"#HttpOnly_.reddit.com\tTRUE\t/\tTRUE\t4102444800\ttoken_v2\ttoken-value",
+		"www.reddit.com\tFALSE\t/\tTRUE\t946684800\tsession_tracker\texpired",
+	}, "\n")
+
+	got, _, err := RedditCookieHeader([]byte(data))
+	if want := "token_v2=token-value; loid=loid-value"; err != nil || got != want {
+		t.Fatalf("RedditCookieHeader() = %q, %v; want %q", got, err, want)
+	}
+}
+
+func TestRedditCookieHeaderHAR(t *testing.T) {
+	data := `{"log": {"entries": [
+		{"request": {"url": "https://www.reddit.com/", "cookies": [{"name": "loid", "value": "loid-value"}]},
+		 "response": {"cookies": [{"name": "token_v2", "value": "first", "domain": ".reddit.com"}]}},
+		{"request": {"url": "https://static.example.com/x.js", "cookies": [{"name": "token_v2", "value": "other-site"}]},
+		 "response": {"cookies": []}},
+		{"request": {"url": "https://www.reddit.com/api/me.json", "cookies": [],
+		             "headers": [{"name": "Cookie", "value": "loid=loid-value; token_v2=latest"}]},
+		 "response": {"cookies": []}}
+	]}}`
+
+	got, _, err := RedditCookieHeader([]byte(data))
+	if want := "token_v2=latest; loid=loid-value"; err != nil || got != want {
+		t.Fatalf("RedditCookieHeader() = %q, %v; want %q", got, err, want)
+	}
+}
+
+func TestRedditCookieHeaderFirefox(t *testing.T) {
+	data, err := os.ReadFile("testdata/cookies.sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cookies, format, err := Parse(data)
+	if err != nil || format != FormatFirefox {
+		t.Fatalf("Parse() = %d cookies, %q, %v", len(cookies), format, err)
+	}
+	if len(cookies) != 154 {
+		t.Errorf("Parse() read %d cookies, want 154", len(cookies))
+	}
+
+	got, _, err := RedditCookieHeader(data)
+	if want := "token_v2=" + strings.Repeat("t", 3000) + "; loid=loid-value"; err != nil || got != want {
+		t.Fatalf("RedditCookieHeader() = %.40q, %v; want %.40q", got, err, want)
+	}
+}
+
+// sqliteFile builds a one-page SQLite database of 512-byte pages whose schema page holds a single cell.
+// For an interior page, rightChild is the page number of its right-most child.
+func sqliteFile(pageType byte, cell []byte, rightChild uint32) string {
+	page := make([]byte, 512)
+	copy(page, sqliteMagic)
+	binary.BigEndian.PutUint16(page[16:], 512)
+	binary.BigEndian.PutUint32(page[56:], 1) // UTF-8
+	header := page[100:]
+	header[0] = pageType
+	binary.BigEndian.PutUint16(header[3:], 1) // cell count
+	pointers := 8
+	if pageType == pageInteriorTable {
+		binary.BigEndian.PutUint32(header[8:], rightChild)
+		pointers = 12
+	}
+	cellOffset := 512 - len(cell)
+	binary.BigEndian.PutUint16(header[pointers:], uint16(cellOffset))
+	copy(page[cellOffset:], cell)
+	return string(page)
+}
+
+func TestRedditCookieHeaderErrors(t *testing.T) {
+	valid, err := os.ReadFile("testdata/cookies.sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A leaf cell claiming a 512 GiB payload (varint 0x90 0x80 0x80 0x80 0x80 0x00), followed by rowid 1.
+	hugePayload := sqliteFile(pageLeafTable, []byte{0x90, 0x80, 0x80, 0x80, 0x80, 0x00, 0x01, 0, 0, 0, 0}, 0)
+	// An interior page whose only child and right-most child are the page itself.
+	pageCycle := sqliteFile(pageInteriorTable, []byte{0, 0, 0, 1, 0x01}, 1)
+
+	tests := []struct {
+		name string
+		data string
+		want error
+	}{
+		{"raw cookie header", "loid=1; token_v2=abc", ErrUnknownFormat},
+		{"no reddit cookies", ".example.com\tTRUE\t/\tFALSE\t0\tsid\tx", ErrNoRedditCookies},
+		{"no token", ".reddit.com\tTRUE\t/\tFALSE\t0\tloid\tx", ErrNoToken},
+		{"empty database header", sqliteMagic + strings.Repeat("\x00", 200), errCorrupt},
+		{"truncated database", string(valid[:len(valid)/2]), errCorrupt},
+		{"oversized payload", hugePayload, errCorrupt},
+		{"page cycle", pageCycle, errCorrupt},
+	}
+	for _, tt := range tests {
+		if _, _, err := RedditCookieHeader([]byte(tt.data)); !errors.Is(err, tt.want) {
+			t.Errorf("%s: RedditCookieHeader() error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package cookieimport
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// sqliteMagic starts every SQLite 3 database file.
+const sqliteMagic = "SQLite format 3\x00"
+
+// errCorrupt is returned for database files that do not follow the SQLite file format.
+var errCorrupt = errors.New("corrupted or truncated SQLite database")
+
+// B-tree page types of the SQLite file format.
+const (
+	pageInteriorTable = 0x05
+	pageLeafTable     = 0x0d
+)
+
+// sqliteDB is a minimal read-only reader of the SQLite 3 file format (https://www.sqlite.org/fileformat.html).
+// It only reads whole rowid tables, which is all that is needed to get the cookies out of a Firefox profile
+// without a SQLite driver. Indexes, WITHOUT ROWID tables and the write-ahead log are not supported.
+type sqliteDB struct {
+	data       []byte
+	pageSize   int
+	usableSize int
+}
+
+// openSQLite validates the database header of data.
+func openSQLite(data []byte) (*sqliteDB, error) {
+	if len(data) < 100 || string(data[:16]) != sqliteMagic {
+		return nil, ErrUnknownFormat
+	}
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, errCorrupt
+	}
+	if encoding := binary.BigEndian.Uint32(data[56:60]); encoding != 0 && encoding != 1 {
+		return nil, fmt.Errorf("unsupported SQLite text encoding %d", encoding)
+	}
+	return &sqliteDB{data: data, pageSize: pageSize, usableSize: pageSize - int(data[20])}, nil
+}
+
+// table returns all rows of the named table and its column names in declaration order.
+func (db *sqliteDB) table(name string) (rows [][]any, columns []string, err error) {
+	// The schema table sqlite_master is rooted at page 1 with columns type, name, tbl_name, rootpage and sql.
+	schema, err := db.readTable(1)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, row := range schema {
+		if textValue(row, 0) != "table" || !strings.EqualFold(textValue(row, 1), name) {
+			continue
+		}
+		rootPage, ok := valueAt(row, 3).(int64)
+		if !ok || rootPage < 1 {
+			return nil, nil, errCorrupt
+		}
+		columns := parseColumns(textValue(row, 4))
+		if len(columns) == 0 {
+			return nil, nil, fmt.Errorf("could not read the columns of table %s", name)
+		}
+		rows, err := db.readTable(int(rootPage))
+		return rows, columns, err
+	}
+	return nil, nil, fmt.Errorf("table %s not found; is this a Firefox cookies.sqlite file?", name)
+}
+
+// readTable returns the decoded records of every row of the table b-tree rooted at rootPage, in rowid order.
+func (db *sqliteDB) readTable(rootPage int) ([][]any, error) {
+	var rows [][]any
+	visited := make(map[int]bool)
+	var walk func(page int) error
+	walk = func(page int) error {
+		if visited[page] {
+			return errCorrupt
+		}
+		visited[page] = true
+
+		content, header, err := db.page(page)
+		if err != nil {
+			return err
+		}
+		if len(content) < header+8 {
+			return errCorrupt
+		}
+		pageType := content[header]
+		cellCount := int(binary.BigEndian.Uint16(content[header+3:]))
+		pointers := header + 8
+		if pageType == pageInteriorTable {
+			pointers = header + 12
+		}
+		if len(content) < pointers+2*cellCount {
+			return errCorrupt
+		}
+
+		for i := 0; i < cellCount; i++ {
+			offset := int(binary.BigEndian.Uint16(content[pointers+2*i:]))
+			if offset >= len(content) {
+				return errCorrupt
+			}
+			cell := content[offset:]
+			switch pageType {
+			case pageInteriorTable:
+				if len(cell) < 4 {
+					return errCorrupt
+				}
+				if err := walk(int(binary.BigEndian.Uint32(cell))); err != nil {
+					return err
+				}
+			case pageLeafTable:
+				payload, err := db.leafPayload(cell)
+				if err != nil {
+					return err
+				}
+				row, err := decodeRecord(payload)
+				if err != nil {
+					return err
+				}
+				rows = append(rows, row)
+			default:
+				return fmt.Errorf("page %d is not a table b-tree page: %w", page, errCorrupt)
+			}
+		}
+		if pageType == pageInteriorTable {
+			return walk(int(binary.BigEndian.Uint32(content[header+8:])))
+		}
+		return nil
+	}
+	if err := walk(rootPage); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// page returns the content of a page and the offset of its b-tree header, which follows the database header on page 1.
+func (db *sqliteDB) page(number int) (content []byte, header int, err error) {
+	start := (number - 1) * db.pageSize
+	if number < 1 || start+db.pageSize > len(db.data) {
+		return nil, 0, errCorrupt
+	}
+	content = db.data[start : start+db.usableSize]
+	if number == 1 {
+		header = 100
+	}
+	return content, header, nil
+}
+
+// leafPayload returns the record of a table leaf cell, following its overflow pages when it does not fit the page.
+func (db *sqliteDB) leafPayload(cell []byte) ([]byte, error) {
+	size, n := readVarint(cell)
+	if n == 0 || size < 0 {
+		return nil, errCorrupt
+	}
+	_, m := readVarint(cell[n:]) // rowid
+	if m == 0 {
+		return nil, errCorrupt
+	}
+	cell = cell[n+m:]
+
+	// The size comes from the file; a payload can never be larger than the file holding it.
+	if size > int64(len(db.data)) {
+		return nil, errCorrupt
+	}
+	usable := int64(db.usableSize)
+	maxLocal := usable - 35
+	if size <= maxLocal {
+		if int64(len(cell)) < size {
+			return nil, errCorrupt
+		}
+		return cell[:size], nil
+	}
+
+	minLocal := (usable-12)*32/255 - 23
+	local := minLocal + (size-minLocal)%(usable-4)
+	if local > maxLocal {
+		local = minLocal
+	}
+	if int64(len(cell)) < local+4 {
+		return nil, errCorrupt
+	}
+	// Grown page by page rather than allocated up front, so the size alone cannot make it large.
+	payload := append([]byte(nil), cell[:local]...)
+
+	next := int(binary.BigEndian.Uint32(cell[local:]))
+	visited := make(map[int]bool)
+	for int64(len(payload)) < size {
+		if next == 0 || visited[next] {
+			return nil, errCorrupt
+		}
+		visited[next] = true
+		content, _, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+		next = int(binary.BigEndian.Uint32(content))
+		chunk := content[4:]
+		if remaining := size - int64(len(payload)); int64(len(chunk)) > remaining {
+			chunk = chunk[:remaining]
+		}
+		payload = append(payload, chunk...)
+	}
+	return payload, nil
+}
+
+// decodeRecord decodes a record in the SQLite record format into int64, float64, string, []byte or nil values.
+func decodeRecord(record []byte) ([]any, error) {
+	headerSize, n := readVarint(record)
+	if n == 0 || headerSize < int64(n) || headerSize > int64(len(record)) {
+		return nil, errCorrupt
+	}
+	header := record[n:headerSize]
+	body := record[headerSize:]
+
+	var values []any
+	for len(header) > 0 {
+		serialType, n := readVarint(header)
+		if n == 0 {
+			return nil, errCorrupt
+		}
+		header = header[n:]
+
+		var size int64
+		switch {
+		case serialType >= 12:
+			size = (serialType - 12) / 2
+		case serialType >= 1 && serialType <= 4:
+			size = serialType
+		case serialType == 5:
+			size = 6
+		case serialType == 6 || serialType == 7:
+			size = 8
+		}
+		if int64(len(body)) < size {
+			return nil, errCorrupt
+		}
+		field := body[:size]
+		body = body[size:]
+
+		switch {
+		case serialType == 0:
+			values = append(values, nil)
+		case serialType >= 1 && serialType <= 6:
+			values = append(values, readInt(field))
+		case serialType == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(field)))
+		case serialType == 8:
+			values = append(values, int64(0))
+		case serialType == 9:
+			values = append(values, int64(1))
+		case serialType >= 12 && serialType%2 == 0:
+			values = append(values, append([]byte(nil), field...))
+		case serialType >= 13:
+			values = append(values, string(field))
+		default:
+			return nil, errCorrupt
+		}
+	}
+	return values, nil
+}
+
+// readInt decodes a big-endian two's complement integer of 1 to 8 bytes.
+func readInt(b []byte) int64 {
+	var v int64
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		v = -1
+	}
+	for _, c := range b {
+		v = v<<8 | int64(c)
+	}
+	return v
+}
+
+// readVarint decodes a SQLite variable-length integer and returns it with its length, or 0 bytes if b is too short.
+func readVarint(b []byte) (int64, int) {
+	var v uint64
+	for i := 0; i < 9; i++ {
+		if i >= len(b) {
+			return 0, 0
+		}
+		if i == 8 {
+			return int64(v<<8 | uint64(b[i])), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return int64(v), i + 1
+		}
+	}
+	return 0, 0
+}
+
+// parseColumns returns the column names of a CREATE TABLE statement, skipping table constraints.
+func parseColumns(createSQL string) []string {
+	start := strings.Index(createSQL, "(")
+	end := strings.LastIndex(createSQL, ")")
+	if start < 0 || end <= start {
+		return nil
+	}
+
+	var definitions []string
+	depth, last := 0, start+1
+	for i := start + 1; i < end; i++ {
+		switch createSQL[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				definitions = append(definitions, createSQL[last:i])
+				last = i + 1
+			}
+		}
+	}
+	definitions = append(definitions, createSQL[last:end])
+
+	var columns []string
+	for _, definition := range definitions {
+		fields := strings.Fields(definition)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "CONSTRAINT", "PRIMARY", "UNIQUE", "CHECK", "FOREIGN":
+			continue
+		}
+		columns = append(columns, strings.Trim(fields[0], "\"`[]'"))
+	}
+	return columns
+}
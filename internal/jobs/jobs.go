@@ -0,0 +1,280 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/progress"
+	"github.com/nileshnk/reddit-migrate/internal/ratelimiter"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+)
+
+// ErrJobNotFound is returned when a job ID is not present in the registry.
+var ErrJobNotFound = errors.New("job not found")
+
+// ErrJobFinished is returned when trying to cancel a job that has already finished.
+var ErrJobFinished = errors.New("job already finished")
+
+// MaxEvents is how many of its most recent progress events a job keeps for EventsSince. Older events are dropped, so a
+// migration of many items does not grow without bound; the final DoneEvent is the newest and always kept.
+const MaxEvents = 1000
+
+// RunFunc is the unit of work executed by a job.
+// The context is cancelled when the job is cancelled through the manager; jobID is the ID the job was registered under.
+type RunFunc func(ctx context.Context, jobID string) types.MigrationResponseType
+
+// Job is a single background migration tracked by a Manager.
+type Job struct {
+	ID   string
+	Kind string
+
+	mu         sync.RWMutex
+	status     types.JobStatus
+	createdAt  time.Time
+	startedAt  time.Time
+	finishedAt time.Time
+	result     *types.MigrationResponseType
+
+	events  []types.ProgressEvent // The latest MaxEvents events, oldest first
+	seq     int                   // Sequence number of the latest event
+	changed chan struct{}         // Closed and replaced whenever an event is appended.
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Report records a progress event and wakes up everyone waiting in EventsSince.
+// It implements progress.Reporter.
+func (j *Job) Report(event types.ProgressEvent) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.seq++
+	event.Seq = j.seq
+	if len(j.events) == MaxEvents {
+		j.events = j.events[1:]
+	}
+	j.events = append(j.events, event)
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// EventsSince returns the events with a sequence number greater than seq, together with a channel
+// that is closed when the next event is recorded. Callers replay the returned events and then wait on the channel.
+// If events after seq have already been dropped, the returned events start with the oldest one kept.
+func (j *Job) EventsSince(seq int) ([]types.ProgressEvent, <-chan struct{}) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	skip := seq - (j.seq - len(j.events))
+	if skip < 0 {
+		skip = 0
+	}
+	if skip >= len(j.events) {
+		return nil, j.changed
+	}
+	events := make([]types.ProgressEvent, len(j.events)-skip)
+	copy(events, j.events[skip:])
+	return events, j.changed
+}
+
+// Info returns a snapshot of the job's current state.
+func (j *Job) Info() types.JobInfo {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	info := types.JobInfo{
+		ID:        j.ID,
+		Kind:      j.Kind,
+		Status:    j.status,
+		CreatedAt: j.createdAt,
+		Result:    j.result,
+	}
+	if !j.startedAt.IsZero() {
+		startedAt := j.startedAt
+		info.StartedAt = &startedAt
+	}
+	if !j.finishedAt.IsZero() {
+		finishedAt := j.finishedAt
+		info.FinishedAt = &finishedAt
+	}
+	return info
+}
+
+// Done returns a channel that is closed once the job has finished, whatever the outcome.
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// isFinished reports whether the job reached a terminal state.
+func (j *Job) isFinished() bool {
+	select {
+	case <-j.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Manager is a registry of background migration jobs.
+// It starts each job in its own goroutine and keeps finished jobs around for status queries
+// until config.JobRetention has elapsed.
+type Manager struct {
+	mu   sync.RWMutex
+	jobs map[string]*Job
+}
+
+// NewManager creates an empty job manager.
+func NewManager() *Manager {
+	return &Manager{jobs: make(map[string]*Job)}
+}
+
+// DefaultManager is the process-wide job manager used by the HTTP handlers.
+var DefaultManager = NewManager()
+
+// Submit registers a new job of the given kind and starts running it in the background.
+// It returns immediately with the registered job.
+func (m *Manager) Submit(kind string, run RunFunc) (*Job, error) {
+	id, err := newJobID()
+	if err != nil {
+		return nil, fmt.Errorf("error generating job ID: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &Job{
+		ID:        id,
+		Kind:      kind,
+		status:    types.JobPending,
+		createdAt: time.Now(),
+		changed:   make(chan struct{}),
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+
+	m.mu.Lock()
+	m.pruneLocked()
+	m.jobs[id] = job
+	m.mu.Unlock()
+
+	config.InfoLogger.Printf("Jobs: Registered %s job %s.", kind, id)
+	go m.execute(ctx, job, run)
+	return job, nil
+}
+
+// execute runs the job's work function and records its outcome.
+func (m *Manager) execute(ctx context.Context, job *Job, run RunFunc) {
+	defer close(job.done)
+	defer job.cancel()
+
+	job.mu.Lock()
+	job.status = types.JobRunning
+	job.startedAt = time.Now()
+	job.mu.Unlock()
+	config.InfoLogger.Printf("Jobs: Started %s job %s.", job.Kind, job.ID)
+
+	var result types.MigrationResponseType
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				config.ErrorLogger.Printf("Jobs: Job %s panicked: %v", job.ID, r)
+				result = types.MigrationResponseType{Success: false, Message: fmt.Sprintf("Migration aborted by internal error: %v", r)}
+			}
+		}()
+		result = run(ratelimiter.WithJob(progress.WithReporter(ctx, job), job.ID), job.ID)
+	}()
+
+	status := types.JobCompleted
+	switch {
+	case ctx.Err() != nil:
+		status = types.JobCancelled
+	case !result.Success:
+		status = types.JobFailed
+	}
+
+	job.mu.Lock()
+	job.status = status
+	job.finishedAt = time.Now()
+	job.result = &result
+	job.mu.Unlock()
+
+	job.Report(types.ProgressEvent{
+		Type:    types.DoneEvent,
+		Time:    time.Now(),
+		Success: result.Success,
+		Status:  status,
+		Message: result.Message,
+	})
+	config.InfoLogger.Printf("Jobs: %s job %s finished with status %s.", job.Kind, job.ID, status)
+}
+
+// Get looks up a job by ID.
+func (m *Manager) Get(id string) (*Job, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	job, ok := m.jobs[id]
+	return job, ok
+}
+
+// List returns snapshots of all known jobs, newest first.
+func (m *Manager) List() []types.JobInfo {
+	m.mu.RLock()
+	infos := make([]types.JobInfo, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		infos = append(infos, job.Info())
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(infos, func(i, k int) bool {
+		return infos[i].CreatedAt.After(infos[k].CreatedAt)
+	})
+	return infos
+}
+
+// Cancel requests cancellation of a running or pending job.
+// The job's context is cancelled; workers stop picking up new items and the job finishes as cancelled.
+func (m *Manager) Cancel(id string) error {
+	job, ok := m.Get(id)
+	if !ok {
+		return ErrJobNotFound
+	}
+	if job.isFinished() {
+		return ErrJobFinished
+	}
+	config.InfoLogger.Printf("Jobs: Cancelling job %s.", id)
+	job.cancel()
+	return nil
+}
+
+// pruneLocked drops finished jobs older than config.JobRetention. The caller must hold m.mu.
+func (m *Manager) pruneLocked() {
+	if config.JobRetention <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-config.JobRetention)
+	for id, job := range m.jobs {
+		if !job.isFinished() {
+			continue
+		}
+		job.mu.RLock()
+		expired := job.finishedAt.Before(cutoff)
+		job.mu.RUnlock()
+		if expired {
+			config.DebugLogger.Printf("Jobs: Pruning finished job %s.", id)
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// newJobID generates a random, URL-safe job identifier.
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,165 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/jobs"
+	"github.com/nileshnk/reddit-migrate/internal/progress"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+)
+
+func TestMain(m *testing.M) {
+	config.InfoLogger = log.New(io.Discard, "", 0)
+	config.ErrorLogger = log.New(io.Discard, "", 0)
+	config.DebugLogger = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+// wait blocks until job has finished, failing the test if it takes longer than a few seconds.
+func wait(t *testing.T, job *jobs.Job) types.JobInfo {
+	t.Helper()
+	select {
+	case <-job.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("job %s did not finish", job.ID)
+	}
+	return job.Info()
+}
+
+func TestSubmitRunsJobToCompletion(t *testing.T) {
+	manager := jobs.NewManager()
+	var gotID string
+	job, err := manager.Submit("migrate", func(ctx context.Context, jobID string) types.MigrationResponseType {
+		gotID = jobID
+		return types.MigrationResponseType{Success: true, Message: "done"}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := wait(t, job)
+	if info.Status != types.JobCompleted || info.Result == nil || !info.Result.Success {
+		t.Fatalf("job finished as %s with result %+v, want completed", info.Status, info.Result)
+	}
+	if gotID != job.ID {
+		t.Errorf("run got job ID %q, want %q", gotID, job.ID)
+	}
+	if info.StartedAt == nil || info.FinishedAt == nil {
+		t.Errorf("job has no start or finish time: %+v", info)
+	}
+	events, _ := job.EventsSince(0)
+	if len(events) == 0 || events[len(events)-1].Type != types.DoneEvent || events[len(events)-1].Status != types.JobCompleted {
+		t.Errorf("events = %+v, want a final done event", events)
+	}
+	if found, ok := manager.Get(job.ID); !ok || found != job {
+		t.Errorf("Get(%s) = %v, %v", job.ID, found, ok)
+	}
+}
+
+func TestEventsSinceKeepsLatestEvents(t *testing.T) {
+	manager := jobs.NewManager()
+	job, err := manager.Submit("migrate", func(ctx context.Context, jobID string) types.MigrationResponseType {
+		for i := 0; i < jobs.MaxEvents+10; i++ {
+			progress.Report(ctx, types.ProgressEvent{Type: types.PostEvent, Success: true})
+		}
+		return types.MigrationResponseType{Success: true}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wait(t, job)
+
+	// MaxEvents+11 events were reported, counting the final done event; the first 11 are gone.
+	for _, seq := range []int{0, 5, 11} {
+		events, _ := job.EventsSince(seq)
+		if len(events) != jobs.MaxEvents || events[0].Seq != 12 || events[len(events)-1].Type != types.DoneEvent {
+			t.Errorf("EventsSince(%d) returned %d events, want the %d kept, from seq 12 to the done event", seq, len(events), jobs.MaxEvents)
+		}
+	}
+	events, _ := job.EventsSince(jobs.MaxEvents + 10)
+	if len(events) != 1 || events[0].Seq != jobs.MaxEvents+11 || events[0].Type != types.DoneEvent {
+		t.Errorf("EventsSince(%d) = %+v, want only the done event", jobs.MaxEvents+10, events)
+	}
+	if events, _ := job.EventsSince(jobs.MaxEvents + 11); len(events) != 0 {
+		t.Errorf("EventsSince(latest) returned %d events, want none", len(events))
+	}
+}
+
+func TestCancelCancelsJobContext(t *testing.T) {
+	manager := jobs.NewManager()
+	started := make(chan struct{})
+	job, err := manager.Submit("migrate", func(ctx context.Context, jobID string) types.MigrationResponseType {
+		close(started)
+		<-ctx.Done()
+		return types.MigrationResponseType{Message: "cancelled"}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	if err := manager.Cancel(job.ID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if info := wait(t, job); info.Status != types.JobCancelled {
+		t.Fatalf("job finished as %s, want cancelled", info.Status)
+	}
+	if err := manager.Cancel(job.ID); !errors.Is(err, jobs.ErrJobFinished) {
+		t.Errorf("cancelling a finished job = %v, want ErrJobFinished", err)
+	}
+	if err := manager.Cancel("unknown"); !errors.Is(err, jobs.ErrJobNotFound) {
+		t.Errorf("cancelling an unknown job = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestPanicFailsJob(t *testing.T) {
+	manager := jobs.NewManager()
+	job, err := manager.Submit("migrate", func(ctx context.Context, jobID string) types.MigrationResponseType {
+		panic("boom")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := wait(t, job)
+	if info.Status != types.JobFailed || info.Result == nil || !strings.Contains(info.Result.Message, "boom") {
+		t.Fatalf("job finished as %s with result %+v, want failed with the panic message", info.Status, info.Result)
+	}
+}
+
+func TestFinishedJobsArePruned(t *testing.T) {
+	retention := config.JobRetention
+	config.JobRetention = 10 * time.Millisecond
+	t.Cleanup(func() { config.JobRetention = retention })
+
+	manager := jobs.NewManager()
+	run := func(ctx context.Context, jobID string) types.MigrationResponseType {
+		return types.MigrationResponseType{Success: true}
+	}
+	old, err := manager.Submit("migrate", run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wait(t, old)
+	time.Sleep(20 * time.Millisecond)
+
+	// Old jobs are pruned when the next one is submitted.
+	current, err := manager.Submit("migrate", run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := manager.Get(old.ID); ok {
+		t.Errorf("job %s is still listed after its retention", old.ID)
+	}
+	if _, ok := manager.Get(current.ID); !ok {
+		t.Errorf("new job %s is not listed", current.ID)
+	}
+}
@@ -0,0 +1,431 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+)
+
+// ErrNotFound is returned when no journal exists for a job ID.
+var ErrNotFound = errors.New("journal not found")
+
+// ErrInUse is returned when a journal is already open for a running migration in this process.
+var ErrInUse = errors.New("journal is in use by a running migration")
+
+// openJournals tracks the job IDs whose journals are currently open, so two migrations never append to the same file.
+var (
+	openMu       sync.Mutex
+	openJournals = make(map[string]bool)
+)
+
+// Item kinds recorded in a journal.
+const (
+	KindSubreddit   = "subreddit"
+	KindUser        = "user"
+	KindPost        = "post"
+	KindMultireddit = "multireddit"
+)
+
+// ItemStatus is the recorded outcome of a single planned action.
+type ItemStatus string
+
+const (
+	// StatusPending means the action was planned but no outcome has been recorded yet.
+	StatusPending ItemStatus = "pending"
+	// StatusDone means the action succeeded.
+	StatusDone ItemStatus = "done"
+	// StatusFailed means the action was attempted and failed.
+	StatusFailed ItemStatus = "failed"
+)
+
+// Header describes the migration a journal belongs to. It is the first record of every journal file.
+// Credentials are never written to the journal; resuming requires authenticating both accounts again.
+type Header struct {
+	JobID         string    `json:"job_id"`
+	Kind          string    `json:"kind"` // "migrate", "migrate-custom" or "import"
+	OldUsername   string    `json:"old_username"`
+	NewUsername   string    `json:"new_username"`
+	CreatedAt     time.Time `json:"created_at"`
+	PreserveOrder bool      `json:"preserve_order,omitempty"` // Saves are replayed one at a time in plan order
+}
+
+// Entry is the planned action and latest outcome for one item.
+type Entry struct {
+	Kind   string     `json:"kind"`   // KindSubreddit, KindUser or KindPost
+	Action string     `json:"action"` // "sub", "unsub", "save", "unsave"
+	Item   string     `json:"item"`   // Subreddit display name, username or full name
+	Status ItemStatus `json:"status"`
+	Error  string     `json:"error,omitempty"`
+	Time   time.Time  `json:"time"`
+}
+
+// record is a single line of the journal file. Exactly one field is set.
+type record struct {
+	Header *Header `json:"header,omitempty"`
+	Entry  *Entry  `json:"entry,omitempty"`
+}
+
+// Journal is an append-only, per-job checkpoint log on disk.
+// Each planned action is written as a pending entry before it is attempted, and every outcome is appended as it
+// happens, so the file always reflects which items still need work even if the process dies mid-migration.
+//
+// All methods are safe to call on a nil *Journal, in which case they do nothing. This lets callers run
+// without a journal (for example when journaling is disabled) without nil checks at every call site.
+type Journal struct {
+	mu      sync.Mutex
+	file    *os.File
+	header  Header
+	entries map[string]*Entry
+	order   []string // Entry keys in plan order.
+}
+
+// Create starts a new journal for the given job in dir, replacing any previous journal with the same job ID.
+func Create(dir string, header Header) (*Journal, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("error creating journal directory %s: %w", dir, err)
+	}
+	if err := acquire(header.JobID); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(journalPath(dir, header.JobID), os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		release(header.JobID)
+		return nil, fmt.Errorf("error creating journal for job %s: %w", header.JobID, err)
+	}
+
+	j := &Journal{file: file, header: header, entries: make(map[string]*Entry)}
+	if err := j.write(record{Header: &header}); err != nil {
+		j.Close()
+		return nil, err
+	}
+	config.InfoLogger.Printf("Journal: Created journal for job %s at %s.", header.JobID, file.Name())
+	return j, nil
+}
+
+// Open loads an existing journal and reopens it for appending further outcomes.
+// It returns ErrNotFound if the job has no journal and ErrInUse if another migration holds it open.
+func Open(dir, jobID string) (*Journal, error) {
+	if err := acquire(jobID); err != nil {
+		return nil, err
+	}
+	j, err := load(journalPath(dir, jobID))
+	if err != nil {
+		release(jobID)
+		return nil, err
+	}
+	file, err := os.OpenFile(journalPath(dir, jobID), os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		release(jobID)
+		return nil, fmt.Errorf("error opening journal for job %s: %w", jobID, err)
+	}
+	j.file = file
+	if err := j.terminateTornLine(); err != nil {
+		j.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+// List returns summaries of all journals in dir, newest first.
+func List(dir string) ([]types.JournalSummary, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]types.JournalSummary, 0, len(files))
+	for _, path := range files {
+		j, err := load(path)
+		if err != nil {
+			config.ErrorLogger.Printf("Journal: Skipping unreadable journal %s: %v", path, err)
+			continue
+		}
+		summaries = append(summaries, j.Summary())
+	}
+	sort.Slice(summaries, func(a, b int) bool {
+		return summaries[a].CreatedAt.After(summaries[b].CreatedAt)
+	})
+	return summaries, nil
+}
+
+// load reads a journal file and replays its records. The returned journal is read-only until a file is attached.
+func load(path string) (*Journal, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening journal %s: %w", path, err)
+	}
+	defer file.Close()
+
+	j := &Journal{entries: make(map[string]*Entry)}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// A torn final line is expected if the process died mid-write; anything before it is still valid.
+			config.ErrorLogger.Printf("Journal: Ignoring malformed record on line %d of %s: %v", line, path, err)
+			continue
+		}
+		switch {
+		case rec.Header != nil:
+			j.header = *rec.Header
+		case rec.Entry != nil:
+			j.apply(*rec.Entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading journal %s: %w", path, err)
+	}
+	if j.header.JobID == "" {
+		return nil, fmt.Errorf("journal %s has no header", path)
+	}
+	return j, nil
+}
+
+// Header returns the journal's header.
+func (j *Journal) Header() Header {
+	if j == nil {
+		return Header{}
+	}
+	return j.header
+}
+
+// Plan records the given items as pending for kind/action.
+// Items already present in the journal keep their recorded status, so re-planning on resume is harmless.
+func (j *Journal) Plan(kind, action string, items []string) {
+	if j == nil || len(items) == 0 {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	for _, item := range items {
+		if _, exists := j.entries[entryKey(kind, action, item)]; exists {
+			continue
+		}
+		entry := Entry{Kind: kind, Action: action, Item: item, Status: StatusPending, Time: now}
+		j.apply(entry)
+		j.writeLocked(record{Entry: &entry})
+	}
+}
+
+// Record stores the outcome of a single item.
+func (j *Journal) Record(kind, action, item string, status ItemStatus, errMsg string) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry := Entry{Kind: kind, Action: action, Item: item, Status: status, Error: errMsg, Time: time.Now()}
+	j.apply(entry)
+	j.writeLocked(record{Entry: &entry})
+}
+
+// Pending returns the items for kind/action that are still pending or have failed, in plan order.
+func (j *Journal) Pending(kind, action string) []string {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var items []string
+	for _, key := range j.order {
+		entry := j.entries[key]
+		if entry.Kind == kind && entry.Action == action && entry.Status != StatusDone {
+			items = append(items, entry.Item)
+		}
+	}
+	return items
+}
+
+// Status returns the recorded status of an item, and false if the item was never planned.
+func (j *Journal) Status(kind, action, item string) (ItemStatus, bool) {
+	if j == nil {
+		return "", false
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry, ok := j.entries[entryKey(kind, action, item)]
+	if !ok {
+		return "", false
+	}
+	return entry.Status, true
+}
+
+// Summary returns the journal's header together with counts of items by status.
+func (j *Journal) Summary() types.JournalSummary {
+	if j == nil {
+		return types.JournalSummary{}
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	summary := types.JournalSummary{
+		JobID:       j.header.JobID,
+		Kind:        j.header.Kind,
+		OldUsername: j.header.OldUsername,
+		NewUsername: j.header.NewUsername,
+		CreatedAt:   j.header.CreatedAt,
+	}
+	for _, entry := range j.entries {
+		switch entry.Status {
+		case StatusPending:
+			summary.Pending++
+		case StatusDone:
+			summary.Done++
+		case StatusFailed:
+			summary.Failed++
+		}
+	}
+	return summary
+}
+
+// Report records item outcomes carried by progress events. It implements progress.Reporter,
+// so attaching a journal to a migration's context checkpoints every subscribe, follow, save and unsave result.
+func (j *Journal) Report(event types.ProgressEvent) {
+	if j == nil {
+		return
+	}
+	switch event.Type {
+	case types.SubredditChunkEvent:
+		failed := make(map[string]bool, len(event.FailedItems))
+		for _, item := range event.FailedItems {
+			failed[item] = true
+		}
+		for _, item := range event.Items {
+			if failed[item] {
+				j.Record(KindSubreddit, event.Action, item, StatusFailed, event.Error)
+			} else {
+				j.Record(KindSubreddit, event.Action, item, StatusDone, "")
+			}
+		}
+	case types.UserEvent:
+		j.Record(KindUser, event.Action, event.Item, outcome(event.Success), event.Error)
+	case types.PostEvent:
+		j.Record(KindPost, event.Action, event.Item, outcome(event.Success), event.Error)
+	case types.MultiredditEvent:
+		j.Record(KindMultireddit, event.Action, event.Item, outcome(event.Success), event.Error)
+	}
+}
+
+// Close closes the underlying journal file and releases it for other migrations.
+func (j *Journal) Close() error {
+	if j == nil || j.file == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	err := j.file.Close()
+	j.file = nil
+	release(j.header.JobID)
+	return err
+}
+
+// terminateTornLine appends a newline if the file does not end with one,
+// so records written after a crash mid-write do not get glued onto the torn line.
+func (j *Journal) terminateTornLine() error {
+	info, err := j.file.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+	last := make([]byte, 1)
+	if _, err := j.file.ReadAt(last, info.Size()-1); err != nil {
+		return fmt.Errorf("error reading journal for job %s: %w", j.header.JobID, err)
+	}
+	if last[0] == '\n' {
+		return nil
+	}
+	if _, err := j.file.Write([]byte{'\n'}); err != nil {
+		return fmt.Errorf("error repairing journal for job %s: %w", j.header.JobID, err)
+	}
+	return nil
+}
+
+// apply updates the in-memory state with an entry. The caller must hold j.mu or own j exclusively.
+func (j *Journal) apply(entry Entry) {
+	key := entryKey(entry.Kind, entry.Action, entry.Item)
+	if existing, ok := j.entries[key]; ok {
+		*existing = entry
+		return
+	}
+	j.entries[key] = &entry
+	j.order = append(j.order, key)
+}
+
+// write appends a record to the journal file.
+func (j *Journal) write(rec record) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.writeLocked(rec)
+}
+
+// writeLocked appends a record to the journal file. The caller must hold j.mu.
+// Write failures are logged rather than aborting the migration; the in-memory state stays authoritative.
+func (j *Journal) writeLocked(rec record) error {
+	if j.file == nil {
+		return nil
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		config.ErrorLogger.Printf("Journal: Error encoding record for job %s: %v", j.header.JobID, err)
+		return err
+	}
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		config.ErrorLogger.Printf("Journal: Error writing record for job %s: %v", j.header.JobID, err)
+		return err
+	}
+	return nil
+}
+
+// acquire marks a job's journal as open, failing if it already is.
+func acquire(jobID string) error {
+	openMu.Lock()
+	defer openMu.Unlock()
+	if openJournals[jobID] {
+		return ErrInUse
+	}
+	openJournals[jobID] = true
+	return nil
+}
+
+// release marks a job's journal as closed.
+func release(jobID string) {
+	openMu.Lock()
+	defer openMu.Unlock()
+	delete(openJournals, jobID)
+}
+
+// outcome converts a success flag into an item status.
+func outcome(success bool) ItemStatus {
+	if success {
+		return StatusDone
+	}
+	return StatusFailed
+}
+
+// entryKey identifies an item within a journal.
+func entryKey(kind, action, item string) string {
+	return strings.Join([]string{kind, action, item}, "\x00")
+}
+
+// journalPath returns the file path of a job's journal.
+func journalPath(dir, jobID string) string {
+	return filepath.Join(dir, filepath.Base(jobID)+".jsonl")
+}
@@ -0,0 +1,142 @@
+package journal_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/journal"
+)
+
+func TestMain(m *testing.M) {
+	config.InfoLogger = log.New(io.Discard, "", 0)
+	config.ErrorLogger = log.New(io.Discard, "", 0)
+	config.DebugLogger = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+// newJournal creates a journal for the test's job with three planned saves, the first done and the second failed.
+func newJournal(t *testing.T, dir string) *journal.Journal {
+	t.Helper()
+	jrnl, err := journal.Create(dir, journal.Header{
+		JobID:       t.Name(),
+		Kind:        "migrate",
+		OldUsername: "old_user",
+		NewUsername: "new_user",
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jrnl.Plan(journal.KindPost, "save", []string{"t3_a", "t3_b", "t3_c"})
+	jrnl.Record(journal.KindPost, "save", "t3_a", journal.StatusDone, "")
+	jrnl.Record(journal.KindPost, "save", "t3_b", journal.StatusFailed, "403")
+	return jrnl
+}
+
+func TestJournalRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	if err := newJournal(t, dir).Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	jrnl, err := journal.Open(dir, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jrnl.Close()
+	if header := jrnl.Header(); header.JobID != t.Name() || header.OldUsername != "old_user" || header.NewUsername != "new_user" {
+		t.Errorf("header = %+v", header)
+	}
+	if got, want := jrnl.Pending(journal.KindPost, "save"), []string{"t3_b", "t3_c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pending = %v, want %v", got, want)
+	}
+	if status, ok := jrnl.Status(journal.KindPost, "save", "t3_a"); !ok || status != journal.StatusDone {
+		t.Errorf("status of t3_a = %q, %v, want done", status, ok)
+	}
+	if _, ok := jrnl.Status(journal.KindPost, "unsave", "t3_a"); ok {
+		t.Error("t3_a has an unsave status but was never planned for it")
+	}
+
+	// Planning again on resume keeps recorded outcomes.
+	jrnl.Plan(journal.KindPost, "save", []string{"t3_a", "t3_d"})
+	if got, want := jrnl.Pending(journal.KindPost, "save"), []string{"t3_b", "t3_c", "t3_d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pending after re-planning = %v, want %v", got, want)
+	}
+	if summary := jrnl.Summary(); summary.Done != 1 || summary.Failed != 1 || summary.Pending != 2 {
+		t.Errorf("summary = %+v, want 1 done, 1 failed, 2 pending", summary)
+	}
+
+	summaries, err := journal.List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summaries) != 1 || summaries[0].JobID != t.Name() {
+		t.Errorf("List = %+v, want the test's journal", summaries)
+	}
+}
+
+func TestOpenRepairsTornLine(t *testing.T) {
+	dir := t.TempDir()
+	if err := newJournal(t, dir).Close(); err != nil {
+		t.Fatal(err)
+	}
+	// The process died while writing the outcome of t3_c.
+	file, err := os.OpenFile(filepath.Join(dir, t.Name()+".jsonl"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString(`{"entry":{"kind":"post","action":"save","item":"t3_c","sta`); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	jrnl, err := journal.Open(dir, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := jrnl.Pending(journal.KindPost, "save"), []string{"t3_b", "t3_c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pending with a torn last line = %v, want %v", got, want)
+	}
+	jrnl.Record(journal.KindPost, "save", "t3_c", journal.StatusDone, "")
+	jrnl.Close()
+
+	// The outcome recorded after the repair must not have been glued onto the torn line.
+	jrnl, err = journal.Open(dir, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jrnl.Close()
+	if got, want := jrnl.Pending(journal.KindPost, "save"), []string{"t3_b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pending after reopening = %v, want %v", got, want)
+	}
+}
+
+func TestOpenFailsWhileInUse(t *testing.T) {
+	dir := t.TempDir()
+	jrnl := newJournal(t, dir)
+
+	if _, err := journal.Open(dir, t.Name()); !errors.Is(err, journal.ErrInUse) {
+		t.Fatalf("opening a journal held by a running migration = %v, want ErrInUse", err)
+	}
+	if _, err := journal.Create(dir, journal.Header{JobID: t.Name()}); !errors.Is(err, journal.ErrInUse) {
+		t.Fatalf("recreating a journal held by a running migration = %v, want ErrInUse", err)
+	}
+	jrnl.Close()
+
+	reopened, err := journal.Open(dir, t.Name())
+	if err != nil {
+		t.Fatalf("opening a released journal: %v", err)
+	}
+	reopened.Close()
+
+	if _, err := journal.Open(dir, "unknown"); !errors.Is(err, journal.ErrNotFound) {
+		t.Errorf("opening a missing journal = %v, want ErrNotFound", err)
+	}
+}
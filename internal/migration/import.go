@@ -0,0 +1,207 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/journal"
+	"github.com/nileshnk/reddit-migrate/internal/progress"
+	"github.com/nileshnk/reddit-migrate/internal/reddit"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+)
+
+// ValidateArchive checks that an archive can be imported by this version of the tool.
+func ValidateArchive(archive types.AccountArchive) error {
+	if archive.SchemaVersion == 0 {
+		return fmt.Errorf("not an account archive: missing schema_version")
+	}
+	if archive.SchemaVersion > types.ArchiveSchemaVersion {
+		return fmt.Errorf("archive schema version %d is newer than the supported version %d", archive.SchemaVersion, types.ArchiveSchemaVersion)
+	}
+	return nil
+}
+
+// ImportArchive subscribes, follows, saves and recreates the custom feeds of the selected items of an exported archive on the destination account.
+// Selections are restricted to items present in the archive and, as in HandleCustomMigration, items already on the
+// destination account are skipped and posts are saved oldest first. The exporting account is never contacted,
+// so it does not need to exist anymore. When jobID is set, progress is checkpointed to the job's journal.
+func ImportArchive(ctx context.Context, jobID string, req types.ImportRequest) types.MigrationResponseType {
+	var finalResponse types.MigrationResponseType
+	archive := req.Archive
+
+	if err := ValidateArchive(archive); err != nil {
+		finalResponse.Message = err.Error()
+		config.ErrorLogger.Println(finalResponse.Message)
+		return finalResponse
+	}
+
+	defer holdTokens(req.AccessToken)()
+	token, username, err := ResolveAccount("destination", req.Profile, req.AuthMethod, req.Cookie, req.AccessToken, req.Username)
+	if err != nil {
+		config.ErrorLogger.Println(err)
+		finalResponse.Message = err.Error()
+		return finalResponse
+	}
+	defer holdTokens(token)()
+
+	subredditsToImport := selectFromArchive(archiveSubredditNames(archive), req.SelectedSubreddits)
+	usersToImport := selectFromArchive(archive.FollowedUsers, req.SelectedUsers)
+	postsToImport := selectFromArchive(archivePostNames(archive), req.SelectedPosts)
+	config.InfoLogger.Printf("Importing archive of %s (exported %s) into %s: %d subreddits, %d users, %d posts selected.",
+		archive.Username, archive.ExportedAt.Format("2006-01-02"), username, len(subredditsToImport), len(usersToImport), len(postsToImport))
+
+	var jrnl *journal.Journal
+	if req.DryRun {
+		config.InfoLogger.Println("Dry run requested. Computing import plan without modifying the account.")
+		finalResponse.Data.Plan = &types.MigrationPlan{}
+	} else {
+		jrnl = startJournal(jobID, "import", archive.Username, username, req.PreserveOrder)
+		defer jrnl.Close()
+		if jrnl != nil {
+			ctx = progress.WithReporter(ctx, jrnl)
+		}
+	}
+	plan := finalResponse.Data.Plan
+	var planErr error
+
+	if len(subredditsToImport)+len(usersToImport) > 0 {
+		config.InfoLogger.Printf("Fetching subreddits from %s to filter out duplicates...", username)
+		progress.Phase(ctx, types.PhaseFetchSubreddits, 0)
+		existing, err := reddit.FetchSubredditFullNames(token)
+		if err != nil {
+			config.ErrorLogger.Printf("Could not fetch subreddits from %s. Proceeding with all selected subreddits and users. Error: %v", username, err)
+			planErr = fmt.Errorf("failed to fetch subreddit names from destination account: %w", err)
+		} else {
+			selectedSubreddits, selectedUsers := subredditsToImport, usersToImport
+			subredditsToImport = filterSlice(selectedSubreddits, existing.DisplayNamesList)
+			usersToImport = filterSlice(selectedUsers, existing.UserDisplayNameList)
+			config.InfoLogger.Printf("Filtered selection: %d subreddits and %d users to import after removing %d duplicates.",
+				len(subredditsToImport), len(usersToImport), len(selectedSubreddits)+len(selectedUsers)-len(subredditsToImport)-len(usersToImport))
+			if plan != nil {
+				plan.SubredditsAlreadyPresent = filterSlice(selectedSubreddits, subredditsToImport)
+				plan.UsersAlreadyFollowed = filterSlice(selectedUsers, usersToImport)
+			}
+		}
+
+		if plan != nil {
+			plan.SubredditsToSubscribe = subredditsToImport
+			plan.UsersToFollow = usersToImport
+		} else {
+			jrnl.Plan(journal.KindSubreddit, string(types.SubscribeAction), subredditsToImport)
+			jrnl.Plan(journal.KindUser, string(types.SubscribeAction), usersToImport)
+
+			if len(subredditsToImport) > 0 && ctx.Err() == nil {
+				progress.Phase(ctx, types.PhaseSubscribeSubreddits, len(subredditsToImport))
+				finalResponse.Data.SubscribeSubreddit = reddit.ManageSubreddits(ctx, token, subredditsToImport, types.SubscribeAction, config.DefaultSubredditChunkSize)
+			}
+			if len(usersToImport) > 0 && ctx.Err() == nil {
+				progress.Phase(ctx, types.PhaseFollowUsers, len(usersToImport))
+				followedUsersResult := reddit.ManageFollowedUsers(ctx, token, usersToImport, types.SubscribeAction)
+				config.InfoLogger.Printf("Followed %d users for %s (failed: %d).", followedUsersResult.SuccessCount, username, followedUsersResult.FailedCount)
+			}
+		}
+	} else {
+		config.InfoLogger.Println("No subreddits or users selected for import")
+	}
+
+	if len(postsToImport) > 0 && ctx.Err() == nil {
+		config.InfoLogger.Printf("Fetching saved posts from %s to avoid duplicates...", username)
+		progress.Phase(ctx, types.PhaseFetchPosts, 0)
+		existing, err := reddit.FetchSavedPostsFullNames(token, username)
+		selectedPosts := postsToImport
+		if err != nil {
+			config.ErrorLogger.Printf("Could not fetch saved posts from %s. Proceeding with all %d selected posts. Error: %v", username, len(selectedPosts), err)
+			if planErr == nil {
+				planErr = fmt.Errorf("failed to fetch saved post names from destination account: %w", err)
+			}
+		} else {
+			postsToImport = filterSlice(selectedPosts, existing)
+			config.InfoLogger.Printf("Filtered selection: %d posts to import after removing %d duplicates.", len(postsToImport), len(selectedPosts)-len(postsToImport))
+		}
+
+		// Archives list saved posts newest first; save the oldest first so the order on the account matches.
+		for i, j := 0, len(postsToImport)-1; i < j; i, j = i+1, j-1 {
+			postsToImport[i], postsToImport[j] = postsToImport[j], postsToImport[i]
+		}
+
+		if plan != nil {
+			plan.PostsToSave = postsToImport
+			plan.PostsAlreadySaved = filterSlice(selectedPosts, postsToImport)
+		} else if len(postsToImport) > 0 {
+			jrnl.Plan(journal.KindPost, string(types.SaveAction), postsToImport)
+			progress.Phase(ctx, types.PhaseSavePosts, len(postsToImport))
+			finalResponse.Data.SavePost = savePosts(ctx, token, postsToImport, req.PreserveOrder)
+		} else {
+			config.InfoLogger.Println("No new posts to import from selection.")
+		}
+	} else {
+		config.InfoLogger.Println("No posts selected for import")
+	}
+
+	// Schema version 1 and 2 archives have no multireddits, so nothing is selected from them.
+	multisToImport := filterMultireddits(archive.Multireddits, req.SelectedMultireddits)
+	if len(multisToImport) > 0 && ctx.Err() == nil {
+		if err := copyMultireddits(ctx, jrnl, token, username, multisToImport, &finalResponse.Data); err != nil {
+			config.ErrorLogger.Printf("Error importing multireddits: %v", err)
+			if planErr == nil {
+				planErr = err
+			}
+		}
+	}
+
+	hasErrors := finalResponse.Data.SubscribeSubreddit.Error || finalResponse.Data.SavePost.FailedCount > 0 || finalResponse.Data.Multireddits.Error
+
+	if plan != nil && ctx.Err() == nil {
+		finishDryRun(&finalResponse, planErr)
+	} else if ctx.Err() != nil {
+		finalResponse.Message = "Import cancelled. Results cover only the operations completed before cancellation."
+		config.InfoLogger.Println("Import cancelled.")
+	} else if hasErrors {
+		finalResponse.Message = "Import completed with some errors. Check individual operation statuses."
+		config.InfoLogger.Println("Import completed with some errors.")
+	} else {
+		finalResponse.Success = true
+		finalResponse.Message = "Import completed successfully."
+		config.InfoLogger.Println("Import completed successfully.")
+	}
+
+	config.InfoLogger.Printf("Import summary - Subreddits subscribed: %d, Posts saved: %d",
+		finalResponse.Data.SubscribeSubreddit.SuccessCount, finalResponse.Data.SavePost.SuccessCount)
+
+	return finalResponse
+}
+
+// archiveSubredditNames returns the display names of the archive's subreddits.
+func archiveSubredditNames(archive types.AccountArchive) []string {
+	names := make([]string, 0, len(archive.Subreddits))
+	for _, subreddit := range archive.Subreddits {
+		names = append(names, subreddit.DisplayName)
+	}
+	return names
+}
+
+// archivePostNames returns the full names of the archive's saved posts and comments in saved listing order, newest first.
+func archivePostNames(archive types.AccountArchive) []string {
+	return reddit.SavedItemFullNames(archive.SavedPosts, archive.SavedComments)
+}
+
+// selectFromArchive returns the archive items that are selected, in archive order.
+// Selected names that are not in the archive are ignored.
+func selectFromArchive(archived, selected []string) []string {
+	wanted := make(map[string]bool, len(selected))
+	for _, item := range selected {
+		wanted[item] = true
+	}
+	var result []string
+	for _, item := range archived {
+		if wanted[item] {
+			result = append(result, item)
+			delete(wanted, item)
+		}
+	}
+	if len(wanted) > 0 {
+		config.DebugLogger.Printf("Ignoring %d selected items that are not in the archive.", len(wanted))
+	}
+	return result
+}
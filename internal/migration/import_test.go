@@ -0,0 +1,45 @@
+package migration
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/nileshnk/reddit-migrate/internal/reddit"
+	"github.com/nileshnk/reddit-migrate/internal/reddittest"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+)
+
+func TestImportArchiveKeepsInterleavedSavedOrder(t *testing.T) {
+	srv, oldAccount, newAccount := newAccountsWith(t, func(oldAccount, newAccount *reddittest.Account) {
+		// Saved posts and comments mixed, newest first, as the old account's saved listing shows them.
+		oldAccount.Saved = []string{"t1_e", "t3_d", "t3_c", "t1_b", "t3_a"}
+		newAccount.Saved = nil
+	})
+
+	posts, comments, err := reddit.FetchSavedItemsWithDetails(oldAccount.Token, oldAccount.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := types.AccountArchive{
+		SchemaVersion: types.ArchiveSchemaVersion,
+		Username:      oldAccount.Name,
+		SavedPosts:    posts,
+		SavedComments: comments,
+	}
+
+	resp := ImportArchive(context.Background(), "", types.ImportRequest{
+		AuthMethod:    "oauth",
+		AccessToken:   newAccount.Token,
+		Username:      newAccount.Name,
+		Archive:       archive,
+		SelectedPosts: reddit.SavedItemFullNames(posts, comments),
+		PreserveOrder: true,
+	})
+	if !resp.Success {
+		t.Fatalf("import failed: %s", resp.Message)
+	}
+	if got := srv.Account("new_user").Saved; !reflect.DeepEqual(got, oldAccount.Saved) {
+		t.Errorf("new account saved items = %v, want %v", got, oldAccount.Saved)
+	}
+}
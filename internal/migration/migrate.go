@@ -1,20 +1,26 @@
 package migration
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/nileshnk/reddit-migrate/internal/auth"
 	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/jobs"
+	"github.com/nileshnk/reddit-migrate/internal/journal"
+	"github.com/nileshnk/reddit-migrate/internal/progress"
 	"github.com/nileshnk/reddit-migrate/internal/reddit"
 	"github.com/nileshnk/reddit-migrate/internal/types"
 )
 
 // MigrationHandler is the HTTP handler for the /migrate endpoint.
-// It orchestrates the entire migration process based on the provided old and new account cookies and user preferences.
+// It validates the request and starts the migration as a background job, responding immediately with the job ID.
+// Progress and the final result are available through the /api/jobs endpoints.
 func MigrationHandler(w http.ResponseWriter, r *http.Request) {
 	config.DebugLogger.Printf("Received migration request from %s", r.RemoteAddr)
 
@@ -49,22 +55,35 @@ func MigrationHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	config.DebugLogger.Printf("Migration preferences: %+v", requestBody.Preferences)
 
-	// Perform the migration.
-	finalResponse := initializeMigration(requestBody)
+	// Start the migration in the background.
+	job, err := jobs.DefaultManager.Submit("migrate", func(ctx context.Context, jobID string) types.MigrationResponseType {
+		return RunMigration(ctx, jobID, requestBody)
+	})
+	if err != nil {
+		config.ErrorLogger.Printf("Error starting migration job for %s: %v", r.RemoteAddr, err)
+		errorResponse(w, "Failed to start migration job", http.StatusInternalServerError)
+		return
+	}
 
 	// Send response.
+	response := types.JobResponseType{
+		Success: true,
+		Message: "Migration job started",
+		Job:     job.Info(),
+	}
 	w.Header().Set("Content-Type", "application/json")
-	jsonResp, err := json.Marshal(finalResponse)
+	jsonResp, err := json.Marshal(response)
 	if err != nil {
-		config.ErrorLogger.Printf("Error marshalling migration response for %s: %v", r.RemoteAddr, err)
+		config.ErrorLogger.Printf("Error marshalling migration job response for %s: %v", r.RemoteAddr, err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 
+	w.WriteHeader(http.StatusAccepted)
 	if _, err := w.Write(jsonResp); err != nil {
-		config.ErrorLogger.Printf("Error writing migration response for %s: %v", r.RemoteAddr, err)
+		config.ErrorLogger.Printf("Error writing migration job response for %s: %v", r.RemoteAddr, err)
 	} else {
-		config.InfoLogger.Printf("Successfully sent migration response to %s. Success: %t", r.RemoteAddr, finalResponse.Success)
+		config.InfoLogger.Printf("Started migration job %s for %s.", job.ID, r.RemoteAddr)
 	}
 }
 
@@ -85,110 +104,137 @@ func decodeMigrationRequest(r *http.Request, requestBody *types.MigrationRequest
 	return nil
 }
 
-// initializeMigration orchestrates the entire migration process based on authentication data and preferences.
+// RunMigration orchestrates the entire migration process based on authentication data and preferences.
 // It verifies accounts, fetches data, and performs migration actions like subscribing/unsubscribing subreddits and saving/unsaving posts.
-func initializeMigration(req types.MigrationRequestType) types.MigrationResponseType {
+// Cancelling ctx stops the migration after the in-flight requests; phases not yet started are skipped.
+// When jobID is set, every planned action and its outcome is checkpointed to the job's journal so the migration can be resumed.
+func RunMigration(ctx context.Context, jobID string, req types.MigrationRequestType) types.MigrationResponseType {
 	var finalResponse types.MigrationResponseType
 	finalResponse.Success = false // Default to false
 
 	config.InfoLogger.Println("Starting migration process...")
+	defer holdTokens(req.OldAccountToken, req.NewAccountToken)()
 
-	// Extract authentication data for old account
-	var oldAccountToken, oldAccountUsername string
-	var err error
-
-	if req.AuthMethod == "oauth" {
-		oldAccountToken = req.OldAccountToken
-		// Use provided username if available, otherwise get from OAuth token
-		if req.OldAccountUsername != "" {
-			oldAccountUsername = req.OldAccountUsername
-		} else {
-			// Get username from OAuth token
-			userInfo, err := auth.GetUserInfoWithToken(oldAccountToken)
-			if err != nil {
-				config.ErrorLogger.Printf("Failed to verify old account OAuth token: %v", err)
-				finalResponse.Message = fmt.Sprintf("Failed to verify old account OAuth token: %v", err)
-				return finalResponse
-			}
-			oldAccountUsername = userInfo.Data.Name
-		}
-	} else {
-		// Cookie-based authentication (default/backward compatibility)
-		oldAccountUsername, err = auth.GetUsernameFromCookie(req.OldAccountCookie)
-		if err != nil {
-			config.ErrorLogger.Printf("Failed to verify old account cookie: %v", err)
-			finalResponse.Message = fmt.Sprintf("Failed to verify old account cookie: %v", err)
-			return finalResponse
-		}
-		oldAccountToken = auth.ParseTokenFromCookie(req.OldAccountCookie)
-		if oldAccountToken == "" {
-			config.ErrorLogger.Println("Failed to parse OAuth token from old account cookie.")
-			finalResponse.Message = "Failed to parse OAuth token from old account cookie. Ensure 'token_v2' is present."
-			return finalResponse
-		}
+	oldAccountToken, oldAccountUsername, err := ResolveAccount("old", req.OldAccountProfile, req.AuthMethod, req.OldAccountCookie, req.OldAccountToken, req.OldAccountUsername)
+	if err != nil {
+		config.ErrorLogger.Println(err)
+		finalResponse.Message = err.Error()
+		return finalResponse
+	}
+	newAccountToken, newAccountUsername, err := ResolveAccount("new", req.NewAccountProfile, req.AuthMethod, req.NewAccountCookie, req.NewAccountToken, req.NewAccountUsername)
+	if err != nil {
+		config.ErrorLogger.Println(err)
+		finalResponse.Message = err.Error()
+		return finalResponse
 	}
 
-	// Extract authentication data for new account
-	var newAccountToken, newAccountUsername string
+	defer holdTokens(oldAccountToken, newAccountToken)()
 
-	if req.AuthMethod == "oauth" {
-		newAccountToken = req.NewAccountToken
-		// Use provided username if available, otherwise get from OAuth token
-		if req.NewAccountUsername != "" {
-			newAccountUsername = req.NewAccountUsername
-		} else {
-			// Get username from OAuth token
-			userInfo, err := auth.GetUserInfoWithToken(newAccountToken)
-			if err != nil {
-				config.ErrorLogger.Printf("Failed to verify new account OAuth token: %v", err)
-				finalResponse.Message = fmt.Sprintf("Failed to verify new account OAuth token: %v", err)
-				return finalResponse
-			}
-			newAccountUsername = userInfo.Data.Name
-		}
+	config.InfoLogger.Printf("Verified old account: %s, new account: %s", oldAccountUsername, newAccountUsername)
+	config.DebugLogger.Printf("Old account token (suffix): ...%s", auth.SafeSuffix(oldAccountToken, 6))
+	config.DebugLogger.Printf("New account token (suffix): ...%s", auth.SafeSuffix(newAccountToken, 6))
+
+	// A dry run only fetches and filters; the plan is collected in finalResponse.Data.Plan instead of being executed.
+	var jrnl *journal.Journal
+	if req.Preferences.DryRun {
+		config.InfoLogger.Println("Dry run requested. Computing migration plan without modifying either account.")
+		finalResponse.Data.Plan = &types.MigrationPlan{}
 	} else {
-		// Cookie-based authentication (default/backward compatibility)
-		newAccountUsername, err = auth.GetUsernameFromCookie(req.NewAccountCookie)
-		if err != nil {
-			config.ErrorLogger.Printf("Failed to verify new account cookie: %v", err)
-			finalResponse.Message = fmt.Sprintf("Failed to verify new account cookie: %v", err)
-			return finalResponse
-		}
-		newAccountToken = auth.ParseTokenFromCookie(req.NewAccountCookie)
-		if newAccountToken == "" {
-			config.ErrorLogger.Println("Failed to parse OAuth token from new account cookie.")
-			finalResponse.Message = "Failed to parse OAuth token from new account cookie. Ensure 'token_v2' is present."
-			return finalResponse
+		jrnl = startJournal(jobID, "migrate", oldAccountUsername, newAccountUsername, req.Preferences.PreserveOrderBool)
+		defer jrnl.Close()
+		if jrnl != nil {
+			ctx = progress.WithReporter(ctx, jrnl)
 		}
 	}
 
-	config.InfoLogger.Printf("Verified old account: %s, new account: %s", oldAccountUsername, newAccountUsername)
-	config.DebugLogger.Printf("Old account token (suffix): ...%s", auth.SafeSuffix(oldAccountToken, 6))
-	config.DebugLogger.Printf("New account token (suffix): ...%s", auth.SafeSuffix(newAccountToken, 6))
+	var planErr error
 
 	// Handle subreddit migration/deletion.
 	if req.Preferences.MigrateSubredditBool || req.Preferences.DeleteSubredditBool {
-		if err := processSubreddits(oldAccountToken, newAccountToken, oldAccountUsername, newAccountUsername, req.Preferences, &finalResponse.Data); err != nil {
+		if err := processSubreddits(ctx, jrnl, oldAccountToken, newAccountToken, oldAccountUsername, newAccountUsername, req.Preferences, &finalResponse.Data); err != nil {
 			config.ErrorLogger.Printf("Error processing subreddits: %v", err)
 			// Message is set within processSubreddits or its sub-functions for partial success.
 			// If a critical error occurs, it might stop here.
+			planErr = err
 		}
 	}
 
 	// Handle post migration/deletion.
-	if req.Preferences.MigratePostBool || req.Preferences.DeletePostBool {
-		if err := processPosts(oldAccountToken, newAccountToken, oldAccountUsername, newAccountUsername, req.Preferences, &finalResponse.Data); err != nil {
+	if (req.Preferences.MigratePostBool || req.Preferences.DeletePostBool) && ctx.Err() == nil {
+		if err := processPosts(ctx, jrnl, oldAccountToken, newAccountToken, oldAccountUsername, newAccountUsername, req.Preferences, &finalResponse.Data); err != nil {
 			config.ErrorLogger.Printf("Error processing posts: %v", err)
 			// Similar to subreddits, messages handled internally for partial success.
+			if planErr == nil {
+				planErr = err
+			}
+		}
+	}
+
+	// Handle vote migration.
+	if (req.Preferences.MigrateUpvotesBool || req.Preferences.MigrateDownvotesBool) && ctx.Err() == nil {
+		if err := processVotes(ctx, jrnl, oldAccountToken, newAccountToken, oldAccountUsername, newAccountUsername, req.Preferences, &finalResponse.Data); err != nil {
+			config.ErrorLogger.Printf("Error processing votes: %v", err)
+			if planErr == nil {
+				planErr = err
+			}
+		}
+	}
+
+	// Handle hidden post migration/unhiding.
+	if (req.Preferences.MigrateHiddenBool || req.Preferences.UnhideOldBool) && ctx.Err() == nil {
+		if err := processHidden(ctx, jrnl, oldAccountToken, newAccountToken, oldAccountUsername, newAccountUsername, req.Preferences, &finalResponse.Data); err != nil {
+			config.ErrorLogger.Printf("Error processing hidden posts: %v", err)
+			if planErr == nil {
+				planErr = err
+			}
+		}
+	}
+
+	// Handle multireddit (custom feed) migration.
+	if req.Preferences.MigrateMultiredditsBool && ctx.Err() == nil {
+		if err := processMultireddits(ctx, jrnl, oldAccountToken, newAccountToken, oldAccountUsername, newAccountUsername, req.Preferences.SelectedMultireddits, &finalResponse.Data); err != nil {
+			config.ErrorLogger.Printf("Error processing multireddits: %v", err)
+			if planErr == nil {
+				planErr = err
+			}
+		}
+	}
+
+	// Handle blocked users.
+	if req.Preferences.MigrateBlockedBool && ctx.Err() == nil {
+		if err := processBlocked(ctx, jrnl, oldAccountToken, newAccountToken, oldAccountUsername, newAccountUsername, &finalResponse.Data); err != nil {
+			config.ErrorLogger.Printf("Error processing blocked users: %v", err)
+			if planErr == nil {
+				planErr = err
+			}
+		}
+	}
+
+	// Handle account preferences.
+	if req.Preferences.MigratePrefsBool && ctx.Err() == nil {
+		if err := processPrefs(ctx, oldAccountToken, newAccountToken, oldAccountUsername, newAccountUsername, req.Preferences.PrefKeys, &finalResponse.Data); err != nil {
+			config.ErrorLogger.Printf("Error processing preferences: %v", err)
+			if planErr == nil {
+				planErr = err
+			}
 		}
 	}
 
 	// Determine overall success and message.
 	// A more sophisticated check might be needed if partial successes are not considered overall success.
-	if finalResponse.Data.SubscribeSubreddit.Error || finalResponse.Data.UnsubscribeSubreddit.Error ||
-		finalResponse.Data.SavePost.FailedCount > 0 || finalResponse.Data.UnsavePost.FailedCount > 0 {
+	if finalResponse.Data.Plan != nil && ctx.Err() == nil {
+		finishDryRun(&finalResponse, planErr)
+	} else if ctx.Err() != nil {
+		finalResponse.Success = false
+		finalResponse.Message = "Migration cancelled. Results cover only the operations completed before cancellation."
+		config.InfoLogger.Println("Migration process cancelled.")
+	} else if finalResponse.Data.SubscribeSubreddit.Error || finalResponse.Data.UnsubscribeSubreddit.Error ||
+		finalResponse.Data.SavePost.FailedCount > 0 || finalResponse.Data.UnsavePost.FailedCount > 0 ||
+		finalResponse.Data.UpvotePost.FailedCount > 0 || finalResponse.Data.DownvotePost.FailedCount > 0 ||
+		finalResponse.Data.HidePost.FailedCount > 0 || finalResponse.Data.UnhidePost.FailedCount > 0 ||
+		finalResponse.Data.Multireddits.Error || finalResponse.Data.BlockUser.Error || finalResponse.Data.Prefs.Error {
 		finalResponse.Success = false
-		finalResponse.Message = "Migration completed with some errors. Check individual operation statuses."
+		finalResponse.Message = "Migration completed with some errors. Check individual operation statuses." + missingScopeHint(finalResponse.Data)
 		config.InfoLogger.Println("Migration process completed with some errors.")
 	} else {
 		finalResponse.Success = true
@@ -199,6 +245,128 @@ func initializeMigration(req types.MigrationRequestType) types.MigrationResponse
 	return finalResponse
 }
 
+// ResolveAccount extracts the API token and username of one account from the request's authentication data.
+// A profile name takes precedence and logs in with that profile of the unlocked vault.
+// With OAuth the token is used as-is and the username is looked up when not provided; with cookies both are derived from the cookie.
+// label ("old" or "new") is used in error messages.
+func ResolveAccount(label, profile, authMethod, cookie, token, username string) (string, string, error) {
+	if profile != "" {
+		token, username, err := auth.LoginProfile(context.Background(), profile)
+		if err != nil {
+			return "", "", fmt.Errorf("Failed to log in to %s account with saved profile: %w", label, err)
+		}
+		return token, username, nil
+	}
+
+	if authMethod == "oauth" {
+		// Use provided username if available, otherwise get from OAuth token
+		if username != "" {
+			return token, username, nil
+		}
+		userInfo, err := auth.GetUserInfoWithToken(token)
+		if err != nil {
+			return "", "", fmt.Errorf("Failed to verify %s account OAuth token: %w", label, err)
+		}
+		if userInfo.Data.Name == "" {
+			return "", "", fmt.Errorf("Failed to verify %s account OAuth token: no username in response", label)
+		}
+		return token, userInfo.Data.Name, nil
+	}
+
+	// Cookie-based authentication (default/backward compatibility)
+	username, err := auth.GetUsernameFromCookie(cookie)
+	if err != nil {
+		return "", "", fmt.Errorf("Failed to verify %s account cookie: %w", label, err)
+	}
+	token = auth.ParseTokenFromCookie(cookie)
+	if token == "" {
+		return "", "", fmt.Errorf("Failed to parse OAuth token from %s account cookie. Ensure 'token_v2' is present.", label)
+	}
+	return token, username, nil
+}
+
+// holdTokens keeps the given OAuth access tokens fresh until the returned function is called: requests made with them
+// send a refreshed token once the original nears expiry or is rejected with 401, so long migrations outlive the
+// one-hour token lifetime. Tokens that cannot be refreshed (cookies, pasted tokens, empty strings) are ignored.
+//
+// Callers hold the request's tokens right away and hold them again once the accounts are resolved, since the tokens
+// of saved profiles are only known then. Holding a token twice is harmless.
+func holdTokens(tokens ...string) (release func()) {
+	var releases []func()
+	for _, token := range tokens {
+		if token == "" {
+			continue
+		}
+		if source := auth.TokenSourceFor(token); source != nil {
+			releases = append(releases, reddit.RegisterTokenSource(token, source))
+		}
+	}
+	return func() {
+		for _, release := range releases {
+			release()
+		}
+	}
+}
+
+// missingScopeHint returns a sentence to append to the final message when the new account refused a phase for lack of
+// an OAuth scope, or "" when none did.
+func missingScopeHint(data types.MigrationDetails) string {
+	if data.BlockUser.StatusCode == http.StatusForbidden || data.Prefs.StatusCode == http.StatusForbidden {
+		return " The new account is " + reddit.MissingScopeMessage("account") + "."
+	}
+	return ""
+}
+
+// startJournal creates the checkpoint journal for a migration job.
+// preserveOrder is recorded so a resume saves the remaining posts in the same mode.
+// It returns nil when jobID is empty or the journal cannot be created; the migration then runs without checkpoints.
+func startJournal(jobID, kind, oldUser, newUser string, preserveOrder bool) *journal.Journal {
+	if jobID == "" {
+		return nil
+	}
+	jrnl, err := journal.Create(config.JournalDir, journal.Header{
+		JobID:         jobID,
+		Kind:          kind,
+		OldUsername:   oldUser,
+		NewUsername:   newUser,
+		CreatedAt:     time.Now(),
+		PreserveOrder: preserveOrder,
+	})
+	if err != nil {
+		config.ErrorLogger.Printf("Could not create journal for job %s, migration will not be resumable: %v", jobID, err)
+		return nil
+	}
+	return jrnl
+}
+
+// finishDryRun sets the final status and message of a dry run.
+// A plan is only reported as successful if every fetch it depends on succeeded, since a partial plan would be misleading.
+func finishDryRun(finalResponse *types.MigrationResponseType, planErr error) {
+	if planErr != nil {
+		finalResponse.Success = false
+		finalResponse.Message = fmt.Sprintf("Dry run could not compute the complete plan: %v", planErr)
+		config.ErrorLogger.Println(finalResponse.Message)
+		return
+	}
+	plan := finalResponse.Data.Plan
+	finalResponse.Success = true
+	finalResponse.Message = "Dry run completed. No changes were made to either account."
+	config.InfoLogger.Printf("Dry run plan: subscribe %d, unsubscribe %d, follow %d, save %d, unsave %d, upvote %d, downvote %d, hide %d, unhide %d, create feeds %d, merge feeds %d, block %d, change preferences %d.",
+		len(plan.SubredditsToSubscribe), len(plan.SubredditsToUnsubscribe), len(plan.UsersToFollow), len(plan.PostsToSave), len(plan.PostsToUnsave),
+		len(plan.PostsToUpvote), len(plan.PostsToDownvote), len(plan.PostsToHide), len(plan.PostsToUnhide),
+		len(plan.MultiredditsToCreate), len(plan.MultiredditsToMerge), len(plan.UsersToBlock), len(plan.PrefChanges))
+}
+
+// savePosts saves posts on the account in the given order. With preserveOrder the saves are strictly sequenced so the
+// account's saved list ends up in that order; otherwise they go through the concurrent worker pool, which is faster but
+// scrambles the order. Only saving needs this: unsaving, voting and hiding are always done concurrently.
+func savePosts(ctx context.Context, token string, postIDs []string, preserveOrder bool) types.ManagePostResponseType {
+	if preserveOrder {
+		return reddit.ManageSavedPostsInOrder(ctx, token, postIDs, types.SaveAction)
+	}
+	return reddit.ManageSavedPosts(ctx, token, postIDs, types.SaveAction, config.DefaultPostConcurrency)
+}
+
 // filterSlice removes items from a source slice that are present in a toRemoveItems slice.
 func filterSlice(source []string, toRemoveItems []string) []string {
 	toRemoveMap := make(map[string]bool)
@@ -216,8 +384,9 @@ func filterSlice(source []string, toRemoveItems []string) []string {
 }
 
 // processSubreddits handles the migration and/or deletion of subreddits.
-func processSubreddits(oldToken, newToken, oldUser, newUser string, prefs types.PreferencesType, responseData *types.MigrationDetails) error { // Adjusted types
+func processSubreddits(ctx context.Context, jrnl *journal.Journal, oldToken, newToken, oldUser, newUser string, prefs types.PreferencesType, responseData *types.MigrationDetails) error { // Adjusted types
 	config.InfoLogger.Println("Fetching all subreddit and followed user names from old account...")
+	progress.Phase(ctx, types.PhaseFetchSubreddits, 0)
 	// Use reddit.FetchSubredditFullNames
 	oldSubredditNameList, err := reddit.FetchSubredditFullNames(oldToken)
 	if err != nil {
@@ -228,6 +397,7 @@ func processSubreddits(oldToken, newToken, oldUser, newUser string, prefs types.
 		len(oldSubredditNameList.UserDisplayNameList), oldUser)
 
 	// Migrate (subscribe) subreddits to the new account.
+	var failedToSubscribe []string
 	if prefs.MigrateSubredditBool {
 		config.InfoLogger.Printf("Fetching subreddits from new account %s to filter out duplicates...", newUser)
 		newSubredditNameList, err := reddit.FetchSubredditFullNames(newToken)
@@ -235,7 +405,9 @@ func processSubreddits(oldToken, newToken, oldUser, newUser string, prefs types.
 		subredditsToMigrate := oldSubredditNameList.DisplayNamesList
 		followedToMigrate := oldSubredditNameList.UserDisplayNameList
 
-		if err != nil {
+		if err != nil && responseData.Plan != nil {
+			return fmt.Errorf("failed to fetch subreddit names from new account: %w", err)
+		} else if err != nil {
 			config.ErrorLogger.Printf("Could not fetch subreddits from new account. Proceeding with all subreddits and followed users. Error: %v", err)
 		} else {
 			subredditsToMigrate = filterSlice(oldSubredditNameList.DisplayNamesList, newSubredditNameList.DisplayNamesList)
@@ -245,16 +417,36 @@ func processSubreddits(oldToken, newToken, oldUser, newUser string, prefs types.
 			config.InfoLogger.Printf("Filtered selection: %d followed users to migrate after removing duplicates.", len(followedToMigrate))
 		}
 
+		if plan := responseData.Plan; plan != nil {
+			plan.SubredditsToSubscribe = subredditsToMigrate
+			plan.SubredditsAlreadyPresent = filterSlice(oldSubredditNameList.DisplayNamesList, subredditsToMigrate)
+			plan.UsersToFollow = followedToMigrate
+			plan.UsersAlreadyFollowed = filterSlice(oldSubredditNameList.UserDisplayNameList, followedToMigrate)
+			if prefs.DeleteSubredditBool {
+				plan.SubredditsToUnsubscribe = oldSubredditNameList.DisplayNamesList
+			}
+			return nil
+		}
+
+		jrnl.Plan(journal.KindSubreddit, string(types.SubscribeAction), subredditsToMigrate)
+		jrnl.Plan(journal.KindUser, string(types.SubscribeAction), followedToMigrate)
+		if prefs.DeleteSubredditBool {
+			jrnl.Plan(journal.KindSubreddit, string(types.UnsubscribeAction), oldSubredditNameList.DisplayNamesList)
+		}
+
 		if len(subredditsToMigrate) > 0 {
 			config.InfoLogger.Printf("Starting subreddit migration for %s -> %s.", oldUser, newUser)
-			responseData.SubscribeSubreddit = migrateSubredditsWithRetry(newToken, subredditsToMigrate, newUser)
+			progress.Phase(ctx, types.PhaseSubscribeSubreddits, len(subredditsToMigrate))
+			responseData.SubscribeSubreddit = migrateSubredditsWithRetry(ctx, newToken, subredditsToMigrate, newUser)
+			failedToSubscribe = responseData.SubscribeSubreddit.FailedSubreddits
 		} else {
 			config.InfoLogger.Printf("No new subreddits to migrate for %s.", newUser)
 		}
 
 		if len(followedToMigrate) > 0 {
 			config.InfoLogger.Printf("Starting followed user migration for %s -> %s.", oldUser, newUser)
-			followedUsersResult := reddit.ManageFollowedUsers(newToken, followedToMigrate, types.SubscribeAction)
+			progress.Phase(ctx, types.PhaseFollowUsers, len(followedToMigrate))
+			followedUsersResult := reddit.ManageFollowedUsers(ctx, newToken, followedToMigrate, types.SubscribeAction)
 			config.InfoLogger.Printf("Followed %d users for %s (failed: %d).", followedUsersResult.SuccessCount, newUser, followedUsersResult.FailedCount)
 		} else {
 			config.InfoLogger.Printf("No followed users to migrate for %s.", oldUser)
@@ -262,10 +454,23 @@ func processSubreddits(oldToken, newToken, oldUser, newUser string, prefs types.
 	}
 
 	// Delete (unsubscribe) subreddits from the old account.
-	if prefs.DeleteSubredditBool {
+	// Subreddits that could not be subscribed on the new account are kept, so nothing is lost; they stay pending in the journal.
+	if prefs.DeleteSubredditBool && ctx.Err() == nil {
+		if responseData.Plan != nil {
+			responseData.Plan.SubredditsToUnsubscribe = oldSubredditNameList.DisplayNamesList
+			return nil
+		}
+		if !prefs.MigrateSubredditBool {
+			jrnl.Plan(journal.KindSubreddit, string(types.UnsubscribeAction), oldSubredditNameList.DisplayNamesList)
+		}
+		subredditsToDelete := filterSlice(oldSubredditNameList.DisplayNamesList, failedToSubscribe)
+		if len(failedToSubscribe) > 0 {
+			config.InfoLogger.Printf("Keeping %d subreddits on %s that failed to migrate.", len(failedToSubscribe), oldUser)
+		}
 		config.InfoLogger.Printf("Starting subreddit deletion (unsubscribing) from %s.", oldUser)
+		progress.Phase(ctx, types.PhaseUnsubscribeSubreddits, len(subredditsToDelete))
 		// Use reddit.ManageSubreddits
-		unsubscribeData := reddit.ManageSubreddits(oldToken, oldSubredditNameList.DisplayNamesList, types.UnsubscribeAction, 500)
+		unsubscribeData := reddit.ManageSubreddits(ctx, oldToken, subredditsToDelete, types.UnsubscribeAction, 500)
 		config.InfoLogger.Printf("Unsubscribed %d subreddits from %s (failed: %d).", unsubscribeData.SuccessCount, oldUser, unsubscribeData.FailedCount)
 		responseData.UnsubscribeSubreddit = unsubscribeData
 	}
@@ -273,18 +478,18 @@ func processSubreddits(oldToken, newToken, oldUser, newUser string, prefs types.
 }
 
 // migrateSubredditsWithRetry attempts to subscribe to subreddits with a retry mechanism.
-func migrateSubredditsWithRetry(token string, displayNames []string, username string) types.ManageSubredditResponseType { // Adjusted type
+func migrateSubredditsWithRetry(ctx context.Context, token string, displayNames []string, username string) types.ManageSubredditResponseType { // Adjusted type
 	// TODO: These should come from config
 	subredditChunkSize := config.DefaultSubredditChunkSize // Initial chunk size for subscribing.
 	maxRetryAttempts := config.MaxSubredditRetryAttempts   // Maximum number of retry attempts.
 
 	config.InfoLogger.Printf("Migrating %d subreddits to account %s.", len(displayNames), username)
 
-	subscribeData := reddit.ManageSubreddits(token, displayNames, types.SubscribeAction, subredditChunkSize)
+	subscribeData := reddit.ManageSubreddits(ctx, token, displayNames, types.SubscribeAction, subredditChunkSize)
 	config.InfoLogger.Printf("Initial subscription attempt for %s: %d successful, %d failed.", username, subscribeData.SuccessCount, subscribeData.FailedCount)
 
 	retryAttempts := 1
-	for subscribeData.FailedCount > 0 && retryAttempts <= maxRetryAttempts {
+	for subscribeData.FailedCount > 0 && retryAttempts <= maxRetryAttempts && ctx.Err() == nil {
 		config.InfoLogger.Printf("Retrying %d failed subreddits for %s (attempt %d/%d). Chunk size: %d",
 			subscribeData.FailedCount, username, retryAttempts, maxRetryAttempts, subredditChunkSize/retryAttempts)
 
@@ -292,7 +497,7 @@ func migrateSubredditsWithRetry(token string, displayNames []string, username st
 		subscribeData.FailedSubreddits = nil
 		subscribeData.FailedCount = 0
 
-		retryResult := reddit.ManageSubreddits(token, failedToRetry, types.SubscribeAction, subredditChunkSize/retryAttempts)
+		retryResult := reddit.ManageSubreddits(ctx, token, failedToRetry, types.SubscribeAction, subredditChunkSize/retryAttempts)
 
 		subscribeData.SuccessCount += retryResult.SuccessCount
 		subscribeData.FailedCount = retryResult.FailedCount
@@ -313,19 +518,20 @@ func migrateSubredditsWithRetry(token string, displayNames []string, username st
 }
 
 // processPosts handles the migration and/or deletion of saved posts.
-func processPosts(oldToken, newToken, oldUser, newUser string, prefs types.PreferencesType, responseData *types.MigrationDetails) error { // Adjusted types
+func processPosts(ctx context.Context, jrnl *journal.Journal, oldToken, newToken, oldUser, newUser string, prefs types.PreferencesType, responseData *types.MigrationDetails) error { // Adjusted types
 	config.InfoLogger.Printf("Fetching saved post full names from old account %s...", oldUser)
+	progress.Phase(ctx, types.PhaseFetchPosts, 0)
 
 	oldSavedPostsFullNamesList, err := reddit.FetchSavedPostsFullNames(oldToken, oldUser)
 	if err != nil {
 		return fmt.Errorf("failed to fetch saved post names from %s: %w", oldUser, err)
 	}
 
-	config.InfoLogger.Printf("Fetching saved post full names from old account %s...", oldUser)
+	config.InfoLogger.Printf("Fetching saved post full names from new account %s...", newUser)
 
 	newSavedPostsFullNamesList, err := reddit.FetchSavedPostsFullNames(newToken, newUser)
 	if err != nil {
-		return fmt.Errorf("failed to fetch saved post names from %s: %w", newToken, err)
+		return fmt.Errorf("failed to fetch saved post names from %s: %w", newUser, err)
 	}
 
 	config.InfoLogger.Printf("Analyzing and selecting only posts that are not added in the new account: %s from old account: %s...", newUser, oldUser)
@@ -333,8 +539,6 @@ func processPosts(oldToken, newToken, oldUser, newUser string, prefs types.Prefe
 	// Filter out posts that are already saved in the new account
 	savedPostsFullNamesList := filterSlice(oldSavedPostsFullNamesList, newSavedPostsFullNamesList)
 
-	fmt.Println(savedPostsFullNamesList)
-
 	config.InfoLogger.Printf("Found %d unique posts in old account that aren't in new account", len(savedPostsFullNamesList))
 
 	// Reverse the order so that oldest posts are saved first to maintain chronological order in new account
@@ -345,24 +549,202 @@ func processPosts(oldToken, newToken, oldUser, newUser string, prefs types.Prefe
 
 	config.InfoLogger.Printf("Fetched %d saved posts from %s.", len(savedPostsFullNamesList), oldUser)
 
+	if plan := responseData.Plan; plan != nil {
+		if prefs.MigratePostBool {
+			plan.PostsToSave = savedPostsFullNamesList
+			plan.PostsAlreadySaved = filterSlice(oldSavedPostsFullNamesList, savedPostsFullNamesList)
+		}
+		if prefs.DeletePostBool {
+			plan.PostsToUnsave = savedPostsFullNamesList
+		}
+		return nil
+	}
+
 	concurrencyForPosts := config.DefaultPostConcurrency // Concurrency level for post operations.
 
+	if prefs.MigratePostBool {
+		jrnl.Plan(journal.KindPost, string(types.SaveAction), savedPostsFullNamesList)
+	}
+	if prefs.DeletePostBool {
+		jrnl.Plan(journal.KindPost, string(types.UnsaveAction), savedPostsFullNamesList)
+	}
+
+	var failedToSave []string
 	if prefs.MigratePostBool { // Adjusted field name
 		config.InfoLogger.Printf("Starting saved post migration for %s -> %s (%d posts).", oldUser, newUser, len(savedPostsFullNamesList))
-		savePostsResponse := reddit.ManageSavedPosts(newToken, savedPostsFullNamesList, types.SaveAction, concurrencyForPosts)
+		progress.Phase(ctx, types.PhaseSavePosts, len(savedPostsFullNamesList))
+		savePostsResponse := savePosts(ctx, newToken, savedPostsFullNamesList, prefs.PreserveOrderBool)
 		config.InfoLogger.Printf("Saved %d posts to %s (failed: %d).", savePostsResponse.SuccessCount, newUser, savePostsResponse.FailedCount)
 		responseData.SavePost = savePostsResponse
+		failedToSave = savePostsResponse.FailedPosts
 	}
 
-	if prefs.DeletePostBool { // Adjusted field name
-		config.InfoLogger.Printf("Starting saved post deletion (unsaving) from %s (%d posts).", oldUser, len(savedPostsFullNamesList))
-		unsavePostsResponse := reddit.ManageSavedPosts(oldToken, savedPostsFullNamesList, types.UnsaveAction, concurrencyForPosts)
+	// Only unsave posts that are safely saved on the new account; the rest stay pending in the journal.
+	if prefs.DeletePostBool && ctx.Err() == nil { // Adjusted field name
+		postsToUnsave := filterSlice(savedPostsFullNamesList, failedToSave)
+		config.InfoLogger.Printf("Starting saved post deletion (unsaving) from %s (%d posts).", oldUser, len(postsToUnsave))
+		progress.Phase(ctx, types.PhaseUnsavePosts, len(postsToUnsave))
+		unsavePostsResponse := reddit.ManageSavedPosts(ctx, oldToken, postsToUnsave, types.UnsaveAction, concurrencyForPosts)
 		config.InfoLogger.Printf("Unsaved %d posts from %s (failed: %d).", unsavePostsResponse.SuccessCount, oldUser, unsavePostsResponse.FailedCount)
 		responseData.UnsavePost = unsavePostsResponse
 	}
 	return nil
 }
 
+// processVotes re-casts the old account's upvotes and/or downvotes on the new account.
+func processVotes(ctx context.Context, jrnl *journal.Journal, oldToken, newToken, oldUser, newUser string, prefs types.PreferencesType, responseData *types.MigrationDetails) error {
+	if prefs.MigrateUpvotesBool {
+		result, err := migrateVotes(ctx, jrnl, oldToken, newToken, oldUser, newUser, types.UpvoteAction, responseData.Plan)
+		if err != nil {
+			return err
+		}
+		responseData.UpvotePost = result
+	}
+	if prefs.MigrateDownvotesBool && ctx.Err() == nil {
+		result, err := migrateVotes(ctx, jrnl, oldToken, newToken, oldUser, newUser, types.DownvoteAction, responseData.Plan)
+		if err != nil {
+			return err
+		}
+		responseData.DownvotePost = result
+	}
+	return nil
+}
+
+// migrateVotes casts the old account's votes of one direction (UpvoteAction or DownvoteAction) on the new account.
+// Posts already voted the same way on the new account are skipped. Votes are cast oldest first through the same
+// rate-limited worker pool as saves; posts Reddit no longer accepts votes on (e.g. archived ones) are reported as failed.
+// When plan is set, the votes are only added to the plan.
+func migrateVotes(ctx context.Context, jrnl *journal.Journal, oldToken, newToken, oldUser, newUser string, action types.PostActionType, plan *types.MigrationPlan) (types.ManagePostResponseType, error) {
+	config.InfoLogger.Printf("Fetching %s posts from %s and %s...", action, oldUser, newUser)
+	progress.Phase(ctx, types.PhaseFetchVotes, 0)
+	oldVoted, err := reddit.FetchVotedPostsFullNames(oldToken, oldUser, action)
+	if err != nil {
+		return types.ManagePostResponseType{}, fmt.Errorf("failed to fetch %s posts from %s: %w", action, oldUser, err)
+	}
+	newVoted, err := reddit.FetchVotedPostsFullNames(newToken, newUser, action)
+	if err != nil {
+		return types.ManagePostResponseType{}, fmt.Errorf("failed to fetch %s posts from %s: %w", action, newUser, err)
+	}
+
+	postsToVote := filterSlice(oldVoted, newVoted)
+	// Listings are newest first; vote oldest first so the new account's history has the same order.
+	for i, j := 0, len(postsToVote)-1; i < j; i, j = i+1, j-1 {
+		postsToVote[i], postsToVote[j] = postsToVote[j], postsToVote[i]
+	}
+	config.InfoLogger.Printf("Found %d posts to %s on %s (%d already done).", len(postsToVote), action, newUser, len(oldVoted)-len(postsToVote))
+
+	if plan != nil {
+		if action == types.UpvoteAction {
+			plan.PostsToUpvote = postsToVote
+			plan.PostsAlreadyUpvoted = filterSlice(oldVoted, postsToVote)
+		} else {
+			plan.PostsToDownvote = postsToVote
+			plan.PostsAlreadyDownvoted = filterSlice(oldVoted, postsToVote)
+		}
+		return types.ManagePostResponseType{}, nil
+	}
+
+	phase := types.PhaseUpvotePosts
+	if action == types.DownvoteAction {
+		phase = types.PhaseDownvotePosts
+	}
+	jrnl.Plan(journal.KindPost, string(action), postsToVote)
+	progress.Phase(ctx, phase, len(postsToVote))
+	result := reddit.ManageSavedPosts(ctx, newToken, postsToVote, action, config.DefaultPostConcurrency)
+	config.InfoLogger.Printf("Cast %d %s votes on %s (failed: %d).", result.SuccessCount, action, newUser, result.FailedCount)
+	return result, nil
+}
+
+// processHidden handles the migration of hidden posts and/or unhiding them on the old account.
+// It mirrors processPosts: posts already hidden on the new account are skipped, posts are hidden oldest first,
+// and only posts that were hidden on the new account successfully are unhidden on the old one.
+func processHidden(ctx context.Context, jrnl *journal.Journal, oldToken, newToken, oldUser, newUser string, prefs types.PreferencesType, responseData *types.MigrationDetails) error {
+	config.InfoLogger.Printf("Fetching hidden posts from %s and %s...", oldUser, newUser)
+	progress.Phase(ctx, types.PhaseFetchHidden, 0)
+
+	oldHidden, err := reddit.FetchHiddenPostsFullNames(oldToken, oldUser)
+	if err != nil {
+		return fmt.Errorf("failed to fetch hidden posts from %s: %w", oldUser, err)
+	}
+	newHidden, err := reddit.FetchHiddenPostsFullNames(newToken, newUser)
+	if err != nil {
+		return fmt.Errorf("failed to fetch hidden posts from %s: %w", newUser, err)
+	}
+
+	hiddenToMigrate := filterSlice(oldHidden, newHidden)
+	for i, j := 0, len(hiddenToMigrate)-1; i < j; i, j = i+1, j-1 {
+		hiddenToMigrate[i], hiddenToMigrate[j] = hiddenToMigrate[j], hiddenToMigrate[i]
+	}
+	config.InfoLogger.Printf("Found %d hidden posts in %s that aren't hidden in %s.", len(hiddenToMigrate), oldUser, newUser)
+
+	if plan := responseData.Plan; plan != nil {
+		if prefs.MigrateHiddenBool {
+			plan.PostsToHide = hiddenToMigrate
+			plan.PostsAlreadyHidden = filterSlice(oldHidden, hiddenToMigrate)
+		}
+		if prefs.UnhideOldBool {
+			plan.PostsToUnhide = hiddenToMigrate
+		}
+		return nil
+	}
+
+	if prefs.MigrateHiddenBool {
+		jrnl.Plan(journal.KindPost, string(types.HideAction), hiddenToMigrate)
+	}
+	if prefs.UnhideOldBool {
+		jrnl.Plan(journal.KindPost, string(types.UnhideAction), hiddenToMigrate)
+	}
+
+	var failedToHide []string
+	if prefs.MigrateHiddenBool {
+		progress.Phase(ctx, types.PhaseHidePosts, len(hiddenToMigrate))
+		hideResponse := reddit.ManageSavedPosts(ctx, newToken, hiddenToMigrate, types.HideAction, config.DefaultPostConcurrency)
+		config.InfoLogger.Printf("Hid %d posts on %s (failed: %d).", hideResponse.SuccessCount, newUser, hideResponse.FailedCount)
+		responseData.HidePost = hideResponse
+		failedToHide = hideResponse.FailedPosts
+	}
+
+	if prefs.UnhideOldBool && ctx.Err() == nil {
+		postsToUnhide := filterSlice(hiddenToMigrate, failedToHide)
+		progress.Phase(ctx, types.PhaseUnhidePosts, len(postsToUnhide))
+		unhideResponse := reddit.ManageSavedPosts(ctx, oldToken, postsToUnhide, types.UnhideAction, config.DefaultPostConcurrency)
+		config.InfoLogger.Printf("Unhid %d posts on %s (failed: %d).", unhideResponse.SuccessCount, oldUser, unhideResponse.FailedCount)
+		responseData.UnhidePost = unhideResponse
+	}
+	return nil
+}
+
+// processBlocked blocks the old account's blocked users on the new account.
+// Users already blocked there are skipped.
+func processBlocked(ctx context.Context, jrnl *journal.Journal, oldToken, newToken, oldUser, newUser string, responseData *types.MigrationDetails) error {
+	config.InfoLogger.Printf("Fetching blocked users from %s and %s...", oldUser, newUser)
+	progress.Phase(ctx, types.PhaseFetchBlocked, 0)
+
+	oldBlocked, err := reddit.FetchBlockedUsers(oldToken)
+	if err != nil {
+		return fmt.Errorf("failed to fetch blocked users from %s: %w", oldUser, err)
+	}
+	newBlocked, err := reddit.FetchBlockedUsers(newToken)
+	if err != nil {
+		return fmt.Errorf("failed to fetch blocked users from %s: %w", newUser, err)
+	}
+
+	usersToBlock := filterSlice(oldBlocked, newBlocked)
+	config.InfoLogger.Printf("Found %d users blocked by %s that aren't blocked by %s.", len(usersToBlock), oldUser, newUser)
+
+	if plan := responseData.Plan; plan != nil {
+		plan.UsersToBlock = usersToBlock
+		plan.UsersAlreadyBlocked = filterSlice(oldBlocked, usersToBlock)
+		return nil
+	}
+
+	jrnl.Plan(journal.KindUser, string(types.BlockAction), usersToBlock)
+	progress.Phase(ctx, types.PhaseBlockUsers, len(usersToBlock))
+	responseData.BlockUser = reddit.BlockUsers(ctx, newToken, usersToBlock)
+	config.InfoLogger.Printf("Blocked %d users on %s (failed: %d).", responseData.BlockUser.SuccessCount, newUser, responseData.BlockUser.FailedCount)
+	return nil
+}
+
 // errorResponse sends a JSON error message to the client with a given HTTP status code.
 func errorResponse(w http.ResponseWriter, message string, httpStatusCode int) {
 	w.Header().Set("Content-Type", "application/json")
@@ -382,108 +764,88 @@ func errorResponse(w http.ResponseWriter, message string, httpStatusCode int) {
 }
 
 // HandleCustomMigration processes a custom selection migration request
-// It migrates only the selected subreddits and posts instead of all items
-func HandleCustomMigration(req types.CustomMigrationRequest) types.MigrationResponseType {
+// It migrates only the selected subreddits and posts instead of all items. Selected posts are saved in the reverse
+// of the order they are given in, which must be the saved listing order of the old account, newest first.
+// Cancelling ctx stops the migration after the in-flight requests.
+// When jobID is set, progress is checkpointed to the job's journal as in RunMigration.
+func HandleCustomMigration(ctx context.Context, jobID string, req types.CustomMigrationRequest) types.MigrationResponseType {
 	var finalResponse types.MigrationResponseType
 	finalResponse.Success = false // Default to false
 
-	config.InfoLogger.Printf("Starting custom migration process with %d subreddits and %d posts",
-		len(req.SelectedSubreddits), len(req.SelectedPosts))
+	config.InfoLogger.Printf("Starting custom migration process with %d subreddits, %d posts and %d custom feeds",
+		len(req.SelectedSubreddits), len(req.SelectedPosts), len(req.SelectedMultireddits))
+	defer holdTokens(req.OldAccountToken, req.NewAccountToken)()
 
-	// Extract authentication data for old account
-	var oldAccountToken, oldAccountUsername string
-	var err error
-
-	if req.AuthMethod == "oauth" {
-		oldAccountToken = req.OldAccountToken
-		// Use provided username if available, otherwise get from OAuth token
-		if req.OldAccountUsername != "" {
-			oldAccountUsername = req.OldAccountUsername
-		} else {
-			// Get username from OAuth token
-			userInfo, err := auth.GetUserInfoWithToken(oldAccountToken)
-			if err != nil {
-				config.ErrorLogger.Printf("Failed to verify old account OAuth token: %v", err)
-				finalResponse.Message = fmt.Sprintf("Failed to verify old account OAuth token: %v", err)
-				return finalResponse
-			}
-			oldAccountUsername = userInfo.Data.Name
-		}
-	} else {
-		// Cookie-based authentication (default/backward compatibility)
-		oldAccountUsername, err = auth.GetUsernameFromCookie(req.OldAccountCookie)
-		if err != nil {
-			config.ErrorLogger.Printf("Failed to verify old account cookie: %v", err)
-			finalResponse.Message = fmt.Sprintf("Failed to verify old account cookie: %v", err)
-			return finalResponse
-		}
-		oldAccountToken = auth.ParseTokenFromCookie(req.OldAccountCookie)
-		if oldAccountToken == "" {
-			config.ErrorLogger.Println("Failed to parse OAuth token from old account cookie.")
-			finalResponse.Message = "Failed to parse OAuth token from old account cookie. Ensure 'token_v2' is present."
-			return finalResponse
-		}
+	oldAccountToken, oldAccountUsername, err := ResolveAccount("old", req.OldAccountProfile, req.AuthMethod, req.OldAccountCookie, req.OldAccountToken, req.OldAccountUsername)
+	if err != nil {
+		config.ErrorLogger.Println(err)
+		finalResponse.Message = err.Error()
+		return finalResponse
+	}
+	newAccountToken, newAccountUsername, err := ResolveAccount("new", req.NewAccountProfile, req.AuthMethod, req.NewAccountCookie, req.NewAccountToken, req.NewAccountUsername)
+	if err != nil {
+		config.ErrorLogger.Println(err)
+		finalResponse.Message = err.Error()
+		return finalResponse
 	}
 
-	// Extract authentication data for new account
-	var newAccountToken, newAccountUsername string
+	defer holdTokens(oldAccountToken, newAccountToken)()
 
-	if req.AuthMethod == "oauth" {
-		newAccountToken = req.NewAccountToken
-		// Use provided username if available, otherwise get from OAuth token
-		if req.NewAccountUsername != "" {
-			newAccountUsername = req.NewAccountUsername
-		} else {
-			// Get username from OAuth token
-			userInfo, err := auth.GetUserInfoWithToken(newAccountToken)
-			if err != nil {
-				config.ErrorLogger.Printf("Failed to verify new account OAuth token: %v", err)
-				finalResponse.Message = fmt.Sprintf("Failed to verify new account OAuth token: %v", err)
-				return finalResponse
-			}
-			newAccountUsername = userInfo.Data.Name
-		}
+	config.InfoLogger.Printf("Verified accounts for custom migration: %s -> %s", oldAccountUsername, newAccountUsername)
+
+	var jrnl *journal.Journal
+	if req.DryRun {
+		config.InfoLogger.Println("Dry run requested. Computing custom migration plan without modifying either account.")
+		finalResponse.Data.Plan = &types.MigrationPlan{}
 	} else {
-		// Cookie-based authentication (default/backward compatibility)
-		newAccountUsername, err = auth.GetUsernameFromCookie(req.NewAccountCookie)
-		if err != nil {
-			config.ErrorLogger.Printf("Failed to verify new account cookie: %v", err)
-			finalResponse.Message = fmt.Sprintf("Failed to verify new account cookie: %v", err)
-			return finalResponse
-		}
-		newAccountToken = auth.ParseTokenFromCookie(req.NewAccountCookie)
-		if newAccountToken == "" {
-			config.ErrorLogger.Println("Failed to parse OAuth token from new account cookie.")
-			finalResponse.Message = "Failed to parse OAuth token from new account cookie. Ensure 'token_v2' is present."
-			return finalResponse
+		jrnl = startJournal(jobID, "migrate-custom", oldAccountUsername, newAccountUsername, req.PreserveOrder)
+		defer jrnl.Close()
+		if jrnl != nil {
+			ctx = progress.WithReporter(ctx, jrnl)
 		}
 	}
-
-	config.InfoLogger.Printf("Verified accounts for custom migration: %s -> %s", oldAccountUsername, newAccountUsername)
+	plan := finalResponse.Data.Plan
+	var planErr error
 
 	// Handle selected subreddits migration
 	if len(req.SelectedSubreddits) > 0 {
 		config.InfoLogger.Printf("Migrating %d selected subreddits", len(req.SelectedSubreddits))
 		config.InfoLogger.Printf("Fetching subreddits from new account %s to filter out duplicates...", newAccountUsername)
+		progress.Phase(ctx, types.PhaseFetchSubreddits, 0)
 		newSubredditNameList, err := reddit.FetchSubredditFullNames(newAccountToken)
 
 		subredditsToMigrate := req.SelectedSubreddits
 
 		if err != nil {
 			config.ErrorLogger.Printf("Could not fetch subreddits from new account. Proceeding with all %d selected subreddits. Error: %v", len(req.SelectedSubreddits), err)
+			planErr = fmt.Errorf("failed to fetch subreddit names from new account: %w", err)
 		} else {
 			subredditsToMigrate = filterSlice(req.SelectedSubreddits, newSubredditNameList.DisplayNamesList)
 			config.InfoLogger.Printf("Filtered selection: %d subreddits to migrate after removing %d duplicates.", len(subredditsToMigrate), len(req.SelectedSubreddits)-len(subredditsToMigrate))
 		}
 
-		if len(subredditsToMigrate) > 0 {
-			subscribeResult := reddit.ManageSubreddits(newAccountToken, subredditsToMigrate, types.SubscribeAction, 100)
+		if plan != nil {
+			plan.SubredditsToSubscribe = subredditsToMigrate
+			plan.SubredditsAlreadyPresent = filterSlice(req.SelectedSubreddits, subredditsToMigrate)
+			if req.DeleteOldSubreddits {
+				plan.SubredditsToUnsubscribe = subredditsToMigrate
+			}
+		} else if len(subredditsToMigrate) > 0 {
+			jrnl.Plan(journal.KindSubreddit, string(types.SubscribeAction), subredditsToMigrate)
+			if req.DeleteOldSubreddits {
+				jrnl.Plan(journal.KindSubreddit, string(types.UnsubscribeAction), subredditsToMigrate)
+			}
+
+			progress.Phase(ctx, types.PhaseSubscribeSubreddits, len(subredditsToMigrate))
+			subscribeResult := reddit.ManageSubreddits(ctx, newAccountToken, subredditsToMigrate, types.SubscribeAction, 100)
 			finalResponse.Data.SubscribeSubreddit = subscribeResult
 
-			// Handle deletion if requested
-			if req.DeleteOldSubreddits {
-				config.InfoLogger.Printf("Deleting %d selected subreddits from old account", len(subredditsToMigrate))
-				unsubscribeResult := reddit.ManageSubreddits(oldAccountToken, subredditsToMigrate, types.UnsubscribeAction, 100)
+			// Handle deletion if requested, keeping subreddits that failed to migrate
+			if req.DeleteOldSubreddits && ctx.Err() == nil {
+				subredditsToDelete := filterSlice(subredditsToMigrate, subscribeResult.FailedSubreddits)
+				config.InfoLogger.Printf("Deleting %d selected subreddits from old account", len(subredditsToDelete))
+				progress.Phase(ctx, types.PhaseUnsubscribeSubreddits, len(subredditsToDelete))
+				unsubscribeResult := reddit.ManageSubreddits(ctx, oldAccountToken, subredditsToDelete, types.UnsubscribeAction, 100)
 				finalResponse.Data.UnsubscribeSubreddit = unsubscribeResult
 			}
 		} else {
@@ -494,35 +856,53 @@ func HandleCustomMigration(req types.CustomMigrationRequest) types.MigrationResp
 	}
 
 	// Handle selected posts migration
-	if len(req.SelectedPosts) > 0 {
+	if len(req.SelectedPosts) > 0 && ctx.Err() == nil {
 		config.InfoLogger.Printf("Migrating %d selected posts", len(req.SelectedPosts))
 
 		// Fetch saved posts from new account to avoid duplicates
 		config.InfoLogger.Printf("Fetching saved posts from new account %s to avoid duplicates...", newAccountUsername)
+		progress.Phase(ctx, types.PhaseFetchPosts, 0)
 		newSavedPosts, err := reddit.FetchSavedPostsFullNames(newAccountToken, newAccountUsername)
 		postsToMigrate := req.SelectedPosts
 		if err != nil {
 			config.ErrorLogger.Printf("Could not fetch saved posts from new account. Proceeding with all %d selected posts. Error: %v", len(req.SelectedPosts), err)
+			if planErr == nil {
+				planErr = fmt.Errorf("failed to fetch saved post names from new account: %w", err)
+			}
 		} else {
 			postsToMigrate = filterSlice(req.SelectedPosts, newSavedPosts)
 			config.InfoLogger.Printf("Filtered selection: %d posts to migrate after removing %d duplicates.", len(postsToMigrate), len(req.SelectedPosts)-len(postsToMigrate))
 		}
 
-		if len(postsToMigrate) > 0 {
-			// Reverse the order so that oldest posts are saved first to maintain chronological order in new account
-			// Reddit API returns newest posts first, but we want oldest posts to be saved first so they appear at bottom
-			for i, j := 0, len(postsToMigrate)-1; i < j; i, j = i+1, j-1 {
-				postsToMigrate[i], postsToMigrate[j] = postsToMigrate[j], postsToMigrate[i]
+		// Reverse the order so that oldest posts are saved first to maintain chronological order in new account
+		// Reddit API returns newest posts first, but we want oldest posts to be saved first so they appear at bottom
+		for i, j := 0, len(postsToMigrate)-1; i < j; i, j = i+1, j-1 {
+			postsToMigrate[i], postsToMigrate[j] = postsToMigrate[j], postsToMigrate[i]
+		}
+
+		if plan != nil {
+			plan.PostsToSave = postsToMigrate
+			plan.PostsAlreadySaved = filterSlice(req.SelectedPosts, postsToMigrate)
+			if req.DeleteOldPosts {
+				plan.PostsToUnsave = postsToMigrate
+			}
+		} else if len(postsToMigrate) > 0 {
+			jrnl.Plan(journal.KindPost, string(types.SaveAction), postsToMigrate)
+			if req.DeleteOldPosts {
+				jrnl.Plan(journal.KindPost, string(types.UnsaveAction), postsToMigrate)
 			}
 
 			concurrencyForPosts := config.DefaultPostConcurrency
-			saveResult := reddit.ManageSavedPosts(newAccountToken, postsToMigrate, types.SaveAction, concurrencyForPosts)
+			progress.Phase(ctx, types.PhaseSavePosts, len(postsToMigrate))
+			saveResult := savePosts(ctx, newAccountToken, postsToMigrate, req.PreserveOrder)
 			finalResponse.Data.SavePost = saveResult
 
-			// Handle deletion if requested
-			if req.DeleteOldPosts {
-				config.InfoLogger.Printf("Deleting %d selected posts from old account", len(postsToMigrate))
-				unsaveResult := reddit.ManageSavedPosts(oldAccountToken, postsToMigrate, types.UnsaveAction, concurrencyForPosts)
+			// Handle deletion if requested, keeping posts that failed to save
+			if req.DeleteOldPosts && ctx.Err() == nil {
+				postsToDelete := filterSlice(postsToMigrate, saveResult.FailedPosts)
+				config.InfoLogger.Printf("Deleting %d selected posts from old account", len(postsToDelete))
+				progress.Phase(ctx, types.PhaseUnsavePosts, len(postsToDelete))
+				unsaveResult := reddit.ManageSavedPosts(ctx, oldAccountToken, postsToDelete, types.UnsaveAction, concurrencyForPosts)
 				finalResponse.Data.UnsavePost = unsaveResult
 			}
 		} else {
@@ -532,15 +912,78 @@ func HandleCustomMigration(req types.CustomMigrationRequest) types.MigrationResp
 		config.InfoLogger.Println("No posts selected for migration")
 	}
 
+	// Votes are not selectable individually; when requested, all of them are migrated
+	if (req.MigrateUpvotes || req.MigrateDownvotes) && ctx.Err() == nil {
+		votePrefs := types.PreferencesType{MigrateUpvotesBool: req.MigrateUpvotes, MigrateDownvotesBool: req.MigrateDownvotes}
+		if err := processVotes(ctx, jrnl, oldAccountToken, newAccountToken, oldAccountUsername, newAccountUsername, votePrefs, &finalResponse.Data); err != nil {
+			config.ErrorLogger.Printf("Error processing votes: %v", err)
+			if planErr == nil {
+				planErr = err
+			}
+		}
+	}
+
+	// Hidden posts are not selectable individually either
+	if (req.MigrateHidden || req.UnhideOld) && ctx.Err() == nil {
+		hiddenPrefs := types.PreferencesType{MigrateHiddenBool: req.MigrateHidden, UnhideOldBool: req.UnhideOld}
+		if err := processHidden(ctx, jrnl, oldAccountToken, newAccountToken, oldAccountUsername, newAccountUsername, hiddenPrefs, &finalResponse.Data); err != nil {
+			config.ErrorLogger.Printf("Error processing hidden posts: %v", err)
+			if planErr == nil {
+				planErr = err
+			}
+		}
+	}
+
+	if len(req.SelectedMultireddits) > 0 && ctx.Err() == nil {
+		if err := processMultireddits(ctx, jrnl, oldAccountToken, newAccountToken, oldAccountUsername, newAccountUsername, req.SelectedMultireddits, &finalResponse.Data); err != nil {
+			config.ErrorLogger.Printf("Error processing multireddits: %v", err)
+			if planErr == nil {
+				planErr = err
+			}
+		}
+	}
+
+	// Blocked users are not selectable individually
+	if req.MigrateBlocked && ctx.Err() == nil {
+		if err := processBlocked(ctx, jrnl, oldAccountToken, newAccountToken, oldAccountUsername, newAccountUsername, &finalResponse.Data); err != nil {
+			config.ErrorLogger.Printf("Error processing blocked users: %v", err)
+			if planErr == nil {
+				planErr = err
+			}
+		}
+	}
+
+	if req.MigratePrefs && ctx.Err() == nil {
+		if err := processPrefs(ctx, oldAccountToken, newAccountToken, oldAccountUsername, newAccountUsername, req.PrefKeys, &finalResponse.Data); err != nil {
+			config.ErrorLogger.Printf("Error processing preferences: %v", err)
+			if planErr == nil {
+				planErr = err
+			}
+		}
+	}
+
 	// Determine overall success and message
 	hasErrors := finalResponse.Data.SubscribeSubreddit.Error ||
 		finalResponse.Data.UnsubscribeSubreddit.Error ||
 		finalResponse.Data.SavePost.FailedCount > 0 ||
-		finalResponse.Data.UnsavePost.FailedCount > 0
-
-	if hasErrors {
+		finalResponse.Data.UnsavePost.FailedCount > 0 ||
+		finalResponse.Data.UpvotePost.FailedCount > 0 ||
+		finalResponse.Data.DownvotePost.FailedCount > 0 ||
+		finalResponse.Data.HidePost.FailedCount > 0 ||
+		finalResponse.Data.UnhidePost.FailedCount > 0 ||
+		finalResponse.Data.Multireddits.Error ||
+		finalResponse.Data.BlockUser.Error ||
+		finalResponse.Data.Prefs.Error
+
+	if plan != nil && ctx.Err() == nil {
+		finishDryRun(&finalResponse, planErr)
+	} else if ctx.Err() != nil {
+		finalResponse.Success = false
+		finalResponse.Message = "Custom migration cancelled. Results cover only the operations completed before cancellation."
+		config.InfoLogger.Println("Custom migration process cancelled.")
+	} else if hasErrors {
 		finalResponse.Success = false
-		finalResponse.Message = "Custom migration completed with some errors. Check individual operation statuses."
+		finalResponse.Message = "Custom migration completed with some errors. Check individual operation statuses." + missingScopeHint(finalResponse.Data)
 		config.InfoLogger.Println("Custom migration process completed with some errors.")
 	} else {
 		finalResponse.Success = true
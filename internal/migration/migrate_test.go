@@ -0,0 +1,377 @@
+package migration
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nileshnk/reddit-migrate/internal/auth"
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/reddit"
+	"github.com/nileshnk/reddit-migrate/internal/reddittest"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+)
+
+func TestMain(m *testing.M) {
+	config.InfoLogger = log.New(io.Discard, "", 0)
+	config.ErrorLogger = log.New(io.Discard, "", 0)
+	config.DebugLogger = log.New(io.Discard, "", 0)
+	config.DefaultPostConcurrency = 4
+	config.DefaultSubredditChunkSize = 100
+	config.MaxSubredditRetryAttempts = 2
+	config.MaxTokensPerInterval = 100
+	config.RateLimitInterval = time.Second
+	config.RateLimitSleepInterval = time.Second
+	os.Exit(m.Run())
+}
+
+// newAccounts starts a fake Reddit holding an old and a new account and makes it the default client for the test.
+func newAccounts(t *testing.T) (srv *reddittest.Server, oldAccount, newAccount *reddittest.Account) {
+	t.Helper()
+	return newAccountsWith(t, nil)
+}
+
+// newAccountsWith is newAccounts with setup called on both accounts before they are registered with the server.
+func newAccountsWith(t *testing.T, setup func(oldAccount, newAccount *reddittest.Account)) (srv *reddittest.Server, oldAccount, newAccount *reddittest.Account) {
+	t.Helper()
+	srv = reddittest.NewServer()
+	t.Cleanup(srv.Close)
+	reddit.SetDefaultClient(srv.Client())
+	t.Cleanup(func() { reddit.SetDefaultClient(nil) })
+
+	oldAccount = &reddittest.Account{
+		Name:         "old_user",
+		Token:        "old-" + t.Name(),
+		RefreshToken: "old-refresh-" + t.Name(),
+		Code:         "old-code-" + t.Name(),
+		Subreddits:   []string{"golang", "programming", "rust"},
+		Followed:     []string{"alice", "bob"},
+		Saved:        []string{"t3_c", "t3_b", "t3_a"},
+	}
+	newAccount = &reddittest.Account{
+		Name:       "new_user",
+		Token:      "new-" + t.Name(),
+		Subreddits: []string{"rust"},
+		Followed:   []string{"bob"},
+		Saved:      []string{"t3_b"},
+	}
+	if setup != nil {
+		setup(oldAccount, newAccount)
+	}
+	srv.AddAccount(oldAccount)
+	srv.AddAccount(newAccount)
+	return srv, oldAccount, newAccount
+}
+
+func sorted(s []string) []string {
+	s = append([]string(nil), s...)
+	sort.Strings(s)
+	return s
+}
+
+func TestRunMigrationMovesEverything(t *testing.T) {
+	srv, oldAccount, newAccount := newAccounts(t)
+
+	resp := RunMigration(context.Background(), "", types.MigrationRequestType{
+		AuthMethod:      "oauth",
+		OldAccountToken: oldAccount.Token,
+		NewAccountToken: newAccount.Token,
+		Preferences: types.PreferencesType{
+			MigrateSubredditBool: true,
+			DeleteSubredditBool:  true,
+			MigratePostBool:      true,
+			DeletePostBool:       true,
+			PreserveOrderBool:    true,
+		},
+	})
+	if !resp.Success {
+		t.Fatalf("migration failed: %s", resp.Message)
+	}
+
+	newState := srv.Account("new_user")
+	if got, want := sorted(newState.Subreddits), []string{"golang", "programming", "rust"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("new account subreddits = %v, want %v", got, want)
+	}
+	if got, want := sorted(newState.Followed), []string{"alice", "bob"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("new account followed users = %v, want %v", got, want)
+	}
+	// t3_b was already saved; the others are saved oldest first, so they end up in their original order above it.
+	if got, want := newState.Saved, []string{"t3_c", "t3_a", "t3_b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("new account saved posts = %v, want %v", got, want)
+	}
+
+	oldState := srv.Account("old_user")
+	if len(oldState.Subreddits) != 0 {
+		t.Errorf("old account still subscribed to %v", oldState.Subreddits)
+	}
+	// t3_b was not saved by this migration, so it stays on the old account.
+	if got, want := oldState.Saved, []string{"t3_b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("old account saved posts = %v, want %v", got, want)
+	}
+}
+
+func TestRunMigrationKeepsPostsThatFailedToSave(t *testing.T) {
+	srv, oldAccount, newAccount := newAccounts(t)
+	srv.FailNext("/api/save", 403)
+
+	resp := RunMigration(context.Background(), "", types.MigrationRequestType{
+		AuthMethod:         "oauth",
+		OldAccountToken:    oldAccount.Token,
+		OldAccountUsername: oldAccount.Name,
+		NewAccountToken:    newAccount.Token,
+		NewAccountUsername: newAccount.Name,
+		Preferences: types.PreferencesType{
+			MigratePostBool:   true,
+			DeletePostBool:    true,
+			PreserveOrderBool: true,
+		},
+	})
+	if resp.Success || resp.Data.SavePost.FailedCount != 1 {
+		t.Fatalf("want one failed save, got success=%v %+v", resp.Success, resp.Data.SavePost)
+	}
+	// The oldest post failed to save, so it must still be saved on the old account.
+	if got, want := sorted(srv.Account("old_user").Saved), []string{"t3_a", "t3_b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("old account saved posts = %v, want %v", got, want)
+	}
+}
+
+func TestRunMigrationDryRunChangesNothing(t *testing.T) {
+	srv, oldAccount, newAccount := newAccounts(t)
+
+	resp := RunMigration(context.Background(), "", types.MigrationRequestType{
+		AuthMethod:      "oauth",
+		OldAccountToken: oldAccount.Token,
+		NewAccountToken: newAccount.Token,
+		Preferences: types.PreferencesType{
+			MigrateSubredditBool: true,
+			MigratePostBool:      true,
+			DeletePostBool:       true,
+			DryRun:               true,
+		},
+	})
+	if !resp.Success || resp.Data.Plan == nil {
+		t.Fatalf("dry run failed: %s", resp.Message)
+	}
+	plan := resp.Data.Plan
+	if got, want := sorted(plan.SubredditsToSubscribe), []string{"golang", "programming"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("plan subscribes %v, want %v", got, want)
+	}
+	if got, want := plan.UsersToFollow, []string{"u_alice"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("plan follows %v, want %v", got, want)
+	}
+	if got, want := plan.PostsToSave, []string{"t3_a", "t3_c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("plan saves %v, want %v", got, want)
+	}
+
+	for _, path := range []string{"/api/subscribe", "/api/save", "/api/unsave"} {
+		if n := srv.Requests(path); n != 0 {
+			t.Errorf("dry run sent %d requests to %s", n, path)
+		}
+	}
+}
+
+func TestRunMigrationWithCookies(t *testing.T) {
+	srv, oldAccount, newAccount := newAccounts(t)
+
+	resp := RunMigration(context.Background(), "", types.MigrationRequestType{
+		OldAccountCookie: "session=x; token_v2=" + oldAccount.Token,
+		NewAccountCookie: "token_v2=" + newAccount.Token,
+		Preferences:      types.PreferencesType{MigrateSubredditBool: true},
+	})
+	if !resp.Success {
+		t.Fatalf("migration failed: %s", resp.Message)
+	}
+	if got := len(srv.Account("new_user").Subreddits); got != 3 {
+		t.Errorf("new account has %d subreddits, want 3", got)
+	}
+}
+
+func TestRunMigrationRejectsUnknownToken(t *testing.T) {
+	_, oldAccount, _ := newAccounts(t)
+
+	resp := RunMigration(context.Background(), "", types.MigrationRequestType{
+		AuthMethod:      "oauth",
+		OldAccountToken: oldAccount.Token,
+		NewAccountToken: "unknown",
+		Preferences:     types.PreferencesType{MigrateSubredditBool: true},
+	})
+	if resp.Success {
+		t.Fatal("migration succeeded with an invalid token")
+	}
+}
+
+func TestRunMigrationRefreshesExpiredToken(t *testing.T) {
+	srv, oldAccount, newAccount := newAccounts(t)
+	token, err := auth.ExchangeCodeForToken(auth.NewOAuthConfig("client", "secret", "http://localhost/callback"), oldAccount.Code, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.ExpireToken("old_user", "old-renewed-"+t.Name())
+
+	resp := RunMigration(context.Background(), "", types.MigrationRequestType{
+		AuthMethod:      "oauth",
+		OldAccountToken: token.AccessToken,
+		NewAccountToken: newAccount.Token,
+		Preferences: types.PreferencesType{
+			MigrateSubredditBool: true,
+			DeleteSubredditBool:  true,
+		},
+	})
+	if !resp.Success {
+		t.Fatalf("migration failed: %s", resp.Message)
+	}
+	if n := srv.Requests("/api/v1/access_token"); n != 2 {
+		t.Errorf("got %d token requests, want the code exchange and one refresh", n)
+	}
+	if got := srv.Account("old_user").Subreddits; len(got) != 0 {
+		t.Errorf("old account still subscribed to %v", got)
+	}
+}
+
+func TestHandleCustomMigrationKeepsInterleavedSavedOrder(t *testing.T) {
+	srv, oldAccount, newAccount := newAccountsWith(t, func(oldAccount, newAccount *reddittest.Account) {
+		oldAccount.Saved = []string{"t1_e", "t3_d", "t3_c", "t1_b", "t3_a"}
+		newAccount.Saved = nil
+	})
+
+	resp := HandleCustomMigration(context.Background(), "", types.CustomMigrationRequest{
+		AuthMethod:         "oauth",
+		OldAccountToken:    oldAccount.Token,
+		NewAccountToken:    newAccount.Token,
+		OldAccountUsername: oldAccount.Name,
+		NewAccountUsername: newAccount.Name,
+		SelectedPosts:      []string{"t1_e", "t3_d", "t1_b", "t3_a"},
+		PreserveOrder:      true,
+	})
+	if !resp.Success {
+		t.Fatalf("migration failed: %s", resp.Message)
+	}
+	if got, want := srv.Account("new_user").Saved, []string{"t1_e", "t3_d", "t1_b", "t3_a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("new account saved items = %v, want %v", got, want)
+	}
+}
+
+func TestRunMigrationCopiesVotes(t *testing.T) {
+	srv, oldAccount, newAccount := newAccountsWith(t, func(oldAccount, newAccount *reddittest.Account) {
+		oldAccount.Upvoted = []string{"t3_u3", "t3_u2", "t3_u1"}
+		oldAccount.Downvoted = []string{"t3_d2", "t3_d1"}
+		newAccount.Upvoted = []string{"t3_u2"}
+	})
+
+	resp := RunMigration(context.Background(), "", types.MigrationRequestType{
+		AuthMethod:      "oauth",
+		OldAccountToken: oldAccount.Token,
+		NewAccountToken: newAccount.Token,
+		Preferences: types.PreferencesType{
+			MigrateUpvotesBool:   true,
+			MigrateDownvotesBool: true,
+		},
+	})
+	if !resp.Success {
+		t.Fatalf("migration failed: %s", resp.Message)
+	}
+	if resp.Data.UpvotePost.SuccessCount != 2 || resp.Data.DownvotePost.SuccessCount != 2 {
+		t.Errorf("got %d upvotes and %d downvotes, want 2 of each", resp.Data.UpvotePost.SuccessCount, resp.Data.DownvotePost.SuccessCount)
+	}
+
+	newState := srv.Account("new_user")
+	if got, want := sorted(newState.Upvoted), []string{"t3_u1", "t3_u2", "t3_u3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("new account upvotes = %v, want %v", got, want)
+	}
+	if got, want := sorted(newState.Downvoted), []string{"t3_d1", "t3_d2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("new account downvotes = %v, want %v", got, want)
+	}
+	// The post already upvoted on the new account is not voted on again.
+	if n := srv.Requests("/api/vote"); n != 4 {
+		t.Errorf("got %d vote requests, want 4", n)
+	}
+}
+
+func TestRunMigrationMovesHiddenPosts(t *testing.T) {
+	srv, oldAccount, newAccount := newAccountsWith(t, func(oldAccount, newAccount *reddittest.Account) {
+		oldAccount.Hidden = []string{"t3_h3", "t3_h2", "t3_h1"}
+		newAccount.Hidden = []string{"t3_h2"}
+	})
+	srv.FailNext("/api/hide", 403)
+
+	resp := RunMigration(context.Background(), "", types.MigrationRequestType{
+		AuthMethod:      "oauth",
+		OldAccountToken: oldAccount.Token,
+		NewAccountToken: newAccount.Token,
+		Preferences: types.PreferencesType{
+			MigrateHiddenBool: true,
+			UnhideOldBool:     true,
+		},
+	})
+	if resp.Success || resp.Data.HidePost.FailedCount != 1 {
+		t.Fatalf("want one failed hide, got success=%v %+v", resp.Success, resp.Data.HidePost)
+	}
+
+	failed := resp.Data.HidePost.FailedPosts[0]
+	hidden := "t3_h1"
+	if failed == hidden {
+		hidden = "t3_h3"
+	}
+	if got, want := sorted(srv.Account("new_user").Hidden), sorted([]string{"t3_h2", hidden}); !reflect.DeepEqual(got, want) {
+		t.Errorf("new account hidden posts = %v, want %v", got, want)
+	}
+	// Only the post hidden on the new account is unhidden on the old one; t3_h2 was not migrated, so it stays too.
+	if got, want := sorted(srv.Account("old_user").Hidden), sorted([]string{"t3_h2", failed}); !reflect.DeepEqual(got, want) {
+		t.Errorf("old account hidden posts = %v, want %v", got, want)
+	}
+}
+
+func TestRunMigrationCopiesBlockedUsers(t *testing.T) {
+	srv, oldAccount, newAccount := newAccountsWith(t, func(oldAccount, newAccount *reddittest.Account) {
+		oldAccount.Blocked = []string{"spammer", "troll", "gone"}
+		newAccount.Blocked = []string{"troll"}
+	})
+	// The first block fails, as for a user that has been deleted since.
+	srv.FailNext("/api/block_user", 400)
+
+	resp := RunMigration(context.Background(), "", types.MigrationRequestType{
+		AuthMethod:      "oauth",
+		OldAccountToken: oldAccount.Token,
+		NewAccountToken: newAccount.Token,
+		Preferences:     types.PreferencesType{MigrateBlockedBool: true},
+	})
+	if resp.Success || resp.Data.BlockUser.SuccessCount != 1 || !reflect.DeepEqual(resp.Data.BlockUser.FailedSubreddits, []string{"spammer"}) {
+		t.Fatalf("want spammer to fail and gone to be blocked, got success=%v %+v", resp.Success, resp.Data.BlockUser)
+	}
+	if got, want := srv.Account("new_user").Blocked, []string{"troll", "gone"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("new account blocked users = %v, want %v", got, want)
+	}
+	if got, want := srv.Account("old_user").Blocked, []string{"spammer", "troll", "gone"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("old account blocked users = %v, want them unchanged", got)
+	}
+}
+
+func TestRunMigrationReportsMissingAccountScope(t *testing.T) {
+	srv, oldAccount, newAccount := newAccountsWith(t, func(oldAccount, newAccount *reddittest.Account) {
+		oldAccount.Blocked = []string{"spammer", "troll"}
+	})
+	// Reddit refuses block_user to a token authorized without the account scope.
+	srv.FailNext("/api/block_user", 403)
+
+	resp := RunMigration(context.Background(), "", types.MigrationRequestType{
+		AuthMethod:      "oauth",
+		OldAccountToken: oldAccount.Token,
+		NewAccountToken: newAccount.Token,
+		Preferences:     types.PreferencesType{MigrateBlockedBool: true},
+	})
+	if resp.Success || resp.Data.BlockUser.StatusCode != 403 || !reflect.DeepEqual(resp.Data.BlockUser.FailedSubreddits, []string{"spammer", "troll"}) {
+		t.Fatalf("want every user to fail with 403, got success=%v %+v", resp.Success, resp.Data.BlockUser)
+	}
+	if got := srv.Requests("/api/block_user"); got != 1 {
+		t.Errorf("block_user requests = %d, want 1: the rest would be refused too", got)
+	}
+	if !strings.Contains(resp.Message, "reconnect") {
+		t.Errorf("Message = %q, want it to ask to reconnect the account", resp.Message)
+	}
+}
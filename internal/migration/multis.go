@@ -0,0 +1,125 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/journal"
+	"github.com/nileshnk/reddit-migrate/internal/progress"
+	"github.com/nileshnk/reddit-migrate/internal/reddit"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+)
+
+// processMultireddits recreates the old account's multireddits (custom feeds) on the new account.
+// When selected is empty every feed is copied; otherwise only feeds with those names.
+func processMultireddits(ctx context.Context, jrnl *journal.Journal, oldToken, newToken, oldUser, newUser string, selected []string, responseData *types.MigrationDetails) error {
+	config.InfoLogger.Printf("Fetching multireddits from %s...", oldUser)
+	progress.Phase(ctx, types.PhaseFetchMultireddits, 0)
+	multis, err := reddit.FetchMultireddits(oldToken)
+	if err != nil {
+		return fmt.Errorf("failed to fetch multireddits from %s: %w", oldUser, err)
+	}
+	if len(selected) > 0 {
+		multis = filterMultireddits(multis, selected)
+	}
+	return copyMultireddits(ctx, jrnl, newToken, newUser, multis, responseData)
+}
+
+// copyMultireddits copies multis to the new account, deduplicating by feed name as filterSlice does for subreddits:
+// feeds the new account does not have are created, feeds it already has get only their missing subreddits,
+// and feeds that already contain every subreddit are skipped. When responseData.Plan is set, the feeds are only added to the plan.
+func copyMultireddits(ctx context.Context, jrnl *journal.Journal, newToken, newUser string, multis []types.MultiredditInfo, responseData *types.MigrationDetails) error {
+	if len(multis) == 0 {
+		config.InfoLogger.Println("No multireddits to migrate.")
+		return nil
+	}
+
+	config.InfoLogger.Printf("Fetching multireddits from %s to filter out duplicates...", newUser)
+	progress.Phase(ctx, types.PhaseFetchMultireddits, 0)
+	existingMultis, err := reddit.FetchMultireddits(newToken)
+	if err != nil {
+		return fmt.Errorf("failed to fetch multireddits from %s: %w", newUser, err)
+	}
+	existing := make(map[string]types.MultiredditInfo, len(existingMultis))
+	for _, multi := range existingMultis {
+		existing[strings.ToLower(multi.Name)] = multi
+	}
+
+	var toCreate, toMerge []types.MultiredditInfo
+	var alreadyPresent []string
+	for _, multi := range multis {
+		current, ok := existing[strings.ToLower(multi.Name)]
+		if !ok {
+			toCreate = append(toCreate, multi)
+			continue
+		}
+		missing := missingMembers(multi.Subreddits, current.Subreddits)
+		if len(missing) == 0 {
+			alreadyPresent = append(alreadyPresent, multi.Name)
+			continue
+		}
+		// Merge into the feed under its existing name, which may differ in case.
+		merge := multi
+		merge.Name = current.Name
+		merge.Subreddits = missing
+		toMerge = append(toMerge, merge)
+	}
+	config.InfoLogger.Printf("Multireddits for %s: %d to create, %d to merge, %d already present.", newUser, len(toCreate), len(toMerge), len(alreadyPresent))
+
+	if plan := responseData.Plan; plan != nil {
+		for _, multi := range toCreate {
+			plan.MultiredditsToCreate = append(plan.MultiredditsToCreate, multi.Name)
+		}
+		for _, multi := range toMerge {
+			plan.MultiredditsToMerge = append(plan.MultiredditsToMerge, fmt.Sprintf("%s: %s", multi.Name, strings.Join(multi.Subreddits, ", ")))
+		}
+		plan.MultiredditsAlreadyPresent = alreadyPresent
+		return nil
+	}
+
+	names := make([]string, 0, len(toCreate)+len(toMerge))
+	for _, multi := range toCreate {
+		names = append(names, multi.Name)
+	}
+	for _, multi := range toMerge {
+		names = append(names, multi.Name)
+	}
+	jrnl.Plan(journal.KindMultireddit, string(types.SubscribeAction), names)
+
+	progress.Phase(ctx, types.PhaseCopyMultireddits, len(names))
+	responseData.Multireddits = reddit.CopyMultireddits(ctx, newToken, newUser, toCreate, toMerge)
+	return nil
+}
+
+// missingMembers returns the subreddits of want that are not in have, keeping their spelling from want.
+// Names are compared in lower case, as Reddit does; feeds may list the same subreddit in different case.
+func missingMembers(want, have []string) []string {
+	present := make(map[string]bool, len(have))
+	for _, sr := range have {
+		present[strings.ToLower(sr)] = true
+	}
+	var missing []string
+	for _, sr := range want {
+		if !present[strings.ToLower(sr)] {
+			missing = append(missing, sr)
+		}
+	}
+	return missing
+}
+
+// filterMultireddits returns the multireddits whose names are in names, in their original order.
+func filterMultireddits(multis []types.MultiredditInfo, names []string) []types.MultiredditInfo {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[strings.ToLower(name)] = true
+	}
+	var result []types.MultiredditInfo
+	for _, multi := range multis {
+		if wanted[strings.ToLower(multi.Name)] {
+			result = append(result, multi)
+		}
+	}
+	return result
+}
@@ -0,0 +1,54 @@
+package migration
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/nileshnk/reddit-migrate/internal/reddittest"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+)
+
+func TestRunMigrationCopiesMultireddits(t *testing.T) {
+	srv, oldAccount, newAccount := newAccountsWith(t, func(oldAccount, newAccount *reddittest.Account) {
+		oldAccount.Multireddits = []reddittest.Multireddit{
+			{Name: "Tech", DisplayName: "Tech", Visibility: "private", Subreddits: []string{"golang", "rust"}},
+			{Name: "news", DisplayName: "News", Visibility: "public", Subreddits: []string{"worldnews"}},
+			{Name: "art", Subreddits: []string{"pics"}},
+		}
+		newAccount.Multireddits = []reddittest.Multireddit{
+			{Name: "tech", Subreddits: []string{"Rust"}},
+			{Name: "art", Subreddits: []string{"Pics"}},
+		}
+	})
+
+	resp := RunMigration(context.Background(), "", types.MigrationRequestType{
+		AuthMethod:      "oauth",
+		OldAccountToken: oldAccount.Token,
+		NewAccountToken: newAccount.Token,
+		Preferences:     types.PreferencesType{MigrateMultiredditsBool: true},
+	})
+	if !resp.Success {
+		t.Fatalf("migration failed: %s", resp.Message)
+	}
+	if got := resp.Data.Multireddits; got.CreatedCount != 1 || got.MergedCount != 1 || got.FailedCount != 0 {
+		t.Errorf("multireddits result = %+v, want 1 created and 1 merged", got)
+	}
+
+	want := []reddittest.Multireddit{
+		{Name: "tech", Subreddits: []string{"Rust", "golang"}},
+		{Name: "art", Subreddits: []string{"Pics"}},
+		{Name: "news", DisplayName: "News", Visibility: "public", Subreddits: []string{"worldnews"}},
+	}
+	if got := srv.Account("new_user").Multireddits; !reflect.DeepEqual(got, want) {
+		t.Errorf("new account multireddits = %+v, want %+v", got, want)
+	}
+	// tech only gets its missing member; art already has every member and is left alone. Members differing only
+	// in case are the same subreddit.
+	if n := srv.Requests("/api/multi/user/new_user/m/tech/r/golang"); n != 1 {
+		t.Errorf("got %d requests adding r/golang to tech, want 1", n)
+	}
+	if n := srv.Requests("/api/multi/user/new_user/m/tech/r/rust") + srv.Requests("/api/multi/user/new_user/m/art/r/pics"); n != 0 {
+		t.Errorf("got %d requests adding members the feeds already have in different case", n)
+	}
+}
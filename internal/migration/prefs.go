@@ -0,0 +1,101 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/progress"
+	"github.com/nileshnk/reddit-migrate/internal/reddit"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+)
+
+// processPrefs copies the selected account preferences from the old account to the new one.
+// When keys is empty, reddit.DefaultPrefKeys are copied. Only keys whose value differs are sent, in a single PATCH,
+// and the result lists each of them with the new account's value before and after. When responseData.Plan is set,
+// the diff is only added to the plan. The update is one request, so it is not checkpointed; running it again is harmless.
+func processPrefs(ctx context.Context, oldToken, newToken, oldUser, newUser string, keys []string, responseData *types.MigrationDetails) error {
+	if len(keys) == 0 {
+		keys = reddit.DefaultPrefKeys
+	}
+
+	config.InfoLogger.Printf("Fetching preferences from %s and %s...", oldUser, newUser)
+	progress.Phase(ctx, types.PhaseFetchPrefs, 0)
+	oldPrefs, err := reddit.FetchPrefs(oldToken)
+	if err != nil {
+		return fmt.Errorf("failed to fetch preferences from %s: %w", oldUser, err)
+	}
+	newPrefs, err := reddit.FetchPrefs(newToken)
+	if err != nil {
+		return fmt.Errorf("failed to fetch preferences from %s: %w", newUser, err)
+	}
+
+	changes, unchanged, skipped := diffPrefs(oldPrefs, newPrefs, keys)
+	config.InfoLogger.Printf("Found %d preferences to change on %s (%d unchanged, %d not set on %s).", len(changes), newUser, len(unchanged), len(skipped), oldUser)
+
+	if plan := responseData.Plan; plan != nil {
+		plan.PrefChanges = changes
+		plan.PrefsUnchanged = unchanged
+		return nil
+	}
+
+	result := types.ManagePrefsResponseType{Unchanged: unchanged, Skipped: skipped}
+	if len(changes) == 0 {
+		responseData.Prefs = result
+		return nil
+	}
+
+	update := make(map[string]interface{}, len(changes))
+	for _, change := range changes {
+		update[change.Key] = change.After
+	}
+	progress.Phase(ctx, types.PhaseCopyPrefs, len(changes))
+	updated, statusCode, err := reddit.UpdatePrefs(ctx, newToken, update)
+	result.StatusCode = statusCode
+	if err != nil {
+		config.ErrorLogger.Printf("Failed to update preferences on %s: %v", newUser, err)
+		result.Error = true
+		result.Changes = changes
+		responseData.Prefs = result
+		return nil
+	}
+
+	// Report what the account actually has now; Reddit silently ignores values it does not accept.
+	for i := range changes {
+		changes[i].After = updated[changes[i].Key]
+		if !reflect.DeepEqual(changes[i].After, update[changes[i].Key]) {
+			config.ErrorLogger.Printf("Preference %s on %s is %v after the update, expected %v.", changes[i].Key, newUser, changes[i].After, update[changes[i].Key])
+			result.Error = true
+		}
+	}
+	result.Changes = changes
+	config.InfoLogger.Printf("Updated %d preferences on %s.", len(changes), newUser)
+	responseData.Prefs = result
+	return nil
+}
+
+// diffPrefs compares the selected keys of two preference sets.
+// changes has one entry per key whose value differs, with Before from newPrefs and After from oldPrefs;
+// unchanged lists keys that already match and skipped lists keys oldPrefs does not have. All three are sorted by key.
+func diffPrefs(oldPrefs, newPrefs map[string]interface{}, keys []string) (changes []types.PrefChange, unchanged, skipped []string) {
+	sortedKeys := append([]string(nil), keys...)
+	sort.Strings(sortedKeys)
+	for i, key := range sortedKeys {
+		if i > 0 && key == sortedKeys[i-1] {
+			continue
+		}
+		value, ok := oldPrefs[key]
+		if !ok {
+			skipped = append(skipped, key)
+			continue
+		}
+		if reflect.DeepEqual(value, newPrefs[key]) {
+			unchanged = append(unchanged, key)
+			continue
+		}
+		changes = append(changes, types.PrefChange{Key: key, Before: newPrefs[key], After: value})
+	}
+	return changes, unchanged, skipped
+}
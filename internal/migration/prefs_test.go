@@ -0,0 +1,153 @@
+package migration
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nileshnk/reddit-migrate/internal/progress"
+	"github.com/nileshnk/reddit-migrate/internal/reddittest"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+)
+
+func TestDiffPrefs(t *testing.T) {
+	oldPrefs := map[string]interface{}{"nightmode": true, "lang": "de", "num_comments": float64(200), "media": nil}
+	tests := []struct {
+		name      string
+		newPrefs  map[string]interface{}
+		keys      []string
+		changes   []types.PrefChange
+		unchanged []string
+		skipped   []string
+	}{
+		{
+			name:      "changed, unchanged and skipped keys, sorted",
+			newPrefs:  map[string]interface{}{"nightmode": false, "lang": "de", "num_comments": float64(100)},
+			keys:      []string{"num_comments", "lang", "over_18", "nightmode"},
+			changes:   []types.PrefChange{{Key: "nightmode", Before: false, After: true}, {Key: "num_comments", Before: float64(100), After: float64(200)}},
+			unchanged: []string{"lang"},
+			skipped:   []string{"over_18"},
+		},
+		{
+			name:     "key missing on the new account",
+			newPrefs: map[string]interface{}{},
+			keys:     []string{"lang"},
+			changes:  []types.PrefChange{{Key: "lang", Before: nil, After: "de"}},
+		},
+		{
+			name:      "null on both accounts",
+			newPrefs:  map[string]interface{}{"media": nil},
+			keys:      []string{"media"},
+			unchanged: []string{"media"},
+		},
+		{
+			name:      "duplicate keys",
+			newPrefs:  map[string]interface{}{"lang": "de"},
+			keys:      []string{"lang", "lang"},
+			unchanged: []string{"lang"},
+		},
+		{
+			name:     "no keys",
+			newPrefs: map[string]interface{}{"lang": "en"},
+		},
+	}
+	for _, tt := range tests {
+		changes, unchanged, skipped := diffPrefs(oldPrefs, tt.newPrefs, tt.keys)
+		if !reflect.DeepEqual(changes, tt.changes) || !reflect.DeepEqual(unchanged, tt.unchanged) || !reflect.DeepEqual(skipped, tt.skipped) {
+			t.Errorf("%s: diffPrefs = %v, %v, %v; want %v, %v, %v", tt.name, changes, unchanged, skipped, tt.changes, tt.unchanged, tt.skipped)
+		}
+	}
+}
+
+func TestRunMigrationCopiesPrefs(t *testing.T) {
+	srv, oldAccount, newAccount := newAccountsWith(t, func(oldAccount, newAccount *reddittest.Account) {
+		oldAccount.Prefs = map[string]interface{}{"nightmode": true, "lang": "de", "public_votes": true}
+		newAccount.Prefs = map[string]interface{}{"nightmode": false, "lang": "de", "public_votes": false}
+	})
+
+	resp := RunMigration(context.Background(), "", types.MigrationRequestType{
+		AuthMethod:      "oauth",
+		OldAccountToken: oldAccount.Token,
+		NewAccountToken: newAccount.Token,
+		Preferences:     types.PreferencesType{MigratePrefsBool: true},
+	})
+	if !resp.Success {
+		t.Fatalf("migration failed: %s", resp.Message)
+	}
+	if got, want := resp.Data.Prefs.Changes, []types.PrefChange{{Key: "nightmode", Before: false, After: true}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %v, want %v", got, want)
+	}
+	// public_votes is a privacy setting and not among the default keys, so it is left alone.
+	want := map[string]interface{}{"nightmode": true, "lang": "de", "public_votes": false}
+	if got := srv.Account("new_user").Prefs; !reflect.DeepEqual(got, want) {
+		t.Errorf("new account preferences = %v, want %v", got, want)
+	}
+	if n := srv.Requests("/api/v1/me/prefs"); n != 3 {
+		t.Errorf("got %d preference requests, want two fetches and one update", n)
+	}
+}
+
+func TestRunMigrationReportsRejectedPrefs(t *testing.T) {
+	srv, oldAccount, newAccount := newAccountsWith(t, func(oldAccount, newAccount *reddittest.Account) {
+		// The new account does not know legacy_pref, so Reddit rejects the whole update.
+		oldAccount.Prefs = map[string]interface{}{"nightmode": true, "legacy_pref": "x"}
+		newAccount.Prefs = map[string]interface{}{"nightmode": false}
+	})
+
+	resp := RunMigration(context.Background(), "", types.MigrationRequestType{
+		AuthMethod:      "oauth",
+		OldAccountToken: oldAccount.Token,
+		NewAccountToken: newAccount.Token,
+		Preferences: types.PreferencesType{
+			MigratePrefsBool: true,
+			PrefKeys:         []string{"nightmode", "legacy_pref"},
+		},
+	})
+	if resp.Success || !resp.Data.Prefs.Error || resp.Data.Prefs.StatusCode != 400 {
+		t.Fatalf("want a rejected update, got success=%v %+v", resp.Success, resp.Data.Prefs)
+	}
+	want := []types.PrefChange{{Key: "legacy_pref", Before: nil, After: "x"}, {Key: "nightmode", Before: false, After: true}}
+	if got := resp.Data.Prefs.Changes; !reflect.DeepEqual(got, want) {
+		t.Errorf("attempted changes = %v, want %v", got, want)
+	}
+	if got := srv.Account("new_user").Prefs["nightmode"]; got != false {
+		t.Errorf("new account nightmode = %v after a rejected update, want false", got)
+	}
+}
+
+// failOnPhase makes the next request to path fail with statusCode once the migration enters phase.
+type failOnPhase struct {
+	srv        *reddittest.Server
+	phase      string
+	path       string
+	statusCode int
+}
+
+func (f failOnPhase) Report(event types.ProgressEvent) {
+	if event.Type == types.PhaseEvent && event.Phase == f.phase {
+		f.srv.FailNext(f.path, f.statusCode)
+	}
+}
+
+func TestRunMigrationReportsMissingScopeForPrefs(t *testing.T) {
+	srv, oldAccount, newAccount := newAccountsWith(t, func(oldAccount, newAccount *reddittest.Account) {
+		oldAccount.Prefs = map[string]interface{}{"nightmode": true}
+		newAccount.Prefs = map[string]interface{}{"nightmode": false}
+	})
+	// Reddit refuses the PATCH, but not the GETs before it, to a token authorized without the account scope.
+	ctx := progress.WithReporter(context.Background(), failOnPhase{srv, types.PhaseCopyPrefs, "/api/v1/me/prefs", 403})
+
+	resp := RunMigration(ctx, "", types.MigrationRequestType{
+		AuthMethod:      "oauth",
+		OldAccountToken: oldAccount.Token,
+		NewAccountToken: newAccount.Token,
+		Preferences:     types.PreferencesType{MigratePrefsBool: true, PrefKeys: []string{"nightmode"}},
+	})
+	if resp.Success || !resp.Data.Prefs.Error || resp.Data.Prefs.StatusCode != 403 {
+		t.Fatalf("want a refused update, got success=%v %+v", resp.Success, resp.Data.Prefs)
+	}
+	if !strings.Contains(resp.Message, "reconnect") {
+		t.Errorf("Message = %q, want it to ask to reconnect the account", resp.Message)
+	}
+}
@@ -0,0 +1,182 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/journal"
+	"github.com/nileshnk/reddit-migrate/internal/progress"
+	"github.com/nileshnk/reddit-migrate/internal/reddit"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+)
+
+// ResumeMigration continues a standard or custom migration from its checkpoint journal.
+// Only items that are still pending or previously failed are processed, in the original phase order.
+// Deletions from the old account are only performed for items whose migration to the new account is recorded as done,
+// so resuming never unsubscribes or unsaves something that is not yet on the new account.
+// Import journals are resumed with the new account's credentials only.
+// The caller owns jrnl and is responsible for closing it.
+func ResumeMigration(ctx context.Context, jrnl *journal.Journal, req types.ResumeMigrationRequest) types.MigrationResponseType {
+	var finalResponse types.MigrationResponseType
+	header := jrnl.Header()
+
+	config.InfoLogger.Printf("Resuming %s job %s (%s -> %s)...", header.Kind, header.JobID, header.OldUsername, header.NewUsername)
+	defer holdTokens(req.OldAccountToken, req.NewAccountToken)()
+
+	// Imports only ever touch the new account; the account the archive came from may no longer exist.
+	var oldAccountToken, oldAccountUsername string
+	if header.Kind == "import" {
+		oldAccountUsername = header.OldUsername
+	} else {
+		var err error
+		oldAccountToken, oldAccountUsername, err = ResolveAccount("old", req.OldAccountProfile, req.AuthMethod, req.OldAccountCookie, req.OldAccountToken, req.OldAccountUsername)
+		if err != nil {
+			config.ErrorLogger.Println(err)
+			finalResponse.Message = err.Error()
+			return finalResponse
+		}
+	}
+	newAccountToken, newAccountUsername, err := ResolveAccount("new", req.NewAccountProfile, req.AuthMethod, req.NewAccountCookie, req.NewAccountToken, req.NewAccountUsername)
+	if err != nil {
+		config.ErrorLogger.Println(err)
+		finalResponse.Message = err.Error()
+		return finalResponse
+	}
+
+	defer holdTokens(oldAccountToken, newAccountToken)()
+
+	// Resuming against different accounts would replay the plan onto the wrong user.
+	if !strings.EqualFold(oldAccountUsername, header.OldUsername) || !strings.EqualFold(newAccountUsername, header.NewUsername) {
+		finalResponse.Message = fmt.Sprintf("Accounts do not match the original migration: expected %s -> %s, got %s -> %s",
+			header.OldUsername, header.NewUsername, oldAccountUsername, newAccountUsername)
+		config.ErrorLogger.Println(finalResponse.Message)
+		return finalResponse
+	}
+
+	ctx = progress.WithReporter(ctx, jrnl)
+	subscribeAction := string(types.SubscribeAction)
+	saveAction := string(types.SaveAction)
+
+	subredditsToMigrate := jrnl.Pending(journal.KindSubreddit, subscribeAction)
+	if len(subredditsToMigrate) > 0 && ctx.Err() == nil {
+		progress.Phase(ctx, types.PhaseSubscribeSubreddits, len(subredditsToMigrate))
+		finalResponse.Data.SubscribeSubreddit = migrateSubredditsWithRetry(ctx, newAccountToken, subredditsToMigrate, newAccountUsername)
+	}
+
+	followedToMigrate := jrnl.Pending(journal.KindUser, subscribeAction)
+	if len(followedToMigrate) > 0 && ctx.Err() == nil {
+		progress.Phase(ctx, types.PhaseFollowUsers, len(followedToMigrate))
+		followedUsersResult := reddit.ManageFollowedUsers(ctx, newAccountToken, followedToMigrate, types.SubscribeAction)
+		config.InfoLogger.Printf("Followed %d users for %s (failed: %d).", followedUsersResult.SuccessCount, newAccountUsername, followedUsersResult.FailedCount)
+	}
+
+	subredditsToDelete, heldSubreddits := readyToDelete(jrnl, journal.KindSubreddit, subscribeAction, string(types.UnsubscribeAction))
+	if len(subredditsToDelete) > 0 && ctx.Err() == nil {
+		progress.Phase(ctx, types.PhaseUnsubscribeSubreddits, len(subredditsToDelete))
+		finalResponse.Data.UnsubscribeSubreddit = reddit.ManageSubreddits(ctx, oldAccountToken, subredditsToDelete, types.UnsubscribeAction, config.DefaultSubredditChunkSize)
+	}
+
+	postsToMigrate := jrnl.Pending(journal.KindPost, saveAction)
+	if len(postsToMigrate) > 0 && ctx.Err() == nil {
+		progress.Phase(ctx, types.PhaseSavePosts, len(postsToMigrate))
+		finalResponse.Data.SavePost = savePosts(ctx, newAccountToken, postsToMigrate, header.PreserveOrder)
+	}
+
+	postsToDelete, heldPosts := readyToDelete(jrnl, journal.KindPost, saveAction, string(types.UnsaveAction))
+	if len(postsToDelete) > 0 && ctx.Err() == nil {
+		progress.Phase(ctx, types.PhaseUnsavePosts, len(postsToDelete))
+		finalResponse.Data.UnsavePost = reddit.ManageSavedPosts(ctx, oldAccountToken, postsToDelete, types.UnsaveAction, config.DefaultPostConcurrency)
+	}
+
+	postsToUpvote := jrnl.Pending(journal.KindPost, string(types.UpvoteAction))
+	if len(postsToUpvote) > 0 && ctx.Err() == nil {
+		progress.Phase(ctx, types.PhaseUpvotePosts, len(postsToUpvote))
+		finalResponse.Data.UpvotePost = reddit.ManageSavedPosts(ctx, newAccountToken, postsToUpvote, types.UpvoteAction, config.DefaultPostConcurrency)
+	}
+
+	postsToDownvote := jrnl.Pending(journal.KindPost, string(types.DownvoteAction))
+	if len(postsToDownvote) > 0 && ctx.Err() == nil {
+		progress.Phase(ctx, types.PhaseDownvotePosts, len(postsToDownvote))
+		finalResponse.Data.DownvotePost = reddit.ManageSavedPosts(ctx, newAccountToken, postsToDownvote, types.DownvoteAction, config.DefaultPostConcurrency)
+	}
+
+	postsToHide := jrnl.Pending(journal.KindPost, string(types.HideAction))
+	if len(postsToHide) > 0 && ctx.Err() == nil {
+		progress.Phase(ctx, types.PhaseHidePosts, len(postsToHide))
+		finalResponse.Data.HidePost = reddit.ManageSavedPosts(ctx, newAccountToken, postsToHide, types.HideAction, config.DefaultPostConcurrency)
+	}
+
+	postsToUnhide, heldHidden := readyToDelete(jrnl, journal.KindPost, string(types.HideAction), string(types.UnhideAction))
+	if len(postsToUnhide) > 0 && ctx.Err() == nil {
+		progress.Phase(ctx, types.PhaseUnhidePosts, len(postsToUnhide))
+		finalResponse.Data.UnhidePost = reddit.ManageSavedPosts(ctx, oldAccountToken, postsToUnhide, types.UnhideAction, config.DefaultPostConcurrency)
+	}
+
+	// The journal only records feed names, so feeds are re-read from the old account and merged again from scratch.
+	// Import journals cannot do that without the archive; importing it again skips everything already copied.
+	multisToMigrate := jrnl.Pending(journal.KindMultireddit, subscribeAction)
+	if len(multisToMigrate) > 0 && ctx.Err() == nil {
+		if header.Kind == "import" {
+			config.InfoLogger.Printf("Skipping %d pending custom feeds of import job %s; import the archive again to copy them.", len(multisToMigrate), header.JobID)
+		} else if err := processMultireddits(ctx, jrnl, oldAccountToken, newAccountToken, oldAccountUsername, newAccountUsername, multisToMigrate, &finalResponse.Data); err != nil {
+			config.ErrorLogger.Printf("Error processing multireddits: %v", err)
+			finalResponse.Data.Multireddits.Error = true
+		}
+	}
+
+	usersToBlock := jrnl.Pending(journal.KindUser, string(types.BlockAction))
+	if len(usersToBlock) > 0 && ctx.Err() == nil {
+		progress.Phase(ctx, types.PhaseBlockUsers, len(usersToBlock))
+		finalResponse.Data.BlockUser = reddit.BlockUsers(ctx, newAccountToken, usersToBlock)
+	}
+
+	hasErrors := finalResponse.Data.SubscribeSubreddit.Error ||
+		finalResponse.Data.UnsubscribeSubreddit.Error ||
+		finalResponse.Data.SavePost.FailedCount > 0 ||
+		finalResponse.Data.UnsavePost.FailedCount > 0 ||
+		finalResponse.Data.UpvotePost.FailedCount > 0 ||
+		finalResponse.Data.DownvotePost.FailedCount > 0 ||
+		finalResponse.Data.HidePost.FailedCount > 0 ||
+		finalResponse.Data.UnhidePost.FailedCount > 0 ||
+		finalResponse.Data.Multireddits.Error ||
+		finalResponse.Data.BlockUser.Error
+	held := heldSubreddits + heldPosts + heldHidden
+
+	summary := jrnl.Summary()
+	switch {
+	case ctx.Err() != nil:
+		finalResponse.Message = "Resumed migration cancelled. It can be resumed again from where it stopped."
+		config.InfoLogger.Printf("Resumed job %s cancelled.", header.JobID)
+	case hasErrors || summary.Pending+summary.Failed > 0:
+		finalResponse.Message = fmt.Sprintf("Resumed migration finished with %d items still pending or failed (%d deletions held back until their migration succeeds). It can be resumed again.",
+			summary.Pending+summary.Failed, held)
+		config.InfoLogger.Printf("Resumed job %s finished with %d items outstanding.", header.JobID, summary.Pending+summary.Failed)
+	default:
+		finalResponse.Success = true
+		finalResponse.Message = "Resumed migration completed successfully."
+		config.InfoLogger.Printf("Resumed job %s completed successfully.", header.JobID)
+	}
+
+	return finalResponse
+}
+
+// readyToDelete returns the pending deletion items of the given kind whose migration is safe to rely on,
+// together with the number of items held back. An item is held back when its migration was planned but has not succeeded;
+// items that were never planned for migration (for example because they already existed on the new account) are ready.
+func readyToDelete(jrnl *journal.Journal, kind, migrateAction, deleteAction string) ([]string, int) {
+	var ready []string
+	held := 0
+	for _, item := range jrnl.Pending(kind, deleteAction) {
+		if status, planned := jrnl.Status(kind, migrateAction, item); planned && status != journal.StatusDone {
+			held++
+			continue
+		}
+		ready = append(ready, item)
+	}
+	if held > 0 {
+		config.InfoLogger.Printf("Holding back %d %s %s items until their migration succeeds.", held, kind, deleteAction)
+	}
+	return ready, held
+}
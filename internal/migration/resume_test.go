@@ -0,0 +1,106 @@
+package migration
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/journal"
+	"github.com/nileshnk/reddit-migrate/internal/progress"
+	"github.com/nileshnk/reddit-migrate/internal/reddittest"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+)
+
+// killAfterSaves cancels the migration once the given number of posts has been saved, as if the process died there.
+type killAfterSaves struct {
+	mu     sync.Mutex
+	left   int
+	cancel context.CancelFunc
+}
+
+func (k *killAfterSaves) Report(event types.ProgressEvent) {
+	if event.Type != types.PostEvent || event.Action != string(types.SaveAction) || !event.Success {
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.left--; k.left == 0 {
+		k.cancel()
+	}
+}
+
+func TestResumeMigrationAfterKillMidSave(t *testing.T) {
+	journalDir := config.JournalDir
+	config.JournalDir = t.TempDir()
+	t.Cleanup(func() { config.JournalDir = journalDir })
+
+	srv, oldAccount, newAccount := newAccountsWith(t, func(oldAccount, newAccount *reddittest.Account) {
+		oldAccount.Saved = []string{"t3_e", "t3_d", "t3_c", "t3_b", "t3_a"}
+		newAccount.Saved = nil
+	})
+	prefs := types.PreferencesType{MigratePostBool: true, DeletePostBool: true, PreserveOrderBool: true}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = progress.WithReporter(ctx, &killAfterSaves{left: 2, cancel: cancel})
+	resp := RunMigration(ctx, t.Name(), types.MigrationRequestType{
+		AuthMethod:      "oauth",
+		OldAccountToken: oldAccount.Token,
+		NewAccountToken: newAccount.Token,
+		Preferences:     prefs,
+	})
+	if resp.Success {
+		t.Fatal("killed migration reported success")
+	}
+	if got, want := srv.Account("new_user").Saved, []string{"t3_b", "t3_a"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("new account saved posts after the kill = %v, want %v", got, want)
+	}
+	if n := srv.Requests("/api/unsave"); n != 0 {
+		t.Fatalf("killed migration unsaved %d posts before saving all of them", n)
+	}
+
+	jrnl, err := journal.Open(config.JournalDir, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jrnl.Close()
+	resp = ResumeMigration(context.Background(), jrnl, types.ResumeMigrationRequest{
+		AuthMethod:      "oauth",
+		OldAccountToken: oldAccount.Token,
+		NewAccountToken: newAccount.Token,
+	})
+	if !resp.Success {
+		t.Fatalf("resume failed: %s", resp.Message)
+	}
+
+	if n := srv.Requests("/api/save"); n != 5 {
+		t.Errorf("got %d save requests over both runs, want each of the 5 posts saved once", n)
+	}
+	if got, want := srv.Account("new_user").Saved, []string{"t3_e", "t3_d", "t3_c", "t3_b", "t3_a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("new account saved posts = %v, want %v", got, want)
+	}
+	if got := srv.Account("old_user").Saved; len(got) != 0 {
+		t.Errorf("old account still has saved posts %v", got)
+	}
+}
+
+func TestReadyToDeleteHoldsBackUnmigratedItems(t *testing.T) {
+	jrnl, err := journal.Create(t.TempDir(), journal.Header{JobID: t.Name()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jrnl.Close()
+	save, unsave := string(types.SaveAction), string(types.UnsaveAction)
+	jrnl.Plan(journal.KindPost, save, []string{"t3_a", "t3_b", "t3_c"})
+	// t3_d was already saved on the new account, so its save was never planned.
+	jrnl.Plan(journal.KindPost, unsave, []string{"t3_a", "t3_b", "t3_c", "t3_d"})
+	jrnl.Record(journal.KindPost, save, "t3_a", journal.StatusDone, "")
+	jrnl.Record(journal.KindPost, save, "t3_b", journal.StatusFailed, "403")
+
+	ready, held := readyToDelete(jrnl, journal.KindPost, save, unsave)
+	if want := []string{"t3_a", "t3_d"}; !reflect.DeepEqual(ready, want) || held != 2 {
+		t.Errorf("readyToDelete = %v, %d held; want %v, 2 held", ready, held, want)
+	}
+}
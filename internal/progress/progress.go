@@ -0,0 +1,53 @@
+package progress
+
+import (
+	"context"
+	"time"
+
+	"github.com/nileshnk/reddit-migrate/internal/types"
+)
+
+// Reporter receives progress events emitted during a migration.
+type Reporter interface {
+	Report(event types.ProgressEvent)
+}
+
+type reporterKey struct{}
+
+// WithReporter returns a copy of ctx that carries the given reporter.
+// Functions deeper in the call chain emit events through Report without needing the reporter passed explicitly.
+// If ctx already carries a reporter, events are delivered to both, the existing one first.
+func WithReporter(ctx context.Context, reporter Reporter) context.Context {
+	if existing, ok := ctx.Value(reporterKey{}).(Reporter); ok && existing != nil {
+		reporter = multiReporter{existing, reporter}
+	}
+	return context.WithValue(ctx, reporterKey{}, reporter)
+}
+
+// multiReporter fans a single event out to several reporters.
+type multiReporter []Reporter
+
+// Report delivers the event to every reporter in order.
+func (m multiReporter) Report(event types.ProgressEvent) {
+	for _, reporter := range m {
+		reporter.Report(event)
+	}
+}
+
+// Report sends an event to the reporter carried by ctx, if any.
+// It fills in the event time when the caller left it empty.
+func Report(ctx context.Context, event types.ProgressEvent) {
+	reporter, ok := ctx.Value(reporterKey{}).(Reporter)
+	if !ok || reporter == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	reporter.Report(event)
+}
+
+// Phase reports the start of a migration phase with the number of items it will process.
+func Phase(ctx context.Context, phase string, total int) {
+	Report(ctx, types.ProgressEvent{Type: types.PhaseEvent, Phase: phase, Total: total, Success: true})
+}
@@ -1,176 +1,222 @@
 package ratelimiter
 
 import (
+	"context"
+	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/nileshnk/reddit-migrate/internal/config"
 )
 
-// RateLimiter provides a token bucket based rate limiting mechanism.
-// It controls the frequency of operations to avoid overwhelming an external API.
+// Reddit reports the state of the caller's rate limit window on every OAuth response.
+const (
+	headerRemaining = "X-Ratelimit-Remaining" // Requests left in the current window (may be fractional)
+	headerUsed      = "X-Ratelimit-Used"      // Requests made in the current window
+	headerReset     = "X-Ratelimit-Reset"     // Seconds until the window resets
+)
+
+// RateLimiter paces requests to Reddit using the X-Ratelimit-* headers of earlier responses.
+// Once a response has been observed, the requests left in the window are spread evenly over the time until it resets,
+// so the budget runs out exactly when the window does and a 429 means waiting only until the reset.
+// Until then, and for hosts that send no headers, it falls back to maxTokens requests per interval.
+//
+// Waiters are queued per job (see WithJob) and served round-robin, so a job running many workers
+// does not starve another job drawing from the same budget.
 type RateLimiter struct {
-	requests     chan struct{} // Channel acting as the token bucket.
-	interval     time.Duration // Interval at which tokens are refreshed/refilled.
-	maxTokens    int           // Maximum number of tokens (requests) allowed per interval.
-	pauseSignal  chan struct{} // Signal to pause the rate limiter.
-	resumeSignal chan struct{} // Signal to resume the rate limiter.
-	isPaused     bool          // Current paused state of the rate limiter.
-	mu           sync.RWMutex  // Mutex to protect access to isPaused state.
+	mu        sync.Mutex
+	interval  time.Duration // Fallback window length.
+	limit     float64       // Requests per window; maxTokens until Reddit reports used+remaining.
+	remaining float64       // Requests left in the current window, minus those granted since Reddit last reported it.
+	reset     time.Time     // End of the current window.
+	next      time.Time     // Earliest start of the next request.
+	lastUsed  time.Time     // Last time a request was granted or queued; used to evict idle limiters.
+
+	queues      map[string][]chan struct{} // Waiters per job, oldest first. A channel is closed when its request is granted.
+	order       []string                   // Jobs with waiters, in the order they are served next.
+	dispatching bool                       // Whether a dispatch goroutine is granting queued waiters.
 }
 
-// NewRateLimiter creates and starts a new RateLimiter.
-// maxTokens defines how many requests can be made within the given interval.
+// NewRateLimiter creates a RateLimiter.
+// maxTokens and interval define the fallback budget used until Reddit reports the real one.
 func NewRateLimiter(maxTokens int, interval time.Duration) *RateLimiter {
-	config.InfoLogger.Printf("RateLimiter: Initializing with maxTokens=%d, interval=%v", maxTokens, interval)
-	rl := &RateLimiter{
-		requests:     make(chan struct{}, maxTokens),
-		interval:     interval,
-		maxTokens:    maxTokens,
-		pauseSignal:  make(chan struct{}, 1),
-		resumeSignal: make(chan struct{}, 1),
-		isPaused:     false,
-	}
-	go rl.refillTokens()
-	return rl
+	config.InfoLogger.Printf("RateLimiter: Initializing with fallback maxTokens=%d, interval=%v", maxTokens, interval)
+	if maxTokens < 1 {
+		maxTokens = 1
+	}
+	return &RateLimiter{
+		interval: interval,
+		limit:    float64(maxTokens),
+		queues:   make(map[string][]chan struct{}),
+	}
 }
 
-func (rl *RateLimiter) refillTokens() {
-	ticker := time.NewTicker(rl.interval)
-	defer ticker.Stop()
-
-	config.DebugLogger.Printf("RateLimiter: Starting token refill goroutine with interval %v", rl.interval)
-	for range ticker.C {
-		rl.mu.RLock()
-		isPausedCurrent := rl.isPaused
-		rl.mu.RUnlock()
-
-		if !isPausedCurrent {
-			// Refill tokens up to maxTokens
-			// Draining and then refilling ensures that the bucket doesn't exceed maxTokens
-			// if tokens were added externally or if maxTokens changed.
-			// However, the current logic only drains. Let's refill properly.
-			tokensToRefill := rl.maxTokens - len(rl.requests)
-			if tokensToRefill < 0 { // Should not happen if len(rl.requests) is capped by maxTokens
-				tokensToRefill = 0
-			}
+// Wait blocks until the next request may start.
+func (rl *RateLimiter) Wait() {
+	_ = rl.WaitContext(context.Background())
+}
 
-			// First, drain any existing tokens to reset the count for the interval if that's the desired behavior.
-			// The original code drained all tokens. If the goal is to ADD tokens up to maxTokens per interval,
-			// then draining is not what we want unless the interval signifies a complete reset.
-			// Given the name 'refill', it implies adding new tokens.
-			// The original draining logic seems to conflict with typical token bucket refill.
-			// A typical refill adds tokens up to the bucket capacity.
-			// Let's adjust to a more standard token bucket refill: add tokens if space is available.
-
-			numToDrain := len(rl.requests) // This was the original logic, which empties the bucket
-			for i := 0; i < numToDrain; i++ {
-				select {
-				case <-rl.requests:
-				default:
-					break
-				}
-			}
-			// After draining, fill to maxTokens. This ensures a fixed number of requests per interval.
-			for i := 0; i < rl.maxTokens; i++ {
-				select {
-				case rl.requests <- struct{}{}:
-				default: // Bucket is full
-					break
-				}
-			}
-			config.DebugLogger.Printf("RateLimiter: Tokens refilled. Current token count: %d", len(rl.requests))
-		} else {
-			config.DebugLogger.Printf("RateLimiter: Token refill skipped because limiter is paused.")
-		}
+// WaitContext blocks until the next request may start or ctx is done, in which case it returns ctx's error.
+// The wait is queued under the job carried by ctx.
+func (rl *RateLimiter) WaitContext(ctx context.Context) error {
+	startTime := time.Now()
+	job := jobFromContext(ctx)
+
+	rl.mu.Lock()
+	now := time.Now()
+	rl.lastUsed = now
+	if len(rl.order) == 0 && rl.reserveLocked(now) == 0 {
+		rl.mu.Unlock()
+		config.DebugLogger.Printf("RateLimiter: Request allowed. Wait time: %v", time.Since(startTime))
+		return nil
+	}
+	ready := make(chan struct{})
+	if len(rl.queues[job]) == 0 {
+		rl.order = append(rl.order, job)
+	}
+	rl.queues[job] = append(rl.queues[job], ready)
+	if !rl.dispatching {
+		rl.dispatching = true
+		go rl.dispatch()
+	}
+	rl.mu.Unlock()
+
+	select {
+	case <-ready:
+		config.DebugLogger.Printf("RateLimiter: Request allowed. Wait time: %v", time.Since(startTime))
+		return nil
+	case <-ctx.Done():
+		rl.mu.Lock()
+		rl.dequeueLocked(job, ready)
+		rl.mu.Unlock()
+		return ctx.Err()
 	}
 }
 
-func (rl *RateLimiter) Pause() {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-	if !rl.isPaused {
-		rl.isPaused = true
-		config.InfoLogger.Println("RateLimiter: Paused.")
-		// Non-blocking send to pauseSignal
-		select {
-		case rl.pauseSignal <- struct{}{}:
-			config.DebugLogger.Println("RateLimiter: Pause signal sent to unblock waiters.")
-		default:
-			config.DebugLogger.Println("RateLimiter: Pause signal channel full or no active waiters on pauseSignal.")
+// dispatch grants queued waiters as the budget allows, taking one waiter from each job in turn.
+// It runs until the queue is empty.
+func (rl *RateLimiter) dispatch() {
+	for {
+		rl.mu.Lock()
+		if len(rl.order) == 0 {
+			rl.dispatching = false
+			rl.mu.Unlock()
+			return
+		}
+		now := time.Now()
+		delay := rl.reserveLocked(now)
+		if delay == 0 {
+			rl.lastUsed = now
+			job := rl.order[0]
+			ready := rl.queues[job][0]
+			rl.queues[job] = rl.queues[job][1:]
+			rl.order = rl.order[1:]
+			if len(rl.queues[job]) > 0 {
+				rl.order = append(rl.order, job)
+			} else {
+				delete(rl.queues, job)
+			}
+			close(ready)
+			rl.mu.Unlock()
+			continue
 		}
+		rl.mu.Unlock()
+		config.DebugLogger.Printf("RateLimiter: Waiting %v before the next request.", delay)
+		time.Sleep(delay)
 	}
 }
 
-func (rl *RateLimiter) Resume() {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-	if rl.isPaused {
-		rl.isPaused = false
-		config.InfoLogger.Println("RateLimiter: Resumed.")
-		// Non-blocking send to resumeSignal
-		select {
-		case rl.resumeSignal <- struct{}{}:
-			config.DebugLogger.Println("RateLimiter: Resume signal sent to unblock waiters.")
-		default:
-			config.DebugLogger.Println("RateLimiter: Resume signal channel full or no active waiters on resumeSignal.")
+// dequeueLocked removes a waiter whose context ended. If it was granted in the meantime, that request goes unused.
+func (rl *RateLimiter) dequeueLocked(job string, ready chan struct{}) {
+	waiters := rl.queues[job]
+	for i, w := range waiters {
+		if w != ready {
+			continue
+		}
+		waiters = append(waiters[:i:i], waiters[i+1:]...)
+		if len(waiters) > 0 {
+			rl.queues[job] = waiters
+			return
 		}
+		delete(rl.queues, job)
+		for j, queued := range rl.order {
+			if queued == job {
+				rl.order = append(rl.order[:j:j], rl.order[j+1:]...)
+				break
+			}
+		}
+		return
 	}
 }
 
-// Wait blocks until a token is available or the limiter is paused and then resumed.
-// It respects the pause and resume signals.
-func (rl *RateLimiter) Wait() {
-	config.DebugLogger.Println("RateLimiter: Attempting to acquire token...")
-	startTime := time.Now()
+// reserveLocked grants a request and returns 0 if one may start at now, or returns how long to wait before trying again.
+// rl.mu must be held.
+func (rl *RateLimiter) reserveLocked(now time.Time) time.Duration {
+	if !now.Before(rl.reset) {
+		// A new window started. Its length is only known once Reddit reports it, so assume the fallback interval.
+		rl.remaining = rl.limit
+		rl.reset = now.Add(rl.interval)
+	}
+	if rl.remaining < 1 {
+		return rl.reset.Sub(now)
+	}
+	if now.Before(rl.next) {
+		return rl.next.Sub(now)
+	}
 
-	// These config values are used by the caller in posts.go, not directly in Wait for its own timeout.
-	// The Wait function's timeout behavior is governed by the channel operations and pause/resume signals.
-	// Keeping them here for now if they are intended to influence how Wait behaves beyond just token acquisition.
-	// However, the current RateLimiter.Wait() primarily waits on rl.requests, rl.pauseSignal, or rl.resumeSignal.
-	// The time.After in the original code within Wait() was used as a periodic check.
-	// Let's use a shorter, more responsive internal timeout for the periodic checks if needed.
-	// A very short sleep/timeout can be used to prevent busy-waiting if no token is immediately available.
-	// The original `config.RateLimitSleepInterval` was used as a generic polling interval.
+	rl.remaining--
+	// Spread the requests still left evenly over the rest of the window.
+	spacing := time.Duration(0)
+	if rl.remaining >= 1 {
+		spacing = time.Duration(float64(rl.reset.Sub(now)) / rl.remaining)
+	}
+	rl.next = now.Add(spacing)
+	return 0
+}
 
-	checkInterval := config.RateLimitInterval // Internal check interval if no token or signal is received quickly
+// Observe updates the limiter from a response's rate limit headers.
+// A 429 empties the current window; if the response has no headers, the window is assumed to reset after
+// config.RateLimitSleepInterval since there is nothing better to go on.
+func (rl *RateLimiter) Observe(header http.Header, statusCode int) {
+	remaining, errRemaining := strconv.ParseFloat(header.Get(headerRemaining), 64)
+	resetSeconds, errReset := strconv.ParseFloat(header.Get(headerReset), 64)
+	used, errUsed := strconv.ParseFloat(header.Get(headerUsed), 64)
+	hasHeaders := errRemaining == nil && errReset == nil
 
-	for {
-		rl.mu.RLock()
-		isPausedCurrent := rl.isPaused
-		rl.mu.RUnlock()
-
-		if !isPausedCurrent {
-			select {
-			case rl.requests <- struct{}{}: // Attempt to send a request token into the channel (acquiring it)
-				config.DebugLogger.Printf("RateLimiter: Token acquired. Wait time: %v", time.Since(startTime))
-				return
-			case <-rl.pauseSignal:
-				config.DebugLogger.Println("RateLimiter: Notified by pauseSignal while attempting to acquire token. Re-evaluating state.")
-				// Drain the signal if multiple pauses happened quickly, ensuring we react to the latest state.
-				// This helps if Pause() is called multiple times before Wait() processes the signal.
-				for len(rl.pauseSignal) > 0 {
-					<-rl.pauseSignal
-				}
-				continue // Re-check isPaused status
-			case <-time.After(checkInterval): // Periodically re-check state if no token or signal
-				config.DebugLogger.Printf("RateLimiter: Timed out waiting for token/pause signal, re-checking state.")
-				continue
-			}
-		} else {
-			config.DebugLogger.Println("RateLimiter: Currently paused. Waiting for resume signal or timeout.")
-			select {
-			case <-rl.resumeSignal:
-				config.DebugLogger.Println("RateLimiter: Notified by resumeSignal while paused. Re-evaluating state.")
-				// Drain the signal
-				for len(rl.resumeSignal) > 0 {
-					<-rl.resumeSignal
-				}
-				continue // Re-check isPaused status
-			case <-time.After(checkInterval): // Periodically re-check state
-				config.DebugLogger.Printf("RateLimiter: Timed out waiting for resume signal, re-checking pause state.")
-				continue
-			}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := time.Now()
+
+	if hasHeaders {
+		rl.remaining = remaining
+		rl.reset = now.Add(time.Duration(resetSeconds * float64(time.Second)))
+		if errUsed == nil && used+remaining > 0 {
+			rl.limit = used + remaining
 		}
 	}
+	if statusCode == http.StatusTooManyRequests {
+		rl.remaining = 0
+		if !hasHeaders {
+			rl.reset = now.Add(config.RateLimitSleepInterval)
+		}
+		config.InfoLogger.Printf("RateLimiter: Rate limited; waiting %v for the window to reset.", rl.reset.Sub(now).Round(time.Second))
+	}
+}
+
+// Delay returns how long the next request would have to wait if it were made now.
+func (rl *RateLimiter) Delay() time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := time.Now()
+	if !now.Before(rl.reset) {
+		return 0
+	}
+	if rl.remaining < 1 {
+		return rl.reset.Sub(now)
+	}
+	if now.Before(rl.next) {
+		return rl.next.Sub(now)
+	}
+	return 0
 }
@@ -0,0 +1,136 @@
+package ratelimiter
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+)
+
+func TestMain(m *testing.M) {
+	config.InfoLogger = log.New(io.Discard, "", 0)
+	config.ErrorLogger = log.New(io.Discard, "", 0)
+	config.DebugLogger = log.New(io.Discard, "", 0)
+	config.RateLimitSleepInterval = time.Second
+	os.Exit(m.Run())
+}
+
+func rateLimitHeader(used, remaining, reset string) http.Header {
+	h := http.Header{}
+	h.Set(headerUsed, used)
+	h.Set(headerRemaining, remaining)
+	h.Set(headerReset, reset)
+	return h
+}
+
+func TestObserveSpreadsRemainingRequests(t *testing.T) {
+	rl := NewRateLimiter(100, time.Minute)
+	rl.Observe(rateLimitHeader("90", "10", "10"), http.StatusOK)
+
+	if err := rl.WaitContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// 9 requests left over 10 seconds are spaced about 1.1s apart.
+	if d := rl.Delay(); d < time.Second || d > 1200*time.Millisecond {
+		t.Fatalf("Delay() = %v, want about 1.1s", d)
+	}
+}
+
+func TestObserveRateLimitedWaitsForReset(t *testing.T) {
+	rl := NewRateLimiter(100, time.Minute)
+	rl.Observe(rateLimitHeader("100", "0", "5"), http.StatusTooManyRequests)
+	if d := rl.Delay(); d < 4*time.Second || d > 5*time.Second {
+		t.Fatalf("Delay() = %v, want about 5s", d)
+	}
+
+	// Without headers the limiter falls back to RateLimitSleepInterval.
+	rl = NewRateLimiter(100, time.Minute)
+	rl.Observe(http.Header{}, http.StatusTooManyRequests)
+	if d := rl.Delay(); d <= 0 || d > config.RateLimitSleepInterval {
+		t.Fatalf("Delay() = %v, want at most %v", d, config.RateLimitSleepInterval)
+	}
+}
+
+func TestWaitContextCancelled(t *testing.T) {
+	rl := NewRateLimiter(1, time.Hour)
+	if err := rl.WaitContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := rl.WaitContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("WaitContext() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if len(rl.order) != 0 || len(rl.queues) != 0 {
+		t.Fatalf("cancelled waiter still queued: order=%v", rl.order)
+	}
+}
+
+func TestJobsAreServedRoundRobin(t *testing.T) {
+	rl := NewRateLimiter(10, 100*time.Millisecond)
+	// Use up the first window so every following request is queued.
+	for i := 0; i < 10; i++ {
+		rl.Wait()
+	}
+
+	var mu sync.Mutex
+	var served []string
+	var wg sync.WaitGroup
+	enqueue := func(job string, n int) {
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := rl.WaitContext(WithJob(context.Background(), job)); err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				served = append(served, job)
+				mu.Unlock()
+			}()
+		}
+	}
+	enqueue("busy", 8)
+	time.Sleep(10 * time.Millisecond) // Let the busy job queue up first.
+	enqueue("quiet", 2)
+	wg.Wait()
+
+	// The quiet job's two requests must not wait behind all of the busy job's.
+	mu.Lock()
+	defer mu.Unlock()
+	lastQuiet := -1
+	for i, job := range served {
+		if job == "quiet" {
+			lastQuiet = i
+		}
+	}
+	if lastQuiet < 0 || lastQuiet > 4 {
+		t.Fatalf("quiet job served at the end of %v", served)
+	}
+}
+
+func TestForRequestSharesAccountLimiter(t *testing.T) {
+	bearer, _ := http.NewRequest(http.MethodGet, "https://oauth.reddit.com/api/v1/me", nil)
+	bearer.Header.Set("Authorization", "bearer shared-token")
+	if ForRequest(bearer) != ForAccount("shared-token") {
+		t.Fatal("bearer request does not use its token's limiter")
+	}
+
+	cookie, _ := http.NewRequest(http.MethodGet, "https://www.reddit.com/api/me.json", nil)
+	cookie.Header.Set("Cookie", "token_v2=other")
+	if ForRequest(cookie) != ForAccount("token_v2=other") {
+		t.Fatal("cookie request does not use its cookie's limiter")
+	}
+	if ForRequest(cookie) == ForRequest(bearer) {
+		t.Fatal("different accounts share a limiter")
+	}
+}
@@ -0,0 +1,85 @@
+package ratelimiter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+)
+
+// idleLimiterTTL is how long an account's limiter is kept after its last request.
+// It is well past Reddit's rate limit window, so an evicted limiter has nothing left to remember.
+const idleLimiterTTL = time.Hour
+
+// registry holds one limiter per account, so concurrent jobs and UI requests for the same account share its budget.
+// Accounts are keyed by a hash of their credential; the credentials themselves are not retained.
+var registry = struct {
+	mu       sync.Mutex
+	limiters map[string]*RateLimiter
+}{limiters: make(map[string]*RateLimiter)}
+
+// ForAccount returns the process-wide limiter of the account that credential (an OAuth token or a cookie header)
+// authenticates as, creating it on first use. An empty credential shares one limiter for unauthenticated requests.
+func ForAccount(credential string) *RateLimiter {
+	key := ""
+	if credential != "" {
+		sum := sha256.Sum256([]byte(credential))
+		key = hex.EncodeToString(sum[:])
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	now := time.Now()
+	for k, rl := range registry.limiters {
+		if k != key && rl.idleSince(now) > idleLimiterTTL {
+			delete(registry.limiters, k)
+		}
+	}
+	rl, ok := registry.limiters[key]
+	if !ok {
+		rl = NewRateLimiter(config.MaxTokensPerInterval, config.RateLimitInterval)
+		registry.limiters[key] = rl
+	}
+	return rl
+}
+
+// ForRequest returns the limiter of the account req authenticates as, from its Authorization or Cookie header.
+// reddit.Client uses it to pick the budget each request draws from.
+func ForRequest(req *http.Request) *RateLimiter {
+	if authz := req.Header.Get("Authorization"); authz != "" {
+		if scheme, token, ok := strings.Cut(authz, " "); ok && strings.EqualFold(scheme, "bearer") {
+			return ForAccount(token)
+		}
+		return ForAccount(authz)
+	}
+	return ForAccount(req.Header.Get("Cookie"))
+}
+
+// idleSince returns how long rl has gone without requests, or 0 while requests are queued.
+func (rl *RateLimiter) idleSince(now time.Time) time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if len(rl.order) > 0 {
+		return 0
+	}
+	return now.Sub(rl.lastUsed)
+}
+
+type jobKey struct{}
+
+// WithJob returns a copy of ctx whose rate limited requests are scheduled as part of the given job.
+// Limiters serve jobs round-robin; requests made without a job share one unnamed slot.
+func WithJob(ctx context.Context, jobID string) context.Context {
+	return context.WithValue(ctx, jobKey{}, jobID)
+}
+
+// jobFromContext returns the job ID carried by ctx, or "" if there is none.
+func jobFromContext(ctx context.Context) string {
+	jobID, _ := ctx.Value(jobKey{}).(string)
+	return jobID
+}
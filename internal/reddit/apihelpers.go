@@ -1,10 +1,9 @@
 package reddit
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-
-	"io"
 	"net/http"
 
 	"github.com/nileshnk/reddit-migrate/internal/config"
@@ -17,6 +16,7 @@ import (
 func fetchAllNames(baseAPIURL, token string, isSubredditContext bool) (types.RedditNameType, error) {
 	var result types.RedditNameType
 	lastFullName := "" // For "after" parameter in pagination.
+	client := DefaultClient()
 
 	config.DebugLogger.Printf("Starting to fetch all names from URL: %s (isSubredditContext: %t)", baseAPIURL, isSubredditContext)
 
@@ -24,33 +24,22 @@ func fetchAllNames(baseAPIURL, token string, isSubredditContext bool) (types.Red
 		paginatedURL := fmt.Sprintf("%s?limit=100&after=%s", baseAPIURL, lastFullName)
 		config.DebugLogger.Printf("Fetching page %d from %s", i+1, paginatedURL)
 
-		req, err := http.NewRequest(http.MethodGet, paginatedURL, nil)
+		req, err := client.NewRequest(context.Background(), http.MethodGet, paginatedURL, token, nil)
 		if err != nil {
 			return result, fmt.Errorf("error creating request for %s: %w", paginatedURL, err)
 		}
-		req.Header = http.Header{
-			"Authorization": {"Bearer " + token},
-			"User-Agent":    {config.UserAgent},
-		}
 
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := client.Do(req)
 		if err != nil {
 			return result, fmt.Errorf("error fetching data from %s: %w", paginatedURL, err)
 		}
+		bodyBytes := resp.Body
 
 		if resp.StatusCode != http.StatusOK {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			resp.Body.Close()
 			config.ErrorLogger.Printf("Failed to fetch names from %s. Status: %d, Body: %s", paginatedURL, resp.StatusCode, string(bodyBytes))
 			return result, fmt.Errorf("failed to fetch data from %s, status code: %d", paginatedURL, resp.StatusCode)
 		}
 
-		bodyBytes, err := io.ReadAll(resp.Body)
-		resp.Body.Close() // Close body immediately after reading.
-		if err != nil {
-			return result, fmt.Errorf("error reading response body from %s: %w", paginatedURL, err)
-		}
-
 		var listing types.FullNameListType
 		if err := json.Unmarshal(bodyBytes, &listing); err != nil {
 			config.ErrorLogger.Printf("Error unmarshalling response from %s: %v. Body: %s", paginatedURL, err, string(bodyBytes))
@@ -116,3 +105,9 @@ func countItems(children []types.FullListChild, predicate func(child types.FullL
 	}
 	return count
 }
+
+// MissingScopeMessage explains a 403 from an endpoint that needs the given OAuth scope. Accounts connected before the
+// tool requested the scope hold tokens without it until they are connected again.
+func MissingScopeMessage(scope string) string {
+	return fmt.Sprintf("missing the %q OAuth scope; reconnect the account to grant it", scope)
+}
@@ -0,0 +1,140 @@
+package reddit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/progress"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+)
+
+// FetchBlockedUsers fetches the usernames blocked by the authenticated user.
+// /prefs/blocked returns the whole list at once, so no pagination is needed.
+func FetchBlockedUsers(token string) ([]string, error) {
+	config.InfoLogger.Println("Fetching blocked users.")
+	client := DefaultClient()
+	apiURL := client.APIURL("/prefs/blocked")
+
+	req, err := client.NewRequest(context.Background(), http.MethodGet, apiURL, token, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request for %s: %w", apiURL, err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching blocked users from %s: %w", apiURL, err)
+	}
+	bodyBytes := resp.Body
+	if resp.StatusCode != http.StatusOK {
+		config.ErrorLogger.Printf("Failed to fetch blocked users from %s. Status: %d, Body: %s", apiURL, resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("failed to fetch blocked users from %s, status code: %d", apiURL, resp.StatusCode)
+	}
+
+	var userList struct {
+		Kind string `json:"kind"`
+		Data struct {
+			Children []struct {
+				Name string `json:"name"`
+				ID   string `json:"id"`
+			} `json:"children"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(bodyBytes, &userList); err != nil {
+		config.ErrorLogger.Printf("Error unmarshalling blocked users response from %s: %v. Body: %s", apiURL, err, string(bodyBytes))
+		return nil, fmt.Errorf("error unmarshalling blocked users response from %s: %w", apiURL, err)
+	}
+
+	usernames := make([]string, 0, len(userList.Data.Children))
+	for _, child := range userList.Data.Children {
+		if child.Name != "" {
+			usernames = append(usernames, child.Name)
+		}
+	}
+	config.InfoLogger.Printf("Fetched %d blocked users.", len(usernames))
+	return usernames, nil
+}
+
+// BlockUsers blocks each of the given users on the authenticated account, one request per user.
+// Results are reported like ManageFollowedUsers: one UserEvent per user and the failed usernames in FailedSubreddits.
+// Blocking a user that no longer exists (deleted or suspended) fails for that user only. A 403 means the token lacks
+// the account scope, so the remaining users are failed without further requests.
+func BlockUsers(ctx context.Context, token string, usernames []string) types.ManageSubredditResponseType {
+	var finalResponse types.ManageSubredditResponseType
+	if len(usernames) == 0 {
+		config.DebugLogger.Println("No users to block.")
+		return finalResponse
+	}
+
+	config.InfoLogger.Printf("Blocking %d users.", len(usernames))
+	client := DefaultClient()
+	apiURL := client.APIURL("/api/block_user")
+	var failedUsernames []string
+
+	for i, username := range usernames {
+		if ctx.Err() != nil {
+			config.ErrorLogger.Printf("Context cancelled while blocking users. Skipping %d remaining users.", len(usernames)-i)
+			failedUsernames = append(failedUsernames, usernames[i:]...)
+			finalResponse.Error = true
+			break
+		}
+
+		userEvent := types.ProgressEvent{
+			Type:      types.UserEvent,
+			Action:    string(types.BlockAction),
+			Item:      username,
+			Completed: i + 1,
+			Total:     len(usernames),
+		}
+
+		req, err := client.NewFormRequest(ctx, http.MethodPost, apiURL, token, url.Values{"name": {username}})
+		if err != nil {
+			config.ErrorLogger.Printf("Error creating request to block user %s: %v", username, err)
+			failedUsernames = append(failedUsernames, username)
+			userEvent.Error = err.Error()
+			progress.Report(ctx, userEvent)
+			continue
+		}
+		config.DebugLogger.Printf("Sending block request for user: %s", username)
+		resp, err := client.Do(req)
+		if err != nil {
+			config.ErrorLogger.Printf("Error sending block request for user %s: %v", username, err)
+			failedUsernames = append(failedUsernames, username)
+			userEvent.Error = err.Error()
+			progress.Report(ctx, userEvent)
+			continue
+		}
+		if resp.StatusCode == http.StatusForbidden {
+			config.ErrorLogger.Printf("Blocking user %s was refused (status 403). Skipping %d remaining users.", username, len(usernames)-i-1)
+			failedUsernames = append(failedUsernames, usernames[i:]...)
+			finalResponse.Error = true
+			finalResponse.StatusCode = resp.StatusCode
+			userEvent.Error = MissingScopeMessage("account")
+			progress.Report(ctx, userEvent)
+			break
+		}
+		if resp.StatusCode != http.StatusOK {
+			config.ErrorLogger.Printf("Failed to block user %s (status %d): %s", username, resp.StatusCode, string(resp.Body))
+			failedUsernames = append(failedUsernames, username)
+			finalResponse.Error = true
+			finalResponse.StatusCode = resp.StatusCode
+			userEvent.Error = fmt.Sprintf("status %d", resp.StatusCode)
+		} else {
+			config.DebugLogger.Printf("Successfully blocked user %s.", username)
+			finalResponse.SuccessCount++
+			userEvent.Success = true
+		}
+		progress.Report(ctx, userEvent)
+	}
+
+	finalResponse.FailedCount = len(failedUsernames)
+	finalResponse.FailedSubreddits = failedUsernames // Re-using FailedSubreddits field for failed usernames, as in ManageFollowedUsers.
+	if finalResponse.FailedCount > 0 {
+		finalResponse.Error = true
+	}
+	config.InfoLogger.Printf("Finished blocking users. Success: %d, Failed: %d.", finalResponse.SuccessCount, finalResponse.FailedCount)
+	return finalResponse
+}
@@ -0,0 +1,223 @@
+package reddit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/ratelimiter"
+)
+
+// Client sends requests to Reddit. It owns the base URLs, the user agent, per-request timeouts, retries of transient
+// failures and rate limiting, so every call to Reddit goes through one place and can be pointed at a local stand-in.
+type Client struct {
+	OAuthURL   string        // Base URL of the token-authenticated API, normally https://oauth.reddit.com.
+	BaseURL    string        // Base URL of www.reddit.com: authorization, token exchange and cookie sessions.
+	UserAgent  string        // Sent unless the request already has a User-Agent.
+	HTTPClient *http.Client  // Sends each attempt. Its Timeout covers one attempt, not the rate limit wait before it.
+	MaxRetries int           // Further attempts after a network error or a 502, 503 or 504 response.
+	RetryDelay time.Duration // Wait before the first retry; doubled for each further one.
+}
+
+// Response is a Reddit response whose body has already been read and closed.
+type Response struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+}
+
+// NewClient creates a Client from the loaded configuration.
+func NewClient() *Client {
+	return &Client{
+		OAuthURL:   config.RedditOauthURL,
+		BaseURL:    config.RedditBaseURL,
+		UserAgent:  config.UserAgent,
+		HTTPClient: &http.Client{Timeout: config.DefaultAPITimeout},
+		MaxRetries: config.MaxAPIRetries,
+		RetryDelay: time.Second,
+	}
+}
+
+var (
+	defaultClientMu sync.Mutex
+	defaultClient   *Client
+)
+
+// DefaultClient returns the client used by the package-level functions and by other packages that talk to Reddit.
+// It is created from the configuration on first use, after config.LoadConfig has run.
+func DefaultClient() *Client {
+	defaultClientMu.Lock()
+	defer defaultClientMu.Unlock()
+	if defaultClient == nil {
+		defaultClient = NewClient()
+	}
+	return defaultClient
+}
+
+// SetDefaultClient replaces the client returned by DefaultClient, e.g. to point the tool at a local stand-in.
+func SetDefaultClient(c *Client) {
+	defaultClientMu.Lock()
+	defer defaultClientMu.Unlock()
+	defaultClient = c
+}
+
+// APIURL returns the URL of path on the token-authenticated API. path starts with a slash.
+func (c *Client) APIURL(path string) string {
+	return strings.TrimRight(c.OAuthURL, "/") + path
+}
+
+// WebURL returns the URL of path on www.reddit.com. path starts with a slash.
+func (c *Client) WebURL(path string) string {
+	return strings.TrimRight(c.BaseURL, "/") + path
+}
+
+// NewRequest creates a request for apiURL authenticated with the given bearer token.
+// An empty token sends no Authorization header, for callers that authenticate some other way.
+func (c *Client) NewRequest(ctx context.Context, method, apiURL, token string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, apiURL, body)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	return req, nil
+}
+
+// NewFormRequest creates a request like NewRequest whose body is the url-encoded form.
+func (c *Client) NewFormRequest(ctx context.Context, method, apiURL, token string, form url.Values) (*http.Request, error) {
+	req, err := c.NewRequest(ctx, method, apiURL, token, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+// NewJSONRequest creates a request like NewRequest whose body is v encoded as JSON.
+func (c *Client) NewJSONRequest(ctx context.Context, method, apiURL, token string, v interface{}) (*http.Request, error) {
+	bodyBytes, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling request body: %w", err)
+	}
+	req, err := c.NewRequest(ctx, method, apiURL, token, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// Do sends req and reads the whole response. Every attempt first waits on the rate limit budget of the account req
+// authenticates as and reports the response back to it. Network errors and 502, 503 and 504 responses are retried
+// up to MaxRetries times if the body can be replayed; any other status, including 429, is returned to the caller.
+// If a TokenSource is registered for req's bearer token, each attempt sends the source's current token, and a 401
+// response is retried once after refreshing it.
+func (c *Client) Do(req *http.Request) (*Response, error) {
+	ctx := req.Context()
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	limiter := ratelimiter.ForRequest(req)
+	tokens := tokenSourceFor(req)
+	canRetry := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	delay := c.RetryDelay
+	retries, refreshed := 0, false
+
+	// backoff waits before retrying a failed attempt; it returns false if ctx ended first.
+	backoff := func() bool {
+		retries++
+		config.DebugLogger.Printf("Retrying %s %s in %v (attempt %d of %d).", req.Method, req.URL.Redacted(), delay, retries+1, c.MaxRetries+1)
+		if !sleepContext(ctx, delay) {
+			return false
+		}
+		delay *= 2
+		return true
+	}
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("error replaying request body: %w", err)
+			}
+			req.Body = body
+		}
+		lastAttempt := !canRetry || retries >= c.MaxRetries
+
+		var sentToken string
+		if tokens != nil {
+			token, err := tokens.Token(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("error renewing access token: %w", err)
+			}
+			sentToken = token
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		if err := limiter.WaitContext(ctx); err != nil {
+			return nil, err
+		}
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			if lastAttempt || ctx.Err() != nil {
+				return nil, err
+			}
+			config.ErrorLogger.Printf("Request %s %s failed: %v", req.Method, req.URL.Redacted(), err)
+			if !backoff() {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		limiter.Observe(resp.Header, resp.StatusCode)
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			if lastAttempt || ctx.Err() != nil {
+				return nil, fmt.Errorf("error reading response body: %w", err)
+			}
+			config.ErrorLogger.Printf("Reading the response of %s %s failed: %v", req.Method, req.URL.Redacted(), err)
+			if !backoff() {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		if resp.StatusCode == http.StatusUnauthorized && tokens != nil && canRetry && !refreshed {
+			refreshed = true
+			config.InfoLogger.Printf("Request %s %s was rejected with 401. Refreshing the access token and retrying.", req.Method, req.URL.Redacted())
+			if _, err := tokens.Refresh(ctx, sentToken); err != nil {
+				config.ErrorLogger.Printf("Could not refresh the access token: %v", err)
+			} else {
+				continue
+			}
+		}
+		if !lastAttempt && isTransientStatus(resp.StatusCode) {
+			config.ErrorLogger.Printf("Request %s %s returned status %d.", req.Method, req.URL.Redacted(), resp.StatusCode)
+			if !backoff() {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		return &Response{StatusCode: resp.StatusCode, Status: resp.Status, Header: resp.Header, Body: body}, nil
+	}
+}
+
+// isTransientStatus reports whether a status means Reddit's front end could not reach the API, so retrying may help.
+func isTransientStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package reddit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+)
+
+// ExportAccount reads the data an AccountArchive holds from the account and returns it as a versioned archive.
+// Votes, hidden posts, blocked users and preferences are not exported.
+// The archive is complete or not returned at all: any fetch error aborts the export.
+func ExportAccount(token, username string) (types.AccountArchive, error) {
+	config.InfoLogger.Printf("Exporting account %s.", username)
+	archive := types.AccountArchive{
+		SchemaVersion: types.ArchiveSchemaVersion,
+		ExportedAt:    time.Now().UTC(),
+		Username:      username,
+	}
+
+	subreddits, err := FetchSubredditsWithDetails(token)
+	if err != nil {
+		return types.AccountArchive{}, fmt.Errorf("failed to export subreddits: %w", err)
+	}
+	archive.Subreddits = subreddits
+
+	names, err := FetchSubredditFullNames(token)
+	if err != nil {
+		return types.AccountArchive{}, fmt.Errorf("failed to export followed users: %w", err)
+	}
+	archive.FollowedUsers = names.UserDisplayNameList
+
+	savedPosts, savedComments, err := FetchSavedItemsWithDetails(token, username)
+	if err != nil {
+		return types.AccountArchive{}, fmt.Errorf("failed to export saved posts: %w", err)
+	}
+	archive.SavedPosts = savedPosts
+	archive.SavedComments = savedComments
+
+	multis, err := FetchMultireddits(token)
+	if err != nil {
+		return types.AccountArchive{}, fmt.Errorf("failed to export multireddits: %w", err)
+	}
+	archive.Multireddits = multis
+
+	config.InfoLogger.Printf("Exported %d subreddits, %d followed users, %d saved posts, %d saved comments and %d custom feeds of %s.",
+		len(archive.Subreddits), len(archive.FollowedUsers), len(archive.SavedPosts), len(archive.SavedComments), len(archive.Multireddits), username)
+	return archive, nil
+}
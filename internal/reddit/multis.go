@@ -0,0 +1,206 @@
+package reddit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/progress"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+)
+
+// FetchMultireddits fetches the multireddits (custom feeds) owned by the authenticated user.
+// /api/multi/mine is not paginated; Reddit caps the number of feeds per account.
+func FetchMultireddits(token string) ([]types.MultiredditInfo, error) {
+	config.InfoLogger.Println("Fetching multireddits.")
+	client := DefaultClient()
+	apiURL := client.APIURL("/api/multi/mine?expand_srs=false")
+
+	req, err := client.NewRequest(context.Background(), http.MethodGet, apiURL, token, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request for %s: %w", apiURL, err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching multireddits from %s: %w", apiURL, err)
+	}
+	bodyBytes := resp.Body
+	if resp.StatusCode != http.StatusOK {
+		config.ErrorLogger.Printf("Failed to fetch multireddits from %s. Status: %d, Body: %s", apiURL, resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("failed to fetch multireddits from %s, status code: %d", apiURL, resp.StatusCode)
+	}
+
+	var children []types.DetailedMultiredditData
+	if err := json.Unmarshal(bodyBytes, &children); err != nil {
+		config.ErrorLogger.Printf("Error unmarshalling multireddits response from %s: %v. Body: %s", apiURL, err, string(bodyBytes))
+		return nil, fmt.Errorf("error unmarshalling multireddits response from %s: %w", apiURL, err)
+	}
+
+	multis := make([]types.MultiredditInfo, 0, len(children))
+	for _, child := range children {
+		if child.Kind != "LabeledMulti" {
+			continue
+		}
+		subreddits := make([]string, 0, len(child.Data.Subreddits))
+		for _, sr := range child.Data.Subreddits {
+			subreddits = append(subreddits, sr.Name)
+		}
+		multis = append(multis, types.MultiredditInfo{
+			Name:            child.Data.Name,
+			DisplayName:     child.Data.DisplayName,
+			Description:     child.Data.DescriptionMD,
+			Visibility:      child.Data.Visibility,
+			Subreddits:      subreddits,
+			Path:            child.Data.Path,
+			IconURL:         child.Data.IconURL,
+			KeyColor:        child.Data.KeyColor,
+			WeightingScheme: child.Data.WeightingScheme,
+		})
+	}
+
+	config.InfoLogger.Printf("Fetched %d multireddits.", len(multis))
+	return multis, nil
+}
+
+// CopyMultireddits recreates multireddits on the account of the given user.
+// Feeds in toCreate are created with their description, visibility and member subreddits.
+// Feeds in toMerge already exist on the account under the same name; only their listed subreddits are added,
+// so toMerge entries should carry just the members the existing feed is missing.
+// Each feed is reported as one MultiredditEvent and fails as a whole if any of its requests fail.
+func CopyMultireddits(ctx context.Context, token, username string, toCreate, toMerge []types.MultiredditInfo) types.ManageMultiredditResponseType {
+	var finalResponse types.ManageMultiredditResponseType
+	if len(toCreate)+len(toMerge) == 0 {
+		config.DebugLogger.Println("No multireddits to copy.")
+		return finalResponse
+	}
+	config.InfoLogger.Printf("Copying multireddits to %s: %d to create, %d to merge.", username, len(toCreate), len(toMerge))
+
+	completed := 0
+	copyOne := func(multi types.MultiredditInfo, merge bool) {
+		var err error
+		if merge {
+			err = addMultiredditSubreddits(ctx, token, username, multi.Name, multi.Subreddits)
+		} else {
+			err = createMultireddit(ctx, token, username, multi)
+		}
+
+		completed++
+		event := types.ProgressEvent{
+			Type:      types.MultiredditEvent,
+			Action:    string(types.SubscribeAction),
+			Item:      multi.Name,
+			Success:   err == nil,
+			Completed: completed,
+			Total:     len(toCreate) + len(toMerge),
+		}
+		switch {
+		case err != nil:
+			config.ErrorLogger.Printf("Failed to copy multireddit %s to %s: %v", multi.Name, username, err)
+			finalResponse.FailedCount++
+			finalResponse.FailedMultireddits = append(finalResponse.FailedMultireddits, multi.Name)
+			finalResponse.Error = true
+			event.Error = err.Error()
+		case merge:
+			finalResponse.MergedCount++
+		default:
+			finalResponse.CreatedCount++
+		}
+		progress.Report(ctx, event)
+	}
+
+	for i, multi := range toCreate {
+		if ctx.Err() != nil {
+			config.ErrorLogger.Printf("Context cancelled while copying multireddits. Skipping %d remaining feeds.", len(toCreate)-i+len(toMerge))
+			finalResponse.Error = true
+			return finalResponse
+		}
+		copyOne(multi, false)
+	}
+	for i, multi := range toMerge {
+		if ctx.Err() != nil {
+			config.ErrorLogger.Printf("Context cancelled while copying multireddits. Skipping %d remaining feeds.", len(toMerge)-i)
+			finalResponse.Error = true
+			return finalResponse
+		}
+		copyOne(multi, true)
+	}
+
+	config.InfoLogger.Printf("Copied multireddits to %s: %d created, %d merged, %d failed.",
+		username, finalResponse.CreatedCount, finalResponse.MergedCount, finalResponse.FailedCount)
+	return finalResponse
+}
+
+// createMultireddit creates a multireddit named multi.Name under /user/{username}/m/.
+func createMultireddit(ctx context.Context, token, username string, multi types.MultiredditInfo) error {
+	type member struct {
+		Name string `json:"name"`
+	}
+	model := struct {
+		DisplayName     string   `json:"display_name"`
+		DescriptionMD   string   `json:"description_md"`
+		Visibility      string   `json:"visibility"`
+		Subreddits      []member `json:"subreddits"`
+		KeyColor        string   `json:"key_color,omitempty"`
+		WeightingScheme string   `json:"weighting_scheme,omitempty"`
+	}{
+		DisplayName:     multi.DisplayName,
+		DescriptionMD:   multi.Description,
+		Visibility:      multi.Visibility,
+		Subreddits:      make([]member, 0, len(multi.Subreddits)),
+		KeyColor:        multi.KeyColor,
+		WeightingScheme: multi.WeightingScheme,
+	}
+	if model.DisplayName == "" {
+		model.DisplayName = multi.Name
+	}
+	for _, sr := range multi.Subreddits {
+		model.Subreddits = append(model.Subreddits, member{Name: sr})
+	}
+
+	apiURL := DefaultClient().APIURL(fmt.Sprintf("/api/multi/user/%s/m/%s", url.PathEscape(username), url.PathEscape(multi.Name)))
+	return sendMultiModel(ctx, token, http.MethodPost, apiURL, model)
+}
+
+// addMultiredditSubreddits adds subreddits to an existing multireddit one at a time.
+// It stops at the first failure; subreddits added before it stay in the feed.
+func addMultiredditSubreddits(ctx context.Context, token, username, multiName string, subreddits []string) error {
+	for _, sr := range subreddits {
+		apiURL := DefaultClient().APIURL(fmt.Sprintf("/api/multi/user/%s/m/%s/r/%s",
+			url.PathEscape(username), url.PathEscape(multiName), url.PathEscape(sr)))
+		if err := sendMultiModel(ctx, token, http.MethodPut, apiURL, map[string]string{"name": sr}); err != nil {
+			return fmt.Errorf("adding r/%s: %w", sr, err)
+		}
+	}
+	return nil
+}
+
+// sendMultiModel sends model as the JSON-encoded "model" form field, which is how the multireddit endpoints take their payload.
+func sendMultiModel(ctx context.Context, token, method, apiURL string, model interface{}) error {
+	modelBytes, err := json.Marshal(model)
+	if err != nil {
+		return fmt.Errorf("error marshalling multireddit model: %w", err)
+	}
+	client := DefaultClient()
+	req, err := client.NewFormRequest(ctx, method, apiURL, token, url.Values{"model": {string(modelBytes)}})
+	if err != nil {
+		return fmt.Errorf("error creating request for %s: %w", apiURL, err)
+	}
+
+	config.DebugLogger.Printf("Sending %s %s", method, apiURL)
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request to %s: %w", apiURL, err)
+	}
+	bodyBytes := resp.Body
+
+	// Creating returns 201 Created; adding a member returns 200 OK or 201 Created depending on whether it was already there.
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		config.DebugLogger.Printf("Multireddit request to %s failed (status %d): %s", apiURL, resp.StatusCode, string(bodyBytes))
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -4,35 +4,38 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/progress"
 	"github.com/nileshnk/reddit-migrate/internal/ratelimiter"
 	"github.com/nileshnk/reddit-migrate/internal/types"
 	"github.com/nileshnk/reddit-migrate/internal/worker"
 )
 
+// init installs default loggers so the package is usable before main configures logging.
+// Configuration itself is loaded by main through config.LoadConfig once logging is set up.
 func init() {
 	config.InfoLogger = log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime|log.Lmicroseconds)
 	config.ErrorLogger = log.New(os.Stderr, "ERROR: ", log.Ldate|log.Ltime|log.Lmicroseconds)
 	config.DebugLogger = log.New(os.Stdout, "DEBUG: ", log.Ldate|log.Ltime|log.Lmicroseconds)
-	config.LoadConfig() // This should be called from main, and config values accessed here.
 }
 
 // ManageSavedPosts coordinates the saving or unsaving of posts concurrently using worker goroutines.
-// It employs a rate limiter and a mechanism to pause/resume workers if API rate limits are hit.
+// The workers draw from the account's shared rate limit budget, and a mechanism pauses them if API rate limits are hit.
+// ctx: Cancelling it stops workers from picking up further posts; unprocessed posts are not counted.
 // token: The OAuth token for API authentication.
 // postIDs: A slice of post full names (e.g., "t3_xxxxx") to be processed.
-// actionType: The action to perform (SaveAction or UnsaveAction from migration package).
+// actionType: The action to perform (any PostActionType, e.g. SaveAction, UpvoteAction or HideAction).
 // concurrency: The number of worker goroutines to use.
 // Returns ManagePostResponseType from migration package
-func ManageSavedPosts(token string, postIDs []string, actionType types.PostActionType, concurrency int) types.ManagePostResponseType {
+func ManageSavedPosts(parentCtx context.Context, token string, postIDs []string, actionType types.PostActionType, concurrency int) types.ManagePostResponseType {
 	numPosts := len(postIDs)
 	config.InfoLogger.Printf("ManageSavedPosts: Starting to %s %d posts. Concurrency: %d.", actionType, numPosts, concurrency)
 
@@ -45,33 +48,27 @@ func ManageSavedPosts(token string, postIDs []string, actionType types.PostActio
 		return types.ManagePostResponseType{SuccessCount: 0, FailedCount: 0}
 	}
 
-	rl := ratelimiter.NewRateLimiter(config.MaxTokensPerInterval, config.RateLimitInterval)
+	rl := ratelimiter.ForAccount(token)
+	client := DefaultClient()
+	process := func(ctx context.Context, postID string) worker.Result {
+		return client.postAction(ctx, token, postID, actionType)
+	}
 
 	jobs := make(chan string, numPosts)
 	results := make(chan worker.Result, numPosts)
 	rateLimitControl := make(chan bool)
 
 	var wg sync.WaitGroup
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(parentCtx)
 	defer cancel()
 
 	go func() {
 		config.DebugLogger.Println("ManageSavedPosts: Rate limit controller goroutine started.")
 
-		rateLimitSleepInterval := config.RateLimitSleepInterval
 		for shouldPause := range rateLimitControl {
 			if shouldPause {
 				config.InfoLogger.Println("ManageSavedPosts: Rate limit controller received pause signal from a worker.")
-				rl.Pause()
-				config.InfoLogger.Printf("ManageSavedPosts: Rate limiter paused. Sleeping for %v before testing API.", rateLimitSleepInterval)
-				time.Sleep(rateLimitSleepInterval)
-
-				for !TestRedditAPI(token, "t3_testdummy") {
-					config.ErrorLogger.Printf("ManageSavedPosts: Test request failed. Rate limit likely still active. Sleeping again for %v.", rateLimitSleepInterval)
-					time.Sleep(rateLimitSleepInterval)
-				}
-				config.InfoLogger.Println("ManageSavedPosts: Test request successful. Resuming rate limiter.")
-				rl.Resume()
+				waitOutRateLimit(ctx, actionType, rl)
 			} else {
 				config.DebugLogger.Println("ManageSavedPosts: Rate limit controller received 'false' signal (currently unused).")
 			}
@@ -85,7 +82,7 @@ func ManageSavedPosts(token string, postIDs []string, actionType types.PostActio
 		go func(workerID int) {
 			defer wg.Done()
 			config.DebugLogger.Printf("Worker %d: Started for %s operation.", workerID, actionType)
-			worker.PostWorker(ctx, token, actionType, rl, jobs, results, rateLimitControl, workerID)
+			worker.PostWorker(ctx, process, jobs, results, rateLimitControl, workerID)
 			config.DebugLogger.Printf("Worker %d: Finished processing jobs.", workerID)
 		}(i)
 	}
@@ -111,18 +108,193 @@ func ManageSavedPosts(token string, postIDs []string, actionType types.PostActio
 
 	successCount := 0
 	failedCount := 0
+	var failedPosts []string
 	config.InfoLogger.Println("ManageSavedPosts: Collecting results...")
 	for result := range results {
+		postEvent := types.ProgressEvent{
+			Type:    types.PostEvent,
+			Action:  string(actionType),
+			Item:    result.PostID,
+			Success: result.Success,
+			Total:   numPosts,
+		}
 		if result.Success {
 			successCount++
 		} else {
 			failedCount++
+			failedPosts = append(failedPosts, result.PostID)
 			config.ErrorLogger.Printf("ManageSavedPosts: Failed to %s post %s: %v", actionType, result.PostID, result.Error)
+			if result.Error != nil {
+				postEvent.Error = result.Error.Error()
+			}
 		}
+		postEvent.Completed = successCount + failedCount
+		progress.Report(parentCtx, postEvent)
 	}
 
 	config.InfoLogger.Printf("ManageSavedPosts: Finished %s %d posts. Success: %d, Failed: %d.", actionType, numPosts, successCount, failedCount)
-	return types.ManagePostResponseType{SuccessCount: successCount, FailedCount: failedCount} // TODO: Use actual type
+	return types.ManagePostResponseType{SuccessCount: successCount, FailedCount: failedCount, FailedPosts: failedPosts}
+}
+
+// waitOutRateLimit sleeps until rl's rate limit window resets, reporting the pause as progress events.
+// rl already knows the reset time from the rejected response's headers, so no test requests are needed.
+// It returns early when ctx is cancelled.
+func waitOutRateLimit(ctx context.Context, actionType types.PostActionType, rl *ratelimiter.RateLimiter) {
+	delay := rl.Delay()
+	config.InfoLogger.Printf("Rate limit hit. Waiting %v for the window to reset.", delay.Round(time.Second))
+	progress.Report(ctx, types.ProgressEvent{
+		Type:    types.RateLimitPauseEvent,
+		Action:  string(actionType),
+		Message: fmt.Sprintf("Rate limit hit, waiting %v for the window to reset", delay.Round(time.Second)),
+	})
+	if !sleepContext(ctx, delay) {
+		return
+	}
+	progress.Report(ctx, types.ProgressEvent{
+		Type:    types.RateLimitResumeEvent,
+		Action:  string(actionType),
+		Success: true,
+	})
+}
+
+// maxOrderedRateLimitRetries bounds how often ManageSavedPostsInOrder retries one post after a rate limit pause.
+const maxOrderedRateLimitRetries = 5
+
+// ManageSavedPostsInOrder performs actionType on the posts strictly one after another, in the given order.
+// Reddit orders saved items by save time, so saving oldest first through this function reproduces the original order,
+// which the concurrent workers of ManageSavedPosts cannot guarantee. A post that hits the rate limit is retried in place
+// after the pause instead of being skipped, so later posts are never saved before it. It is slower than ManageSavedPosts
+// and only worth using where the order is visible.
+func ManageSavedPostsInOrder(ctx context.Context, token string, postIDs []string, actionType types.PostActionType) types.ManagePostResponseType {
+	numPosts := len(postIDs)
+	if numPosts == 0 {
+		config.InfoLogger.Printf("ManageSavedPostsInOrder: No posts to %s. Operation skipped.", actionType)
+		return types.ManagePostResponseType{}
+	}
+	config.InfoLogger.Printf("ManageSavedPostsInOrder: Starting to %s %d posts in order.", actionType, numPosts)
+
+	rl := ratelimiter.ForAccount(token)
+	client := DefaultClient()
+	var response types.ManagePostResponseType
+	for i, postID := range postIDs {
+		if ctx.Err() != nil {
+			config.ErrorLogger.Printf("ManageSavedPostsInOrder: Context cancelled. %d posts not processed.", numPosts-i)
+			break
+		}
+
+		var result worker.Result
+		for attempt := 0; ; attempt++ {
+			result = client.postAction(ctx, token, postID, actionType)
+			if !result.RateLimited || attempt == maxOrderedRateLimitRetries || ctx.Err() != nil {
+				break
+			}
+			waitOutRateLimit(ctx, actionType, rl)
+		}
+
+		postEvent := types.ProgressEvent{
+			Type:      types.PostEvent,
+			Action:    string(actionType),
+			Item:      postID,
+			Success:   result.Success,
+			Completed: i + 1,
+			Total:     numPosts,
+		}
+		if result.Success {
+			response.SuccessCount++
+		} else {
+			response.FailedCount++
+			response.FailedPosts = append(response.FailedPosts, postID)
+			config.ErrorLogger.Printf("ManageSavedPostsInOrder: Failed to %s post %s: %v", actionType, postID, result.Error)
+			if result.Error != nil {
+				postEvent.Error = result.Error.Error()
+			}
+		}
+		progress.Report(ctx, postEvent)
+	}
+
+	config.InfoLogger.Printf("ManageSavedPostsInOrder: Finished %s %d posts. Success: %d, Failed: %d.", actionType, numPosts, response.SuccessCount, response.FailedCount)
+	return response
+}
+
+// postAction performs actionType on a single post. On a 429 the result has RateLimited set
+// and the caller decides when to retry.
+func (c *Client) postAction(ctx context.Context, token, postID string, actionType types.PostActionType) worker.Result {
+	path := actionPath(actionType)
+	if path == "" {
+		return worker.Result{PostID: postID, Success: false, Error: fmt.Errorf("unknown action type: %v", actionType)}
+	}
+	apiURL := c.APIURL(path)
+	config.DebugLogger.Printf("Action %s on post %s using URL %s", actionType, postID, apiURL)
+
+	form := url.Values{"id": {postID}}
+	if dir := voteDirection(actionType); dir != "" {
+		form.Set("dir", dir)
+	}
+	req, err := c.NewFormRequest(ctx, http.MethodPost, apiURL, token, form)
+	if err != nil {
+		config.ErrorLogger.Printf("Failed to create request for post %s: %v", postID, err)
+		return worker.Result{PostID: postID, Success: false, Error: err}
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		config.ErrorLogger.Printf("Failed to %s post %s: %v", actionType, postID, err)
+		return worker.Result{PostID: postID, Success: false, Error: err}
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		config.ErrorLogger.Printf("Rate limit hit (status %d) when trying to %s post %s.", resp.StatusCode, actionType, postID)
+		return worker.Result{PostID: postID, Success: false, RateLimited: true, Error: fmt.Errorf("rate limited (status %d): %s", resp.StatusCode, string(resp.Body))}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		config.ErrorLogger.Printf("Failed to %s post %s. Status: %s, Body: %s", actionType, postID, resp.Status, string(resp.Body))
+		return worker.Result{PostID: postID, Success: false, Error: fmt.Errorf("failed with status %s: %s", resp.Status, string(resp.Body))}
+	}
+
+	config.DebugLogger.Printf("Successfully %s post %s. Status: %s", actionType, postID, resp.Status)
+	return worker.Result{PostID: postID, Success: true}
+}
+
+// actionPath returns the API path for a post action, or "" for an unknown action.
+func actionPath(actionType types.PostActionType) string {
+	switch actionType {
+	case types.SaveAction:
+		return "/api/save"
+	case types.UnsaveAction:
+		return "/api/unsave"
+	case types.UpvoteAction, types.DownvoteAction:
+		return "/api/vote"
+	case types.HideAction:
+		return "/api/hide"
+	case types.UnhideAction:
+		return "/api/unhide"
+	}
+	return ""
+}
+
+// voteDirection returns the "dir" parameter of /api/vote for a vote action, or "" for other actions.
+func voteDirection(actionType types.PostActionType) string {
+	switch actionType {
+	case types.UpvoteAction:
+		return "1"
+	case types.DownvoteAction:
+		return "-1"
+	}
+	return ""
+}
+
+// sleepContext sleeps for the given duration or until ctx is cancelled.
+// It returns false if the sleep was interrupted by cancellation.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
 
 // FetchSavedPostsFullNames retrieves a list of full names for all posts saved by the user.
@@ -134,7 +306,7 @@ func FetchSavedPostsFullNames(token, username string) ([]string, error) {
 
 	config.InfoLogger.Printf("Fetching saved posts for user %s.", username)
 	// The endpoint is /user/{username}/saved.json
-	apiURL := fmt.Sprintf("https://oauth.reddit.com/user/%s/saved.json", username)
+	apiURL := DefaultClient().APIURL(fmt.Sprintf("/user/%s/saved.json", username))
 
 	nameList, err := fetchAllNames(apiURL, token, false) // false indicates not specifically for subreddits (affects u_ filtering)
 	if err != nil {
@@ -147,112 +319,146 @@ func FetchSavedPostsFullNames(token, username string) ([]string, error) {
 	return nameList.FullNamesList, nil
 }
 
-// TestRedditAPI sends a simple, non-modifying GET request to the Reddit API to check connectivity and authentication.
-// It uses the /api/v1/me endpoint which just requires a valid token.
-// targetName is not used in this version but kept for potential future use (e.g. specific resource check).
-func TestRedditAPI(token string, targetName string) bool {
-	config.DebugLogger.Printf("TestRedditAPI: Testing API with dummy target: %s", targetName) // targetName currently unused
+// FetchVotedPostsFullNames retrieves the full names of all posts the user has upvoted (UpvoteAction) or downvoted (DownvoteAction).
+// Reddit only lists the votes of the authenticated user, newest first.
+func FetchVotedPostsFullNames(token, username string, direction types.PostActionType) ([]string, error) {
+	if username == "" {
+		return nil, fmt.Errorf("username is required for fetching voted posts")
+	}
 
-	redditAPIURL := fmt.Sprintf("%s/api/v1/me", config.RedditOauthURL)
-	httpClient := http.Client{Timeout: config.TestAPITimeout}
-	req, err := http.NewRequest("GET", redditAPIURL, nil)
-	if err != nil {
-		config.ErrorLogger.Printf("TestRedditAPI: Failed to create request: %v", err)
-		return false
+	var listing string
+	switch direction {
+	case types.UpvoteAction:
+		listing = "upvoted"
+	case types.DownvoteAction:
+		listing = "downvoted"
+	default:
+		return nil, fmt.Errorf("unsupported vote direction: %s", direction)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("User-Agent", config.UserAgent)
+	config.InfoLogger.Printf("Fetching %s posts for user %s.", listing, username)
+	apiURL := DefaultClient().APIURL(fmt.Sprintf("/user/%s/%s.json", username, listing))
 
-	resp, err := httpClient.Do(req)
+	nameList, err := fetchAllNames(apiURL, token, false)
 	if err != nil {
-		config.ErrorLogger.Printf("TestRedditAPI: Request failed: %v", err)
-		return false
+		return nil, fmt.Errorf("error fetching %s posts for %s: %w", listing, username, err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusOK {
-		config.DebugLogger.Println("TestRedditAPI: API test successful (status 200 OK).")
-		return true
-	} else if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == 429 {
-		config.ErrorLogger.Printf("TestRedditAPI: API test failed due to rate limiting (status %d).", resp.StatusCode)
-		return false
-	} else {
-		config.ErrorLogger.Printf("TestRedditAPI: API test failed with status %s.", resp.Status)
-		return false
+	config.InfoLogger.Printf("Fetched %d %s post full names for user %s.", len(nameList.FullNamesList), listing, username)
+	return nameList.FullNamesList, nil
+}
+
+// FetchHiddenPostsFullNames retrieves the full names of all posts the user has hidden, newest first.
+func FetchHiddenPostsFullNames(token, username string) ([]string, error) {
+	if username == "" {
+		return nil, fmt.Errorf("username is required for fetching hidden posts")
+	}
+
+	config.InfoLogger.Printf("Fetching hidden posts for user %s.", username)
+	apiURL := DefaultClient().APIURL(fmt.Sprintf("/user/%s/hidden.json", username))
+
+	nameList, err := fetchAllNames(apiURL, token, false)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching hidden posts for %s: %w", username, err)
 	}
+
+	config.InfoLogger.Printf("Fetched %d hidden post full names for user %s.", len(nameList.FullNamesList), username)
+	return nameList.FullNamesList, nil
 }
 
 // FetchSavedPostsWithDetails retrieves detailed information about all saved posts for a user
-// including titles, images, thumbnails, and metadata needed for the selection UI
+// including titles, images, thumbnails, and metadata needed for the selection UI.
+// Saved comments are skipped; use FetchSavedItemsWithDetails to get both.
 func FetchSavedPostsWithDetails(token, username string) ([]types.SavedPostInfo, error) {
+	posts, _, err := FetchSavedItemsWithDetails(token, username)
+	return posts, err
+}
+
+// FetchSavedItemsWithDetails retrieves detailed information about all saved posts (t3) and saved comments (t1) for a user.
+// Both lists are ordered newest saved first, as returned by Reddit, and each item's Index is its position in the
+// combined listing, so SavedItemFullNames can restore how posts and comments were interleaved.
+func FetchSavedItemsWithDetails(token, username string) ([]types.SavedPostInfo, []types.SavedCommentInfo, error) {
 	if username == "" {
-		return nil, fmt.Errorf("username is required for fetching saved posts")
+		return nil, nil, fmt.Errorf("username is required for fetching saved posts")
 	}
 
-	config.InfoLogger.Printf("Fetching detailed saved posts for user %s.", username)
-	apiURL := fmt.Sprintf("https://oauth.reddit.com/user/%s/saved.json", username)
+	config.InfoLogger.Printf("Fetching detailed saved posts and comments for user %s.", username)
+	client := DefaultClient()
+	apiURL := client.APIURL(fmt.Sprintf("/user/%s/saved.json", username))
 
 	var allPosts []types.SavedPostInfo
+	var allComments []types.SavedCommentInfo
 	lastFullName := ""
+	index := 0
 
 	for i := 0; ; i++ {
 		paginatedURL := fmt.Sprintf("%s?limit=100&after=%s", apiURL, lastFullName)
 		config.DebugLogger.Printf("Fetching saved posts page %d from %s", i+1, paginatedURL)
 
-		req, err := http.NewRequest(http.MethodGet, paginatedURL, nil)
+		req, err := client.NewRequest(context.Background(), http.MethodGet, paginatedURL, token, nil)
 		if err != nil {
-			return nil, fmt.Errorf("error creating request for %s: %w", paginatedURL, err)
+			return nil, nil, fmt.Errorf("error creating request for %s: %w", paginatedURL, err)
 		}
 
-		req.Header = http.Header{
-			"Authorization": {"Bearer " + token},
-			"User-Agent":    {config.UserAgent},
-		}
-
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := client.Do(req)
 		if err != nil {
-			return nil, fmt.Errorf("error fetching saved posts from %s: %w", paginatedURL, err)
+			return nil, nil, fmt.Errorf("error fetching saved posts from %s: %w", paginatedURL, err)
 		}
+		bodyBytes := resp.Body
 
 		if resp.StatusCode != http.StatusOK {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			resp.Body.Close()
 			config.ErrorLogger.Printf("Failed to fetch saved posts from %s. Status: %d, Body: %s", paginatedURL, resp.StatusCode, string(bodyBytes))
-			return nil, fmt.Errorf("failed to fetch saved posts from %s, status code: %d", paginatedURL, resp.StatusCode)
-		}
-
-		bodyBytes, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			return nil, fmt.Errorf("error reading saved posts response body from %s: %w", paginatedURL, err)
+			return nil, nil, fmt.Errorf("failed to fetch saved posts from %s, status code: %d", paginatedURL, resp.StatusCode)
 		}
 
 		var listing struct {
 			Kind string `json:"kind"`
 			Data struct {
-				After    string                   `json:"after"`
-				Children []types.DetailedPostData `json:"children"`
+				After    string `json:"after"`
+				Children []struct {
+					Kind string          `json:"kind"`
+					Data json.RawMessage `json:"data"`
+				} `json:"children"`
 			} `json:"data"`
 		}
 
 		if err := json.Unmarshal(bodyBytes, &listing); err != nil {
 			config.ErrorLogger.Printf("Error unmarshalling saved posts response from %s: %v. Body: %s", paginatedURL, err, string(bodyBytes))
-			return nil, fmt.Errorf("error unmarshalling saved posts response from %s: %w", paginatedURL, err)
+			return nil, nil, fmt.Errorf("error unmarshalling saved posts response from %s: %w", paginatedURL, err)
 		}
 
-		config.DebugLogger.Printf("Page %d: found %d saved posts", i+1, len(listing.Data.Children))
+		config.DebugLogger.Printf("Page %d: found %d saved items", i+1, len(listing.Data.Children))
 
 		if len(listing.Data.Children) == 0 && listing.Data.After == "" && lastFullName != "" {
 			config.DebugLogger.Printf("No posts found on page %d and no 'after' token. End of saved posts for %s.", i+1, username)
 			break
 		}
 
-		// Process each post and extract detailed information
+		// Process each saved item according to its kind and extract detailed information
 		for _, child := range listing.Data.Children {
-			if child.Kind == "t3" { // Only process actual posts, not comments
-				postInfo := parseDetailedPostData(child)
-				allPosts = append(allPosts, postInfo)
+			switch child.Kind {
+			case "t3":
+				post := types.DetailedPostData{Kind: child.Kind}
+				if err := json.Unmarshal(child.Data, &post.Data); err != nil {
+					config.ErrorLogger.Printf("Skipping saved post that could not be parsed: %v", err)
+					continue
+				}
+				info := parseDetailedPostData(post)
+				info.Index = index
+				allPosts = append(allPosts, info)
+				index++
+			case "t1":
+				comment := types.DetailedCommentData{Kind: child.Kind}
+				if err := json.Unmarshal(child.Data, &comment.Data); err != nil {
+					config.ErrorLogger.Printf("Skipping saved comment that could not be parsed: %v", err)
+					continue
+				}
+				info := parseDetailedCommentData(comment)
+				info.Index = index
+				allComments = append(allComments, info)
+				index++
+			default:
+				config.DebugLogger.Printf("Skipping saved item of unsupported kind %q", child.Kind)
 			}
 		}
 
@@ -264,12 +470,30 @@ func FetchSavedPostsWithDetails(token, username string) ([]types.SavedPostInfo,
 
 		if i > 100 {
 			config.ErrorLogger.Printf("fetchSavedPostsWithDetails exceeded 100 pages for %s. Aborting.", username)
-			return nil, fmt.Errorf("exceeded 100 pages fetching saved posts for %s", username)
+			return nil, nil, fmt.Errorf("exceeded 100 pages fetching saved posts for %s", username)
 		}
 	}
 
-	config.InfoLogger.Printf("Fetched %d detailed saved posts for user %s.", len(allPosts), username)
-	return allPosts, nil
+	config.InfoLogger.Printf("Fetched %d detailed saved posts and %d saved comments for user %s.", len(allPosts), len(allComments), username)
+	return allPosts, allComments, nil
+}
+
+// SavedItemFullNames returns the full names of saved posts and comments merged back into the order of the saved
+// listing, newest first. Both lists must be newest first. Items of archives written before Index existed all have
+// index 0 and come out as the posts followed by the comments.
+func SavedItemFullNames(posts []types.SavedPostInfo, comments []types.SavedCommentInfo) []string {
+	names := make([]string, 0, len(posts)+len(comments))
+	i, j := 0, 0
+	for i < len(posts) || j < len(comments) {
+		if j == len(comments) || (i < len(posts) && posts[i].Index <= comments[j].Index) {
+			names = append(names, posts[i].FullName)
+			i++
+		} else {
+			names = append(names, comments[j].FullName)
+			j++
+		}
+	}
+	return names
 }
 
 // parseDetailedPostData converts Reddit API post data into our SavedPostInfo structure
@@ -298,6 +522,23 @@ func parseDetailedPostData(postData types.DetailedPostData) types.SavedPostInfo
 	}
 }
 
+// parseDetailedCommentData converts Reddit API comment data into our SavedCommentInfo structure
+func parseDetailedCommentData(commentData types.DetailedCommentData) types.SavedCommentInfo {
+	return types.SavedCommentInfo{
+		ID:        commentData.Data.ID,
+		FullName:  commentData.Data.Name,
+		Body:      commentData.Data.Body,
+		LinkTitle: commentData.Data.LinkTitle,
+		LinkID:    commentData.Data.LinkID,
+		Subreddit: commentData.Data.Subreddit,
+		Author:    commentData.Data.Author,
+		Permalink: "https://reddit.com" + commentData.Data.Permalink,
+		Created:   int64(commentData.Data.CreatedUTC),
+		Score:     commentData.Data.Score,
+		NSFW:      commentData.Data.Over18,
+	}
+}
+
 // extractImageData extracts image/media information from Reddit post data
 func extractImageData(postData types.DetailedPostData) types.PostImageData {
 	imageData := types.PostImageData{
@@ -403,44 +644,39 @@ func generateThumbnailFromURL(url, domain string) string {
 
 // GetSavedPostsCount returns the total count of saved posts for a user
 func GetSavedPostsCount(token, username string) (int, error) {
+	posts, _, err := GetSavedItemsCount(token, username)
+	return posts, err
+}
+
+// GetSavedItemsCount returns the total counts of saved posts and saved comments for a user
+func GetSavedItemsCount(token, username string) (int, int, error) {
 	if username == "" {
-		return 0, fmt.Errorf("username is required for fetching saved posts count")
+		return 0, 0, fmt.Errorf("username is required for fetching saved posts count")
 	}
 
 	config.DebugLogger.Printf("Getting saved posts count for user %s.", username)
-	apiURL := fmt.Sprintf("https://oauth.reddit.com/user/%s/saved.json", username)
+	client := DefaultClient()
+	apiURL := client.APIURL(fmt.Sprintf("/user/%s/saved.json", username))
 
-	count := 0
+	postCount, commentCount := 0, 0
 	lastFullName := ""
 
 	for i := 0; ; i++ {
 		paginatedURL := fmt.Sprintf("%s?limit=100&after=%s", apiURL, lastFullName)
 
-		req, err := http.NewRequest(http.MethodGet, paginatedURL, nil)
+		req, err := client.NewRequest(context.Background(), http.MethodGet, paginatedURL, token, nil)
 		if err != nil {
-			return 0, fmt.Errorf("error creating request for saved posts count: %w", err)
-		}
-
-		req.Header = http.Header{
-			"Authorization": {"Bearer " + token},
-			"User-Agent":    {config.UserAgent},
+			return 0, 0, fmt.Errorf("error creating request for saved posts count: %w", err)
 		}
 
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := client.Do(req)
 		if err != nil {
-			return 0, fmt.Errorf("error fetching saved posts count: %w", err)
+			return 0, 0, fmt.Errorf("error fetching saved posts count: %w", err)
 		}
+		bodyBytes := resp.Body
 
 		if resp.StatusCode != http.StatusOK {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			resp.Body.Close()
-			return 0, fmt.Errorf("failed to fetch saved posts count, status code: %d, body: %s", resp.StatusCode, string(bodyBytes))
-		}
-
-		bodyBytes, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			return 0, fmt.Errorf("error reading saved posts count response: %w", err)
+			return 0, 0, fmt.Errorf("failed to fetch saved posts count, status code: %d, body: %s", resp.StatusCode, string(bodyBytes))
 		}
 
 		var listing struct {
@@ -454,13 +690,16 @@ func GetSavedPostsCount(token, username string) (int, error) {
 		}
 
 		if err := json.Unmarshal(bodyBytes, &listing); err != nil {
-			return 0, fmt.Errorf("error unmarshalling saved posts count response: %w", err)
+			return 0, 0, fmt.Errorf("error unmarshalling saved posts count response: %w", err)
 		}
 
-		// Count only actual posts (t3_)
+		// Count posts (t3_) and comments (t1_) separately
 		for _, child := range listing.Data.Children {
-			if child.Kind == "t3" {
-				count++
+			switch child.Kind {
+			case "t3":
+				postCount++
+			case "t1":
+				commentCount++
 			}
 		}
 
@@ -470,10 +709,10 @@ func GetSavedPostsCount(token, username string) (int, error) {
 		lastFullName = listing.Data.After
 
 		if i > 100 {
-			return 0, fmt.Errorf("exceeded 100 pages fetching saved posts count")
+			return 0, 0, fmt.Errorf("exceeded 100 pages fetching saved posts count")
 		}
 	}
 
-	config.DebugLogger.Printf("Found %d saved posts for user %s.", count, username)
-	return count, nil
+	config.DebugLogger.Printf("Found %d saved posts and %d saved comments for user %s.", postCount, commentCount, username)
+	return postCount, commentCount, nil
 }
@@ -0,0 +1,214 @@
+package reddit_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/reddit"
+	"github.com/nileshnk/reddit-migrate/internal/reddittest"
+	"github.com/nileshnk/reddit-migrate/internal/types"
+)
+
+func TestMain(m *testing.M) {
+	config.InfoLogger = log.New(io.Discard, "", 0)
+	config.ErrorLogger = log.New(io.Discard, "", 0)
+	config.DebugLogger = log.New(io.Discard, "", 0)
+	config.MaxTokensPerInterval = 100
+	config.RateLimitInterval = time.Second
+	config.RateLimitSleepInterval = time.Second
+	os.Exit(m.Run())
+}
+
+// newServer starts a fake Reddit with one account and makes it the default client for the duration of the test.
+// The token is derived from the test name, since rate limiters are shared per token across the process.
+func newServer(t *testing.T) (*reddittest.Server, *reddittest.Account) {
+	t.Helper()
+	srv := reddittest.NewServer()
+	t.Cleanup(srv.Close)
+	reddit.SetDefaultClient(srv.Client())
+	t.Cleanup(func() { reddit.SetDefaultClient(nil) })
+
+	account := &reddittest.Account{Name: "alice", Token: "token-" + t.Name()}
+	srv.AddAccount(account)
+	return srv, account
+}
+
+func postIDs(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("t3_p%02d", i)
+	}
+	return ids
+}
+
+func TestManageSavedPostsSavesAllPosts(t *testing.T) {
+	srv, account := newServer(t)
+	ids := postIDs(30)
+
+	result := reddit.ManageSavedPosts(context.Background(), account.Token, ids, types.SaveAction, 5)
+	if result.SuccessCount != len(ids) || result.FailedCount != 0 {
+		t.Fatalf("got %d saved, %d failed; want %d saved", result.SuccessCount, result.FailedCount, len(ids))
+	}
+	if saved := srv.Account("alice").Saved; len(saved) != len(ids) {
+		t.Fatalf("account has %d saved posts, want %d", len(saved), len(ids))
+	}
+
+	result = reddit.ManageSavedPosts(context.Background(), account.Token, ids, types.UnsaveAction, 5)
+	if result.SuccessCount != len(ids) {
+		t.Fatalf("got %d unsaved, want %d", result.SuccessCount, len(ids))
+	}
+	if saved := srv.Account("alice").Saved; len(saved) != 0 {
+		t.Fatalf("account still has %d saved posts", len(saved))
+	}
+}
+
+func TestManageSavedPostsReportsRateLimitedPost(t *testing.T) {
+	srv, account := newServer(t)
+	srv.SetRateLimit(1000, 100*time.Millisecond)
+	srv.FailNext("/api/save", http.StatusTooManyRequests)
+	ids := postIDs(10)
+
+	result := reddit.ManageSavedPosts(context.Background(), account.Token, ids, types.SaveAction, 3)
+	if result.SuccessCount != len(ids)-1 || result.FailedCount != 1 || len(result.FailedPosts) != 1 {
+		t.Fatalf("got %d saved, %d failed (%v); want exactly one failure", result.SuccessCount, result.FailedCount, result.FailedPosts)
+	}
+	if saved := srv.Account("alice").Saved; len(saved) != len(ids)-1 {
+		t.Fatalf("account has %d saved posts, want %d", len(saved), len(ids)-1)
+	}
+}
+
+func TestManageSavedPostsInOrderRetriesAndKeepsOrder(t *testing.T) {
+	srv, account := newServer(t)
+	srv.SetRateLimit(1000, 100*time.Millisecond)
+	srv.FailNext("/api/save", http.StatusTooManyRequests, http.StatusTooManyRequests)
+	ids := postIDs(8)
+
+	result := reddit.ManageSavedPostsInOrder(context.Background(), account.Token, ids, types.SaveAction)
+	if result.SuccessCount != len(ids) || result.FailedCount != 0 {
+		t.Fatalf("got %d saved, %d failed; want all %d saved", result.SuccessCount, result.FailedCount, len(ids))
+	}
+
+	// Reddit lists saved posts newest first, so saving oldest first reverses the input.
+	want := make([]string, len(ids))
+	for i, id := range ids {
+		want[len(ids)-1-i] = id
+	}
+	if saved := srv.Account("alice").Saved; !reflect.DeepEqual(saved, want) {
+		t.Fatalf("saved order = %v, want %v", saved, want)
+	}
+	if got := srv.Requests("/api/save"); got != len(ids)+2 {
+		t.Fatalf("got %d save requests, want %d", got, len(ids)+2)
+	}
+}
+
+func TestClientRetriesTransientFailures(t *testing.T) {
+	srv, account := newServer(t)
+	srv.FailNext("/api/save", http.StatusServiceUnavailable, http.StatusBadGateway)
+
+	result := reddit.ManageSavedPostsInOrder(context.Background(), account.Token, []string{"t3_abc"}, types.SaveAction)
+	if result.SuccessCount != 1 {
+		t.Fatalf("save failed after transient errors: %+v", result)
+	}
+	if got := srv.Requests("/api/save"); got != 3 {
+		t.Fatalf("got %d save requests, want 3", got)
+	}
+}
+
+func TestClientGivesUpAfterMaxRetries(t *testing.T) {
+	srv, account := newServer(t)
+	srv.FailNext("/api/save", http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+
+	result := reddit.ManageSavedPostsInOrder(context.Background(), account.Token, []string{"t3_abc"}, types.SaveAction)
+	if result.FailedCount != 1 {
+		t.Fatalf("save succeeded despite persistent errors: %+v", result)
+	}
+	if got := srv.Requests("/api/save"); got != 3 {
+		t.Fatalf("got %d save requests, want 3", got)
+	}
+}
+
+func TestManageSavedPostsStaysWithinBudget(t *testing.T) {
+	srv, account := newServer(t)
+	srv.SetRateLimit(10, 200*time.Millisecond)
+	ids := postIDs(40)
+
+	start := time.Now()
+	result := reddit.ManageSavedPosts(context.Background(), account.Token, ids, types.SaveAction, 4)
+	if result.SuccessCount != len(ids) {
+		t.Fatalf("got %d saved, %d failed (%v); want all %d saved", result.SuccessCount, result.FailedCount, result.FailedPosts, len(ids))
+	}
+	if n := srv.RateLimited(); n != 0 {
+		t.Fatalf("server rate limited %d requests", n)
+	}
+	// 40 requests at 10 per 200ms need at least three full windows.
+	if elapsed := time.Since(start); elapsed < 600*time.Millisecond {
+		t.Fatalf("finished in %v, faster than the budget allows", elapsed)
+	}
+}
+
+func TestFetchSubredditFullNamesPaginates(t *testing.T) {
+	srv := reddittest.NewServer()
+	t.Cleanup(srv.Close)
+	reddit.SetDefaultClient(srv.Client())
+	t.Cleanup(func() { reddit.SetDefaultClient(nil) })
+
+	account := &reddittest.Account{Name: "bob", Token: "token-" + t.Name(), Followed: []string{"carol"}}
+	for i := 0; i < 250; i++ {
+		account.Subreddits = append(account.Subreddits, fmt.Sprintf("sub%03d", i))
+	}
+	srv.AddAccount(account)
+
+	names, err := reddit.FetchSubredditFullNames(account.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names.DisplayNamesList) != 250 {
+		t.Fatalf("got %d subreddits, want 250", len(names.DisplayNamesList))
+	}
+	if !reflect.DeepEqual(names.UserDisplayNameList, []string{"u_carol"}) {
+		t.Fatalf("followed users = %v, want [u_carol]", names.UserDisplayNameList)
+	}
+	if got := srv.Requests("/subreddits/mine.json"); got != 3 {
+		t.Fatalf("got %d listing requests, want 3", got)
+	}
+}
+
+func TestSavedItemFullNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		posts    []types.SavedPostInfo
+		comments []types.SavedCommentInfo
+		want     []string
+	}{
+		{
+			name:     "interleaved",
+			posts:    []types.SavedPostInfo{{FullName: "t3_a", Index: 0}, {FullName: "t3_b", Index: 2}, {FullName: "t3_c", Index: 3}},
+			comments: []types.SavedCommentInfo{{FullName: "t1_x", Index: 1}, {FullName: "t1_y", Index: 4}},
+			want:     []string{"t3_a", "t1_x", "t3_b", "t3_c", "t1_y"},
+		},
+		{
+			name:     "archive without indexes",
+			posts:    []types.SavedPostInfo{{FullName: "t3_a"}, {FullName: "t3_b"}},
+			comments: []types.SavedCommentInfo{{FullName: "t1_x"}},
+			want:     []string{"t3_a", "t3_b", "t1_x"},
+		},
+		{
+			name:     "comments only",
+			comments: []types.SavedCommentInfo{{FullName: "t1_x", Index: 0}, {FullName: "t1_y", Index: 1}},
+			want:     []string{"t1_x", "t1_y"},
+		},
+	}
+	for _, tt := range tests {
+		if got := reddit.SavedItemFullNames(tt.posts, tt.comments); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package reddit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+)
+
+// DefaultPrefKeys are the preferences copied when the user does not pick any.
+// They only affect how content is displayed, sorted and notified. Privacy settings (public_votes, show_presence,
+// hide_from_robots, accept_pms), ad and data-sharing settings and email_unsubscribe_all are left out on purpose;
+// they can still be picked explicitly.
+var DefaultPrefKeys = []string{
+	"over_18",
+	"search_include_over_18",
+	"label_nsfw",
+	"no_profanity",
+	"default_comment_sort",
+	"ignore_suggested_sort",
+	"highlight_controversial",
+	"num_comments",
+	"numsites",
+	"min_comment_score",
+	"min_link_score",
+	"media",
+	"media_preview",
+	"video_autoplay",
+	"show_stylesheets",
+	"show_flair",
+	"show_link_flair",
+	"show_trending",
+	"domain_details",
+	"newwindow",
+	"nightmode",
+	"lang",
+	"mark_messages_read",
+	"threaded_messages",
+	"collapse_read_messages",
+	"email_messages",
+	"email_digests",
+}
+
+const prefsPath = "/api/v1/me/prefs"
+
+// FetchPrefs fetches all preferences of the authenticated user.
+func FetchPrefs(token string) (map[string]interface{}, error) {
+	config.InfoLogger.Println("Fetching account preferences.")
+	client := DefaultClient()
+	prefsURL := client.APIURL(prefsPath)
+
+	req, err := client.NewRequest(context.Background(), http.MethodGet, prefsURL, token, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request for %s: %w", prefsURL, err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching preferences from %s: %w", prefsURL, err)
+	}
+	bodyBytes := resp.Body
+	if resp.StatusCode != http.StatusOK {
+		config.ErrorLogger.Printf("Failed to fetch preferences from %s. Status: %d, Body: %s", prefsURL, resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("failed to fetch preferences from %s, status code: %d", prefsURL, resp.StatusCode)
+	}
+
+	var prefs map[string]interface{}
+	if err := json.Unmarshal(bodyBytes, &prefs); err != nil {
+		config.ErrorLogger.Printf("Error unmarshalling preferences response from %s: %v. Body: %s", prefsURL, err, string(bodyBytes))
+		return nil, fmt.Errorf("error unmarshalling preferences response from %s: %w", prefsURL, err)
+	}
+	return prefs, nil
+}
+
+// UpdatePrefs PATCHes the given preferences onto the authenticated user's account in a single request.
+// It returns the account's preferences as reported by Reddit after the update, together with the response status code.
+func UpdatePrefs(ctx context.Context, token string, prefs map[string]interface{}) (map[string]interface{}, int, error) {
+	config.InfoLogger.Printf("Updating %d account preferences.", len(prefs))
+	client := DefaultClient()
+	prefsURL := client.APIURL(prefsPath)
+
+	req, err := client.NewJSONRequest(ctx, http.MethodPatch, prefsURL, token, prefs)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error creating request for %s: %w", prefsURL, err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error updating preferences at %s: %w", prefsURL, err)
+	}
+	bodyBytes := resp.Body
+	if resp.StatusCode == http.StatusForbidden {
+		config.ErrorLogger.Printf("Updating preferences at %s was refused (status 403). Body: %s", prefsURL, string(bodyBytes))
+		return nil, resp.StatusCode, fmt.Errorf("failed to update preferences at %s: %s", prefsURL, MissingScopeMessage("account"))
+	}
+	if resp.StatusCode != http.StatusOK {
+		config.ErrorLogger.Printf("Failed to update preferences at %s. Status: %d, Body: %s", prefsURL, resp.StatusCode, string(bodyBytes))
+		return nil, resp.StatusCode, fmt.Errorf("failed to update preferences at %s, status code: %d", prefsURL, resp.StatusCode)
+	}
+
+	var updated map[string]interface{}
+	if err := json.Unmarshal(bodyBytes, &updated); err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("error unmarshalling preferences response from %s: %w", prefsURL, err)
+	}
+	return updated, resp.StatusCode, nil
+}
@@ -2,19 +2,20 @@ package reddit
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
 
 	"github.com/nileshnk/reddit-migrate/internal/config"
+	"github.com/nileshnk/reddit-migrate/internal/progress"
 	"github.com/nileshnk/reddit-migrate/internal/types"
 )
 
 // ManageSubreddits performs subscribe or unsubscribe actions on a list of subreddits in chunks.
-// It aggregates results from chunk operations.
-func ManageSubreddits(token string, subredditDisplayNames []string, action types.SubredditActionType, chunkSize int) types.ManageSubredditResponseType {
+// It aggregates results from chunk operations. Chunks not yet sent when ctx is cancelled are reported as failed.
+func ManageSubreddits(ctx context.Context, token string, subredditDisplayNames []string, action types.SubredditActionType, chunkSize int) types.ManageSubredditResponseType {
 	if len(subredditDisplayNames) == 0 {
 		config.DebugLogger.Printf("No subreddits to %s.", action)
 		return types.ManageSubredditResponseType{SuccessCount: 0, FailedCount: 0}
@@ -29,8 +30,17 @@ func ManageSubreddits(token string, subredditDisplayNames []string, action types
 	var finalResponse types.ManageSubredditResponseType
 
 	for i, chunk := range chunks {
+		if ctx.Err() != nil {
+			config.ErrorLogger.Printf("Context cancelled before chunk %d/%d for %s action. Skipping remaining subreddits.", i+1, len(chunks), action)
+			for _, remaining := range chunks[i:] {
+				finalResponse.FailedCount += len(remaining)
+				finalResponse.FailedSubreddits = append(finalResponse.FailedSubreddits, remaining...)
+			}
+			finalResponse.Error = true
+			break
+		}
 		config.DebugLogger.Printf("Processing chunk %d/%d for %s action (size: %d).", i+1, len(chunks), action, len(chunk))
-		response := manageSubredditChunk(token, chunk, action)
+		response := manageSubredditChunk(ctx, token, chunk, action)
 		finalResponse.SuccessCount += response.SuccessCount
 		finalResponse.FailedCount += response.FailedCount
 		if response.Error { // If any chunk has an error, mark the overall as having an error.
@@ -40,13 +50,22 @@ func ManageSubreddits(token string, subredditDisplayNames []string, action types
 		if response.FailedCount > 0 {
 			finalResponse.FailedSubreddits = append(finalResponse.FailedSubreddits, response.FailedSubreddits...)
 		}
+		progress.Report(ctx, types.ProgressEvent{
+			Type:        types.SubredditChunkEvent,
+			Action:      string(action),
+			Items:       chunk,
+			FailedItems: response.FailedSubreddits,
+			Success:     !response.Error,
+			Completed:   finalResponse.SuccessCount + finalResponse.FailedCount,
+			Total:       len(subredditDisplayNames),
+		})
 	}
 	config.DebugLogger.Printf("Finished managing subreddits with action '%s'. Success: %d, Failed: %d.", action, finalResponse.SuccessCount, finalResponse.FailedCount)
 	return finalResponse
 }
 
 // manageSubredditChunk sends a request to Reddit API to subscribe/unsubscribe a single chunk of subreddits.
-func manageSubredditChunk(token string, subredditDisplayNamesChunk []string, action types.SubredditActionType) types.ManageSubredditResponseType {
+func manageSubredditChunk(ctx context.Context, token string, subredditDisplayNamesChunk []string, action types.SubredditActionType) types.ManageSubredditResponseType {
 	if len(subredditDisplayNamesChunk) == 0 {
 		return types.ManageSubredditResponseType{SuccessCount: 0, FailedCount: 0}
 	}
@@ -55,7 +74,8 @@ func manageSubredditChunk(token string, subredditDisplayNamesChunk []string, act
 	requestBodyStr := fmt.Sprintf("sr_name=%s&action=%s&api_type=json", subredditNames, action)
 	requestBodyBytes := []byte(requestBodyStr)
 
-	req, err := http.NewRequest(http.MethodPost, "https://oauth.reddit.com/api/subscribe", bytes.NewBuffer(requestBodyBytes))
+	client := DefaultClient()
+	req, err := client.NewRequest(ctx, http.MethodPost, client.APIURL("/api/subscribe"), token, bytes.NewReader(requestBodyBytes))
 	if err != nil {
 		config.ErrorLogger.Printf("Error creating request for %s subreddits: %v. Subreddits: %v", action, err, subredditDisplayNamesChunk)
 		return types.ManageSubredditResponseType{
@@ -66,14 +86,10 @@ func manageSubredditChunk(token string, subredditDisplayNamesChunk []string, act
 		}
 	}
 
-	req.Header = http.Header{
-		"Authorization": {"Bearer " + token},
-		"Content-Type":  {"application/x-www-form-urlencoded"},
-		"User-Agent":    {config.UserAgent}, // Assuming userAgent is a global constant or variable.
-	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	config.DebugLogger.Printf("Sending %s request for subreddits: %s", action, subredditNames)
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		config.ErrorLogger.Printf("Error sending %s request for subreddits: %v. Subreddits: %v", action, err, subredditDisplayNamesChunk)
 		return types.ManageSubredditResponseType{
@@ -83,19 +99,7 @@ func manageSubredditChunk(token string, subredditDisplayNamesChunk []string, act
 			FailedSubreddits: subredditDisplayNamesChunk,
 		}
 	}
-	defer resp.Body.Close()
-
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		config.ErrorLogger.Printf("Error reading response body for %s subreddits (status %d): %v. Subreddits: %v", action, resp.StatusCode, err, subredditDisplayNamesChunk)
-		// Still process status code, but mark as error.
-		return types.ManageSubredditResponseType{
-			Error:            true,
-			StatusCode:       resp.StatusCode,
-			FailedCount:      len(subredditDisplayNamesChunk),
-			FailedSubreddits: subredditDisplayNamesChunk,
-		}
-	}
+	bodyBytes := resp.Body
 	config.DebugLogger.Printf("Response for %s subreddits (Status: %d): %s", action, resp.StatusCode, string(bodyBytes))
 
 	if resp.StatusCode != http.StatusOK {
@@ -121,7 +125,8 @@ func manageSubredditChunk(token string, subredditDisplayNamesChunk []string, act
 }
 
 // ManageFollowedUsers performs follow (subscribe) or unfollow (unsubscribe) actions for a list of user display names.
-func ManageFollowedUsers(token string, userDisplayNames []string, action types.SubredditActionType) types.ManageSubredditResponseType {
+// Users not yet processed when ctx is cancelled are reported as failed.
+func ManageFollowedUsers(ctx context.Context, token string, userDisplayNames []string, action types.SubredditActionType) types.ManageSubredditResponseType {
 	if len(userDisplayNames) == 0 {
 		config.DebugLogger.Printf("No users to %s.", action)
 		return types.ManageSubredditResponseType{SuccessCount: 0, FailedCount: 0}
@@ -135,8 +140,16 @@ func ManageFollowedUsers(token string, userDisplayNames []string, action types.S
 	if action == types.UnsubscribeAction { // Corrected: was types.SubscribeAction, should be UnsubscribeAction for DELETE
 		requestMethod = http.MethodDelete // For "unsub" (unfollow)
 	}
+	client := DefaultClient()
+
+	for i, username := range userDisplayNames {
+		if ctx.Err() != nil {
+			config.ErrorLogger.Printf("Context cancelled during %s of users. Skipping %d remaining users.", action, len(userDisplayNames)-i)
+			failedUsernames = append(failedUsernames, userDisplayNames[i:]...)
+			finalResponse.Error = true
+			break
+		}
 
-	for _, username := range userDisplayNames {
 		// Reddit API expects username without "u_" prefix for this endpoint.
 		cleanUsername := strings.TrimPrefix(username, "u_")
 		if cleanUsername == "" {
@@ -147,45 +160,28 @@ func ManageFollowedUsers(token string, userDisplayNames []string, action types.S
 		// The endpoint is /api/v1/me/friends/{username}
 		// For following, it's PUT with JSON body {"name": "username"}
 		// For unfollowing, it's DELETE.
-		apiURL := fmt.Sprintf("https://oauth.reddit.com/api/v1/me/friends/%s", cleanUsername)
-		var requestBodyBytes []byte
+		apiURL := client.APIURL("/api/v1/me/friends/" + cleanUsername)
+		var req *http.Request
 		var err error
-
 		if requestMethod == http.MethodPut {
-			jsonBody := map[string]string{"name": cleanUsername}
-			requestBodyBytes, err = json.Marshal(jsonBody)
-			if err != nil {
-				config.ErrorLogger.Printf("Error marshalling body for %s user %s: %v", action, cleanUsername, err)
-				failedUsernames = append(failedUsernames, username) // Original name for reporting
-				continue
-			}
+			req, err = client.NewJSONRequest(ctx, requestMethod, apiURL, token, map[string]string{"name": cleanUsername})
+		} else {
+			req, err = client.NewRequest(ctx, requestMethod, apiURL, token, nil) // DELETE has no body
 		}
-
-		req, err := http.NewRequest(requestMethod, apiURL, bytes.NewBuffer(requestBodyBytes)) // Pass nil buffer for DELETE if no body
 		if err != nil {
 			config.ErrorLogger.Printf("Error creating request to %s user %s: %v", action, cleanUsername, err)
-			failedUsernames = append(failedUsernames, username)
+			failedUsernames = append(failedUsernames, username) // Original name for reporting
 			continue
 		}
 
-		req.Header = http.Header{
-			"Authorization": {"Bearer " + token},
-			"User-Agent":    {config.UserAgent},
-		}
-		if requestMethod == http.MethodPut { // Content-Type only for PUT
-			req.Header.Set("Content-Type", "application/json")
-		}
-
 		config.DebugLogger.Printf("Sending %s request for user: %s (URL: %s)", action, cleanUsername, apiURL)
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := client.Do(req)
 		if err != nil {
 			config.ErrorLogger.Printf("Error sending %s request for user %s: %v", action, cleanUsername, err)
 			failedUsernames = append(failedUsernames, username)
 			continue
 		}
-
-		bodyBytes, _ := io.ReadAll(resp.Body) // Read body for logging, even on error.
-		resp.Body.Close()                     // Close body immediately.
+		bodyBytes := resp.Body // Logged even on error.
 
 		// According to Reddit API for follow:
 		// PUT to /api/v1/me/friends/username with {"name": "username"} returns 200 OK (empty body or relationship object).
@@ -193,15 +189,25 @@ func ManageFollowedUsers(token string, userDisplayNames []string, action types.S
 		isSuccess := (requestMethod == http.MethodPut && resp.StatusCode == http.StatusOK) ||
 			(requestMethod == http.MethodDelete && resp.StatusCode == http.StatusNoContent)
 
+		userEvent := types.ProgressEvent{
+			Type:      types.UserEvent,
+			Action:    string(action),
+			Item:      username,
+			Success:   isSuccess,
+			Completed: i + 1,
+			Total:     len(userDisplayNames),
+		}
 		if !isSuccess {
 			config.ErrorLogger.Printf("Failed to %s user %s (status %d): %s", action, cleanUsername, resp.StatusCode, string(bodyBytes))
 			failedUsernames = append(failedUsernames, username)
 			finalResponse.Error = true                 // Mark overall error if any user fails.
 			finalResponse.StatusCode = resp.StatusCode // Report the last erroring status.
+			userEvent.Error = fmt.Sprintf("status %d", resp.StatusCode)
 		} else {
 			config.DebugLogger.Printf("Successfully %s user %s (status %d). Response: %s", action, cleanUsername, resp.StatusCode, string(bodyBytes))
 			finalResponse.SuccessCount++
 		}
+		progress.Report(ctx, userEvent)
 	}
 
 	finalResponse.FailedCount = len(failedUsernames)
@@ -235,7 +241,7 @@ func FetchSubredditFullNames(token string) (types.RedditNameType, error) {
 	config.InfoLogger.Println("Fetching subscribed subreddits and followed users.")
 	// The endpoint is /subreddits/mine.json (variant like /subreddits/mine/subscriber.json also exists)
 	// For this use case, /subreddits/mine.json usually lists subscribed "true" subreddits and followed users.
-	apiURL := "https://oauth.reddit.com/subreddits/mine.json"
+	apiURL := DefaultClient().APIURL("/subreddits/mine.json")
 	nameList, err := fetchAllNames(apiURL, token, true) // true indicates it's for subreddits (enables u_ filtering)
 	if err != nil {
 		return types.RedditNameType{}, fmt.Errorf("error fetching subscribed subreddits: %w", err)
@@ -249,7 +255,8 @@ func FetchSubredditFullNames(token string) (types.RedditNameType, error) {
 // including subscriber counts, descriptions, icons, and metadata needed for the selection UI
 func FetchSubredditsWithDetails(token string) ([]types.SubredditInfo, error) {
 	config.InfoLogger.Println("Fetching detailed subscribed subreddits information.")
-	apiURL := "https://oauth.reddit.com/subreddits/mine.json"
+	client := DefaultClient()
+	apiURL := client.APIURL("/subreddits/mine.json")
 
 	var allSubreddits []types.SubredditInfo
 	lastFullName := ""
@@ -258,34 +265,22 @@ func FetchSubredditsWithDetails(token string) ([]types.SubredditInfo, error) {
 		paginatedURL := fmt.Sprintf("%s?limit=100&after=%s", apiURL, lastFullName)
 		config.DebugLogger.Printf("Fetching subreddits page %d from %s", i+1, paginatedURL)
 
-		req, err := http.NewRequest(http.MethodGet, paginatedURL, nil)
+		req, err := client.NewRequest(context.Background(), http.MethodGet, paginatedURL, token, nil)
 		if err != nil {
 			return nil, fmt.Errorf("error creating request for %s: %w", paginatedURL, err)
 		}
 
-		req.Header = http.Header{
-			"Authorization": {"Bearer " + token},
-			"User-Agent":    {config.UserAgent},
-		}
-
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := client.Do(req)
 		if err != nil {
 			return nil, fmt.Errorf("error fetching subreddits from %s: %w", paginatedURL, err)
 		}
+		bodyBytes := resp.Body
 
 		if resp.StatusCode != http.StatusOK {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			resp.Body.Close()
 			config.ErrorLogger.Printf("Failed to fetch subreddits from %s. Status: %d, Body: %s", paginatedURL, resp.StatusCode, string(bodyBytes))
 			return nil, fmt.Errorf("failed to fetch subreddits from %s, status code: %d", paginatedURL, resp.StatusCode)
 		}
 
-		bodyBytes, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			return nil, fmt.Errorf("error reading subreddits response body from %s: %w", paginatedURL, err)
-		}
-
 		var listing struct {
 			Kind string `json:"kind"`
 			Data struct {
@@ -375,7 +370,8 @@ func unescapeHTMLEntities(url string) string {
 // GetSubredditCount returns the total count of subscribed subreddits (excluding followed users)
 func GetSubredditCount(token string) (int, error) {
 	config.DebugLogger.Println("Getting subscribed subreddits count.")
-	apiURL := "https://oauth.reddit.com/subreddits/mine.json"
+	client := DefaultClient()
+	apiURL := client.APIURL("/subreddits/mine.json")
 
 	count := 0
 	lastFullName := ""
@@ -383,33 +379,21 @@ func GetSubredditCount(token string) (int, error) {
 	for i := 0; ; i++ {
 		paginatedURL := fmt.Sprintf("%s?limit=100&after=%s", apiURL, lastFullName)
 
-		req, err := http.NewRequest(http.MethodGet, paginatedURL, nil)
+		req, err := client.NewRequest(context.Background(), http.MethodGet, paginatedURL, token, nil)
 		if err != nil {
 			return 0, fmt.Errorf("error creating request for subreddit count: %w", err)
 		}
 
-		req.Header = http.Header{
-			"Authorization": {"Bearer " + token},
-			"User-Agent":    {config.UserAgent},
-		}
-
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := client.Do(req)
 		if err != nil {
 			return 0, fmt.Errorf("error fetching subreddit count: %w", err)
 		}
+		bodyBytes := resp.Body
 
 		if resp.StatusCode != http.StatusOK {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			resp.Body.Close()
 			return 0, fmt.Errorf("failed to fetch subreddit count, status code: %d, body: %s", resp.StatusCode, string(bodyBytes))
 		}
 
-		bodyBytes, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			return 0, fmt.Errorf("error reading subreddit count response: %w", err)
-		}
-
 		var listing struct {
 			Kind string `json:"kind"`
 			Data struct {
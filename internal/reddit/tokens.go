@@ -0,0 +1,69 @@
+package reddit
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// TokenSource keeps an OAuth access token valid for as long as it is in use.
+type TokenSource interface {
+	// Token returns the current access token, refreshing it first if it is about to expire.
+	Token(ctx context.Context) (string, error)
+	// Refresh renews the access token after Reddit rejected stale with 401 and returns the new one.
+	// If stale has already been replaced, e.g. by a concurrent request, the current token is returned without refreshing again.
+	Refresh(ctx context.Context, stale string) (string, error)
+}
+
+// tokenSources maps the access token a caller authenticates with to the source that keeps it fresh.
+// Callers keep passing the token they started with; Client.Do sends the source's current token in its place.
+var tokenSources = struct {
+	mu      sync.Mutex
+	entries map[string]*tokenSourceEntry
+}{entries: make(map[string]*tokenSourceEntry)}
+
+type tokenSourceEntry struct {
+	source TokenSource
+	refs   int
+}
+
+// RegisterTokenSource makes requests authenticated with token use source's current access token instead, so they keep
+// working after the original token expires. The returned function removes the registration; registrations of the same
+// token are counted, so concurrent jobs using one account can register and release it independently.
+func RegisterTokenSource(token string, source TokenSource) (release func()) {
+	tokenSources.mu.Lock()
+	defer tokenSources.mu.Unlock()
+	entry, ok := tokenSources.entries[token]
+	if !ok {
+		entry = &tokenSourceEntry{source: source}
+		tokenSources.entries[token] = entry
+	}
+	entry.refs++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			tokenSources.mu.Lock()
+			defer tokenSources.mu.Unlock()
+			entry.refs--
+			if entry.refs == 0 && tokenSources.entries[token] == entry {
+				delete(tokenSources.entries, token)
+			}
+		})
+	}
+}
+
+// tokenSourceFor returns the source registered for req's bearer token, or nil if there is none.
+func tokenSourceFor(req *http.Request) TokenSource {
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return nil
+	}
+	tokenSources.mu.Lock()
+	defer tokenSources.mu.Unlock()
+	if entry, ok := tokenSources.entries[token]; ok {
+		return entry.source
+	}
+	return nil
+}
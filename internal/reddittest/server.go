@@ -0,0 +1,745 @@
+// Package reddittest provides an in-process stand-in for the parts of Reddit's API this tool uses.
+// It lets the migration engine, the worker pool and the rate limiter be tested end to end without network access.
+package reddittest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/nileshnk/reddit-migrate/internal/reddit"
+)
+
+// Account is the state of one fake Reddit account.
+type Account struct {
+	Name          string
+	Password      string   // Accepted by the password grant of the token endpoint.
+	Token         string   // Bearer token; also accepted as the token_v2 cookie.
+	RefreshToken  string   // Accepted by the refresh_token grant, which answers with Token.
+	Code          string   // Accepted by the authorization_code grant, which answers with Token.
+	CodeChallenge string   // When set, the authorization_code grant also requires the matching S256 code_verifier.
+	ClientID      string   // When set, the refresh_token and authorization_code grants require this app's client ID.
+	Subreddits    []string // Subscribed subreddits by display name, oldest subscription first.
+	Followed      []string // Followed users, without the u_ prefix.
+	Saved         []string // Saved post (t3_) and comment (t1_) full names, newest first as Reddit lists them.
+	Upvoted       []string // Upvoted post full names, newest first.
+	Downvoted     []string // Downvoted post full names, newest first.
+	Hidden        []string // Hidden post full names, newest first.
+	Multireddits  []Multireddit
+	Blocked       []string               // Blocked users, in the order they were blocked.
+	Prefs         map[string]interface{} // Preferences; updates of keys not in the map are rejected like invalid values.
+}
+
+// Multireddit is a custom feed of a fake account.
+type Multireddit struct {
+	Name        string
+	DisplayName string
+	Visibility  string
+	Subreddits  []string // Member subreddit display names, in the order they were added.
+}
+
+// Server is a fake Reddit API backed by httptest.Server. Both the OAuth API and www.reddit.com are served from its URL.
+// Every response carries X-Ratelimit-* headers for a budget of requests per window and per credential;
+// requests over the budget are answered with 429.
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	budget      int
+	window      time.Duration
+	accounts    []*Account
+	failures    map[string][]int
+	windows     map[string]*rateWindow
+	requests    map[string]int
+	rateLimited int
+}
+
+// rateWindow counts the requests of one credential in its current window.
+type rateWindow struct {
+	start time.Time
+	used  int
+}
+
+// NewServer starts a fake Reddit with a budget of 1000 requests per second, which tests only exhaust on purpose.
+// The caller must Close it.
+func NewServer() *Server {
+	s := &Server{
+		budget:   1000,
+		window:   time.Second,
+		failures: make(map[string][]int),
+		windows:  make(map[string]*rateWindow),
+		requests: make(map[string]int),
+	}
+
+	router := chi.NewRouter()
+	router.Use(s.middleware)
+	router.Get("/api/me.json", s.handleMeJSON)
+	router.Get("/api/v1/me", s.handleMe)
+	router.Get("/api/v1/me/prefs", s.handlePrefs)
+	router.Patch("/api/v1/me/prefs", s.handlePrefs)
+	router.Post("/api/v1/access_token", s.handleAccessToken)
+	router.Get("/subreddits/mine.json", s.handleMySubreddits)
+	router.Get("/user/{username}/saved.json", s.handlePostListing(func(account *Account) []string { return account.Saved }))
+	router.Get("/user/{username}/upvoted.json", s.handlePostListing(func(account *Account) []string { return account.Upvoted }))
+	router.Get("/user/{username}/downvoted.json", s.handlePostListing(func(account *Account) []string { return account.Downvoted }))
+	router.Get("/user/{username}/hidden.json", s.handlePostListing(func(account *Account) []string { return account.Hidden }))
+	router.Post("/api/subscribe", s.handleSubscribe)
+	router.Post("/api/save", s.handleSave)
+	router.Post("/api/unsave", s.handleUnsave)
+	router.Post("/api/vote", s.handleVote)
+	router.Post("/api/hide", s.handleHide)
+	router.Post("/api/unhide", s.handleUnhide)
+	router.Get("/api/multi/mine", s.handleMyMultis)
+	router.Post("/api/multi/user/{username}/m/{multi}", s.handleCreateMulti)
+	router.Put("/api/multi/user/{username}/m/{multi}/r/{subreddit}", s.handleAddMultiSubreddit)
+	router.Get("/prefs/blocked", s.handleBlocked)
+	router.Post("/api/block_user", s.handleBlockUser)
+	router.Put("/api/v1/me/friends/{username}", s.handleFriend)
+	router.Delete("/api/v1/me/friends/{username}", s.handleFriend)
+
+	s.Server = httptest.NewServer(router)
+	return s
+}
+
+// Client returns a reddit.Client that sends every request to s, with a short retry delay.
+func (s *Server) Client() *reddit.Client {
+	return &reddit.Client{
+		OAuthURL:   s.URL,
+		BaseURL:    s.URL,
+		UserAgent:  "reddittest",
+		HTTPClient: &http.Client{Transport: s.Server.Client().Transport, Timeout: 5 * time.Second},
+		MaxRetries: 2,
+		RetryDelay: time.Millisecond,
+	}
+}
+
+// AddAccount registers an account. The server keeps the pointer, so its fields must not be changed afterwards;
+// use Account to read the current state.
+func (s *Server) AddAccount(account *Account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts = append(s.accounts, account)
+}
+
+// Account returns a copy of the current state of the named account.
+func (s *Server) Account(name string) Account {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, account := range s.accounts {
+		if strings.EqualFold(account.Name, name) {
+			snapshot := *account
+			snapshot.Subreddits = append([]string(nil), account.Subreddits...)
+			snapshot.Followed = append([]string(nil), account.Followed...)
+			snapshot.Saved = append([]string(nil), account.Saved...)
+			snapshot.Upvoted = append([]string(nil), account.Upvoted...)
+			snapshot.Downvoted = append([]string(nil), account.Downvoted...)
+			snapshot.Hidden = append([]string(nil), account.Hidden...)
+			snapshot.Blocked = append([]string(nil), account.Blocked...)
+			snapshot.Prefs = make(map[string]interface{}, len(account.Prefs))
+			for key, value := range account.Prefs {
+				snapshot.Prefs[key] = value
+			}
+			snapshot.Multireddits = make([]Multireddit, len(account.Multireddits))
+			for i, multi := range account.Multireddits {
+				multi.Subreddits = append([]string(nil), multi.Subreddits...)
+				snapshot.Multireddits[i] = multi
+			}
+			return snapshot
+		}
+	}
+	return Account{}
+}
+
+// ExpireToken replaces the access token of the named account with newToken, as if the old one had expired.
+// Requests made with the old token are answered with 401; the refresh_token grant answers with newToken.
+func (s *Server) ExpireToken(name, newToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, account := range s.accounts {
+		if strings.EqualFold(account.Name, name) {
+			account.Token = newToken
+		}
+	}
+}
+
+// SetCodeChallenge makes the authorization_code grant of the named account require the code_verifier of challenge,
+// as if the authorization request had been made with it.
+func (s *Server) SetCodeChallenge(name, challenge string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, account := range s.accounts {
+		if strings.EqualFold(account.Name, name) {
+			account.CodeChallenge = challenge
+		}
+	}
+}
+
+// SetRateLimit changes the number of requests each credential may make per window.
+func (s *Server) SetRateLimit(budget int, window time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.budget = budget
+	s.window = window
+	s.windows = make(map[string]*rateWindow)
+}
+
+// FailNext makes the next requests to path fail with the given status codes, one request per code, in order.
+// The failed requests are not processed, but still count against the rate limit budget.
+func (s *Server) FailNext(path string, statusCodes ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[path] = append(s.failures[path], statusCodes...)
+}
+
+// Requests returns how many requests were made to path.
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// RateLimited returns how many requests were answered with 429 because their credential exceeded its budget.
+// Failures injected with FailNext are not included.
+func (s *Server) RateLimited() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rateLimited
+}
+
+// middleware counts the request, applies the rate limit and injected failures, and sets the rate limit headers.
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+
+		credential := r.Header.Get("Authorization")
+		if credential == "" {
+			credential = r.Header.Get("Cookie")
+		}
+		now := time.Now()
+		win, ok := s.windows[credential]
+		if !ok || now.Sub(win.start) >= s.window {
+			win = &rateWindow{start: now}
+			s.windows[credential] = win
+		}
+		win.used++
+		remaining := s.budget - win.used
+		if remaining < 0 {
+			remaining = 0
+		}
+		reset := win.start.Add(s.window).Sub(now)
+		w.Header().Set("X-Ratelimit-Used", strconv.Itoa(win.used))
+		w.Header().Set("X-Ratelimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-Ratelimit-Reset", strconv.FormatFloat(reset.Seconds(), 'f', 3, 64))
+
+		status := 0
+		if queued := s.failures[r.URL.Path]; len(queued) > 0 {
+			status = queued[0]
+			s.failures[r.URL.Path] = queued[1:]
+		} else if win.used > s.budget {
+			status = http.StatusTooManyRequests
+			s.rateLimited++
+		}
+		s.mu.Unlock()
+
+		if status != 0 {
+			writeJSON(w, status, map[string]interface{}{"message": http.StatusText(status), "error": status})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticate returns the account of the request's bearer token or token_v2 cookie, or answers 401 and returns nil.
+// The caller must not hold s.mu.
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) *Account {
+	token := ""
+	if scheme, value, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "bearer") {
+		token = value
+	} else if cookie, err := r.Cookie("token_v2"); err == nil {
+		token = cookie.Value
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, account := range s.accounts {
+		if token != "" && account.Token == token {
+			return account
+		}
+	}
+	writeJSON(w, http.StatusUnauthorized, map[string]interface{}{"message": "Unauthorized", "error": http.StatusUnauthorized})
+	return nil
+}
+
+// authenticateOwner is authenticate for URLs naming a user, which must be the authenticated account.
+// Other users get 403, as on Reddit.
+func (s *Server) authenticateOwner(w http.ResponseWriter, r *http.Request) *Account {
+	account := s.authenticate(w, r)
+	if account == nil {
+		return nil
+	}
+	if !strings.EqualFold(chi.URLParam(r, "username"), account.Name) {
+		writeJSON(w, http.StatusForbidden, map[string]interface{}{"message": "Forbidden", "error": http.StatusForbidden})
+		return nil
+	}
+	return account
+}
+
+func (s *Server) handleMeJSON(w http.ResponseWriter, r *http.Request) {
+	account := s.authenticate(w, r)
+	if account == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"kind": "t2", "data": map[string]interface{}{"name": account.Name}})
+}
+
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	account := s.authenticate(w, r)
+	if account == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"name": account.Name})
+}
+
+// handleAccessToken implements the password, refresh_token and authorization_code grants. Client secrets are not checked,
+// so installed apps with an empty secret are accepted like web apps; client IDs only for accounts with a ClientID.
+func (s *Server) handleAccessToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "invalid_request"})
+		return
+	}
+
+	clientID, _, _ := r.BasicAuth()
+	s.mu.Lock()
+	var found *Account
+	for _, account := range s.accounts {
+		var match bool
+		switch r.PostForm.Get("grant_type") {
+		case "password":
+			match = strings.EqualFold(account.Name, r.PostForm.Get("username")) && account.Password == r.PostForm.Get("password")
+		case "refresh_token":
+			match = account.RefreshToken != "" && account.RefreshToken == r.PostForm.Get("refresh_token") &&
+				(account.ClientID == "" || account.ClientID == clientID)
+		case "authorization_code":
+			match = account.Code != "" && account.Code == r.PostForm.Get("code") &&
+				(account.ClientID == "" || account.ClientID == clientID) &&
+				(account.CodeChallenge == "" || account.CodeChallenge == codeChallenge(r.PostForm.Get("code_verifier")))
+		}
+		if match {
+			found = account
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if found == nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "invalid_grant"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token":  found.Token,
+		"token_type":    "bearer",
+		"expires_in":    3600,
+		"refresh_token": found.RefreshToken,
+		"scope":         "*",
+	})
+}
+
+// codeChallenge returns the S256 code challenge of a PKCE code verifier (RFC 7636).
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// handleMySubreddits lists subscribed subreddits followed by followed users, which Reddit reports as "user" subreddits.
+func (s *Server) handleMySubreddits(w http.ResponseWriter, r *http.Request) {
+	account := s.authenticate(w, r)
+	if account == nil {
+		return
+	}
+	s.mu.Lock()
+	var children []listingChild
+	for _, name := range account.Subreddits {
+		children = append(children, listingChild{Kind: "t5", Data: map[string]interface{}{
+			"name":           "t5_" + strings.ToLower(name),
+			"display_name":   name,
+			"subreddit_type": "public",
+		}})
+	}
+	for _, name := range account.Followed {
+		children = append(children, listingChild{Kind: "t5", Data: map[string]interface{}{
+			"name":           "t5_u_" + strings.ToLower(name),
+			"display_name":   "u_" + name,
+			"subreddit_type": "user",
+		}})
+	}
+	s.mu.Unlock()
+	writeListing(w, r, children)
+}
+
+// handlePostListing returns a handler listing the posts and comments that list returns for the authenticated account.
+// Like Reddit's saved, upvoted, downvoted and hidden listings, it is private to the account itself.
+func (s *Server) handlePostListing(list func(account *Account) []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		account := s.authenticateOwner(w, r)
+		if account == nil {
+			return
+		}
+		s.mu.Lock()
+		children := postChildren(list(account))
+		s.mu.Unlock()
+		writeListing(w, r, children)
+	}
+}
+
+// postChildren returns the listing children of the posts (t3_) and comments (t1_) with the given full names.
+func postChildren(fullNames []string) []listingChild {
+	children := make([]listingChild, 0, len(fullNames))
+	for _, fullName := range fullNames {
+		if id, ok := strings.CutPrefix(fullName, "t1_"); ok {
+			children = append(children, listingChild{Kind: "t1", Data: map[string]interface{}{
+				"name":       fullName,
+				"id":         id,
+				"body":       "Comment " + id,
+				"link_title": "Post of comment " + id,
+				"link_id":    "t3_link" + id,
+				"subreddit":  "test",
+				"author":     "reddittest",
+				"permalink":  "/r/test/comments/link" + id + "/_/" + id + "/",
+			}})
+			continue
+		}
+		id := strings.TrimPrefix(fullName, "t3_")
+		children = append(children, listingChild{Kind: "t3", Data: map[string]interface{}{
+			"name":      fullName,
+			"id":        id,
+			"title":     "Post " + id,
+			"subreddit": "test",
+			"author":    "reddittest",
+			"permalink": "/r/test/comments/" + id + "/",
+			"url":       "https://www.reddit.com/r/test/comments/" + id + "/",
+		}})
+	}
+	return children
+}
+
+func (s *Server) handleSubscribe(w http.ResponseWriter, r *http.Request) {
+	account := s.authenticate(w, r)
+	if account == nil {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"message": "Bad Request", "error": http.StatusBadRequest})
+		return
+	}
+	names := strings.Split(r.PostForm.Get("sr_name"), ",")
+	s.mu.Lock()
+	switch r.PostForm.Get("action") {
+	case "sub":
+		for _, name := range names {
+			account.Subreddits = addName(account.Subreddits, name, false)
+		}
+	case "unsub":
+		for _, name := range names {
+			account.Subreddits = removeName(account.Subreddits, name)
+		}
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{})
+}
+
+func (s *Server) handleSave(w http.ResponseWriter, r *http.Request) {
+	s.handlePostAction(w, r, func(account *Account, id string) {
+		account.Saved = addName(removeName(account.Saved, id), id, true)
+	})
+}
+
+func (s *Server) handleUnsave(w http.ResponseWriter, r *http.Request) {
+	s.handlePostAction(w, r, func(account *Account, id string) {
+		account.Saved = removeName(account.Saved, id)
+	})
+}
+
+func (s *Server) handleHide(w http.ResponseWriter, r *http.Request) {
+	s.handlePostAction(w, r, func(account *Account, id string) {
+		account.Hidden = addName(removeName(account.Hidden, id), id, true)
+	})
+}
+
+func (s *Server) handleUnhide(w http.ResponseWriter, r *http.Request) {
+	s.handlePostAction(w, r, func(account *Account, id string) {
+		account.Hidden = removeName(account.Hidden, id)
+	})
+}
+
+// handleVote casts (dir 1 or -1) or clears (dir 0) the authenticated account's vote on a post.
+func (s *Server) handleVote(w http.ResponseWriter, r *http.Request) {
+	s.handlePostAction(w, r, func(account *Account, id string) {
+		account.Upvoted = removeName(account.Upvoted, id)
+		account.Downvoted = removeName(account.Downvoted, id)
+		switch r.PostForm.Get("dir") {
+		case "1":
+			account.Upvoted = addName(account.Upvoted, id, true)
+		case "-1":
+			account.Downvoted = addName(account.Downvoted, id, true)
+		}
+	})
+}
+
+// handlePostAction applies apply to the post named by the "id" form field under s.mu.
+func (s *Server) handlePostAction(w http.ResponseWriter, r *http.Request, apply func(account *Account, id string)) {
+	account := s.authenticate(w, r)
+	if account == nil {
+		return
+	}
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("id") == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"message": "Bad Request", "error": http.StatusBadRequest})
+		return
+	}
+	s.mu.Lock()
+	apply(account, r.PostForm.Get("id"))
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{})
+}
+
+// handleMyMultis lists the custom feeds of the authenticated account, with their members.
+func (s *Server) handleMyMultis(w http.ResponseWriter, r *http.Request) {
+	account := s.authenticate(w, r)
+	if account == nil {
+		return
+	}
+	s.mu.Lock()
+	multis := make([]map[string]interface{}, 0, len(account.Multireddits))
+	for _, multi := range account.Multireddits {
+		members := make([]map[string]string, 0, len(multi.Subreddits))
+		for _, sr := range multi.Subreddits {
+			members = append(members, map[string]string{"name": sr})
+		}
+		multis = append(multis, map[string]interface{}{"kind": "LabeledMulti", "data": map[string]interface{}{
+			"name":         multi.Name,
+			"display_name": multi.DisplayName,
+			"visibility":   multi.Visibility,
+			"path":         "/user/" + account.Name + "/m/" + multi.Name + "/",
+			"subreddits":   members,
+		}})
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, multis)
+}
+
+// handleCreateMulti creates a custom feed from the JSON "model" form field. Feed names are unique per account.
+func (s *Server) handleCreateMulti(w http.ResponseWriter, r *http.Request) {
+	account := s.authenticateOwner(w, r)
+	if account == nil {
+		return
+	}
+	var model struct {
+		DisplayName string `json:"display_name"`
+		Visibility  string `json:"visibility"`
+		Subreddits  []struct {
+			Name string `json:"name"`
+		} `json:"subreddits"`
+	}
+	if err := r.ParseForm(); err != nil || json.Unmarshal([]byte(r.PostForm.Get("model")), &model) != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"message": "Bad Request", "error": http.StatusBadRequest})
+		return
+	}
+	multi := Multireddit{Name: chi.URLParam(r, "multi"), DisplayName: model.DisplayName, Visibility: model.Visibility}
+	for _, sr := range model.Subreddits {
+		multi.Subreddits = addName(multi.Subreddits, sr.Name, false)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if findMulti(account, multi.Name) != nil {
+		writeJSON(w, http.StatusConflict, map[string]interface{}{"explanation": "that multireddit already exists", "reason": "MULTI_EXISTS"})
+		return
+	}
+	account.Multireddits = append(account.Multireddits, multi)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"kind": "LabeledMulti", "data": map[string]interface{}{"name": multi.Name}})
+}
+
+// handleAddMultiSubreddit adds a subreddit to a custom feed, answering 200 if it already was a member.
+func (s *Server) handleAddMultiSubreddit(w http.ResponseWriter, r *http.Request) {
+	account := s.authenticateOwner(w, r)
+	if account == nil {
+		return
+	}
+	sr := chi.URLParam(r, "subreddit")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	multi := findMulti(account, chi.URLParam(r, "multi"))
+	if multi == nil {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"message": "Not Found", "error": http.StatusNotFound})
+		return
+	}
+	members := len(multi.Subreddits)
+	multi.Subreddits = addName(multi.Subreddits, sr, false)
+	status := http.StatusCreated
+	if len(multi.Subreddits) == members {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, map[string]string{"name": sr})
+}
+
+// findMulti returns the account's custom feed with the given name, compared case-insensitively, or nil. The caller must hold s.mu.
+func findMulti(account *Account, name string) *Multireddit {
+	for i := range account.Multireddits {
+		if strings.EqualFold(account.Multireddits[i].Name, name) {
+			return &account.Multireddits[i]
+		}
+	}
+	return nil
+}
+
+// handlePrefs returns the preferences of the authenticated account, after applying the JSON body of a PATCH.
+// A PATCH with any key the account does not have is rejected as a whole with 400, as Reddit does for invalid values.
+func (s *Server) handlePrefs(w http.ResponseWriter, r *http.Request) {
+	account := s.authenticate(w, r)
+	if account == nil {
+		return
+	}
+	var update map[string]interface{}
+	if r.Method == http.MethodPatch {
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"message": "Bad Request", "error": http.StatusBadRequest})
+			return
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range update {
+		if _, ok := account.Prefs[key]; !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"explanation": "invalid value", "fields": []string{key}, "reason": "BAD_PREF"})
+			return
+		}
+	}
+	for key, value := range update {
+		account.Prefs[key] = value
+	}
+	writeJSON(w, http.StatusOK, account.Prefs)
+}
+
+// handleBlocked lists the users blocked by the authenticated account, all at once as Reddit does.
+func (s *Server) handleBlocked(w http.ResponseWriter, r *http.Request) {
+	account := s.authenticate(w, r)
+	if account == nil {
+		return
+	}
+	s.mu.Lock()
+	children := make([]map[string]string, 0, len(account.Blocked))
+	for _, username := range account.Blocked {
+		children = append(children, map[string]string{"name": username, "id": "t2_" + strings.ToLower(username)})
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"kind": "UserList", "data": map[string]interface{}{"children": children}})
+}
+
+// handleBlockUser blocks the user named by the "name" form field.
+func (s *Server) handleBlockUser(w http.ResponseWriter, r *http.Request) {
+	account := s.authenticate(w, r)
+	if account == nil {
+		return
+	}
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("name") == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"message": "Bad Request", "error": http.StatusBadRequest})
+		return
+	}
+	s.mu.Lock()
+	account.Blocked = addName(account.Blocked, r.PostForm.Get("name"), false)
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{})
+}
+
+// handleFriend follows (PUT) or unfollows (DELETE) a user.
+func (s *Server) handleFriend(w http.ResponseWriter, r *http.Request) {
+	account := s.authenticate(w, r)
+	if account == nil {
+		return
+	}
+	username := chi.URLParam(r, "username")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Method == http.MethodDelete {
+		account.Followed = removeName(account.Followed, username)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	account.Followed = addName(account.Followed, username, false)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"name": username})
+}
+
+// listingChild is one item of a listing response.
+type listingChild struct {
+	Kind string                 `json:"kind"`
+	Data map[string]interface{} `json:"data"`
+}
+
+// writeListing writes one page of children, honouring the request's "limit" and "after" parameters like Reddit does.
+func writeListing(w http.ResponseWriter, r *http.Request, children []listingChild) {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 25
+	}
+	start := 0
+	if after := r.URL.Query().Get("after"); after != "" {
+		for i, child := range children {
+			if child.Data["name"] == after {
+				start = i + 1
+				break
+			}
+		}
+	}
+	end := start + limit
+	if end > len(children) {
+		end = len(children)
+	}
+	page := children[start:end]
+	after := ""
+	if end < len(children) && len(page) > 0 {
+		after = fmt.Sprint(page[len(page)-1].Data["name"])
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"kind": "Listing",
+		"data": map[string]interface{}{"after": after, "children": page},
+	})
+}
+
+// addName appends name, or prepends it when first is set, unless the list already has it (case-insensitively).
+func addName(names []string, name string, first bool) []string {
+	for _, existing := range names {
+		if strings.EqualFold(existing, name) {
+			return names
+		}
+	}
+	if first {
+		return append([]string{name}, names...)
+	}
+	return append(names, name)
+}
+
+// removeName returns names without name, compared case-insensitively.
+func removeName(names []string, name string) []string {
+	kept := names[:0:0]
+	for _, existing := range names {
+		if !strings.EqualFold(existing, name) {
+			kept = append(kept, existing)
+		}
+	}
+	return kept
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
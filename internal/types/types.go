@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 // MigrationRequestType defines the structure for the migration request body.
 // It includes authentication data for old and new accounts, and user preferences for migration.
 type MigrationRequestType struct {
@@ -10,16 +12,29 @@ type MigrationRequestType struct {
 	NewAccountToken    string          `json:"new_account_token,omitempty"`    // For OAuth-based auth
 	OldAccountUsername string          `json:"old_account_username,omitempty"` // For OAuth-based auth
 	NewAccountUsername string          `json:"new_account_username,omitempty"` // For OAuth-based auth
+	OldAccountProfile  string          `json:"old_account_profile,omitempty"`  // Saved vault profile used instead of the old account's cookie or token
+	NewAccountProfile  string          `json:"new_account_profile,omitempty"`  // Saved vault profile used instead of the new account's cookie or token
 	Preferences        PreferencesType `json:"preferences"`
 }
 
 // PreferencesType defines the user's choices for the migration process.
 // Each boolean field indicates whether a specific migration or deletion action should be performed.
 type PreferencesType struct {
-	MigrateSubredditBool bool `json:"migrate_subreddit_bool"`
-	MigratePostBool      bool `json:"migrate_post_bool"`
-	DeletePostBool       bool `json:"delete_post_bool"`
-	DeleteSubredditBool  bool `json:"delete_subreddit_bool"`
+	MigrateSubredditBool    bool     `json:"migrate_subreddit_bool"`
+	MigratePostBool         bool     `json:"migrate_post_bool"`
+	DeletePostBool          bool     `json:"delete_post_bool"`
+	DeleteSubredditBool     bool     `json:"delete_subreddit_bool"`
+	MigrateUpvotesBool      bool     `json:"migrate_upvotes_bool"`            // Re-cast the old account's upvotes on the new account
+	MigrateDownvotesBool    bool     `json:"migrate_downvotes_bool"`          // Re-cast the old account's downvotes on the new account
+	MigrateHiddenBool       bool     `json:"migrate_hidden_bool"`             // Hide the old account's hidden posts on the new account
+	UnhideOldBool           bool     `json:"unhide_old_bool"`                 // Unhide migrated posts on the old account
+	MigrateMultiredditsBool bool     `json:"migrate_multireddits_bool"`       // Recreate the old account's custom feeds on the new account
+	SelectedMultireddits    []string `json:"selected_multireddits,omitempty"` // Limits MigrateMultiredditsBool to these feed names; empty means all
+	MigrateBlockedBool      bool     `json:"migrate_blocked_bool"`            // Block the old account's blocked users on the new account
+	MigratePrefsBool        bool     `json:"migrate_prefs_bool"`              // Copy account preferences to the new account
+	PrefKeys                []string `json:"pref_keys,omitempty"`             // Preference keys to copy; empty means reddit.DefaultPrefKeys
+	PreserveOrderBool       bool     `json:"preserve_order_bool,omitempty"`   // Save posts strictly one at a time, oldest first, so the saved list keeps its order
+	DryRun                  bool     `json:"dry_run,omitempty"`               // Only compute the plan; neither account is modified
 }
 
 // MigrationResponseType defines the structure of the response sent after a migration attempt.
@@ -33,10 +48,45 @@ type MigrationResponseType struct {
 // MigrationDetails holds the detailed results of migration operations.
 // This structure is embedded within MigrationResponseType.
 type MigrationDetails struct {
-	SubscribeSubreddit   ManageSubredditResponseType `json:"subscribeSubreddit"`
-	UnsubscribeSubreddit ManageSubredditResponseType `json:"unsubscribeSubreddit"`
-	SavePost             ManagePostResponseType      `json:"savePost"`
-	UnsavePost           ManagePostResponseType      `json:"unsavePost"`
+	SubscribeSubreddit   ManageSubredditResponseType   `json:"subscribeSubreddit"`
+	UnsubscribeSubreddit ManageSubredditResponseType   `json:"unsubscribeSubreddit"`
+	SavePost             ManagePostResponseType        `json:"savePost"`
+	UnsavePost           ManagePostResponseType        `json:"unsavePost"`
+	UpvotePost           ManagePostResponseType        `json:"upvotePost"`
+	DownvotePost         ManagePostResponseType        `json:"downvotePost"`
+	HidePost             ManagePostResponseType        `json:"hidePost"`
+	UnhidePost           ManagePostResponseType        `json:"unhidePost"`
+	Multireddits         ManageMultiredditResponseType `json:"multireddits"`
+	BlockUser            ManageSubredditResponseType   `json:"blockUser"`
+	Prefs                ManagePrefsResponseType       `json:"prefs"`
+	Plan                 *MigrationPlan                `json:"plan,omitempty"` // Only set for dry runs
+}
+
+// MigrationPlan is the exact set of changes a migration would make, computed by a dry run.
+// Lists contain subreddit display names, post full names and usernames as used by the Reddit API.
+type MigrationPlan struct {
+	SubredditsToSubscribe      []string     `json:"subreddits_to_subscribe"`
+	SubredditsAlreadyPresent   []string     `json:"subreddits_already_present"` // Already subscribed on the new account
+	SubredditsToUnsubscribe    []string     `json:"subreddits_to_unsubscribe"`  // From the old account
+	PostsToSave                []string     `json:"posts_to_save"`              // In the order they would be saved
+	PostsAlreadySaved          []string     `json:"posts_already_saved"`        // Already saved on the new account
+	PostsToUnsave              []string     `json:"posts_to_unsave"`            // From the old account
+	UsersToFollow              []string     `json:"users_to_follow"`
+	UsersAlreadyFollowed       []string     `json:"users_already_followed"` // Already followed on the new account
+	PostsToUpvote              []string     `json:"posts_to_upvote"`
+	PostsAlreadyUpvoted        []string     `json:"posts_already_upvoted"` // Already upvoted on the new account
+	PostsToDownvote            []string     `json:"posts_to_downvote"`
+	PostsAlreadyDownvoted      []string     `json:"posts_already_downvoted"` // Already downvoted on the new account
+	PostsToHide                []string     `json:"posts_to_hide"`
+	PostsAlreadyHidden         []string     `json:"posts_already_hidden"` // Already hidden on the new account
+	PostsToUnhide              []string     `json:"posts_to_unhide"`      // On the old account
+	MultiredditsToCreate       []string     `json:"multireddits_to_create"`
+	MultiredditsToMerge        []string     `json:"multireddits_to_merge"`        // Existing feeds that get missing subreddits, as "name: sub1, sub2"
+	MultiredditsAlreadyPresent []string     `json:"multireddits_already_present"` // Existing feeds that already have every subreddit
+	UsersToBlock               []string     `json:"users_to_block"`
+	UsersAlreadyBlocked        []string     `json:"users_already_blocked"`
+	PrefChanges                []PrefChange `json:"pref_changes"` // Before is the new account's value, After the old account's
+	PrefsUnchanged             []string     `json:"prefs_unchanged"`
 }
 
 // SubredditActionType defines the action to be performed on a subreddit (subscribe or unsubscribe).
@@ -47,6 +97,8 @@ const (
 	SubscribeAction SubredditActionType = "sub"
 	// UnsubscribeAction indicates an action to unsubscribe from a subreddit.
 	UnsubscribeAction SubredditActionType = "unsub"
+	// BlockAction indicates an action to block a user. It is only used by reddit.BlockUsers.
+	BlockAction SubredditActionType = "block"
 )
 
 // ManageSubredditResponseType defines the structure for the response of managing subreddits.
@@ -59,6 +111,35 @@ type ManageSubredditResponseType struct {
 	FailedSubreddits []string
 }
 
+// ManageMultiredditResponseType defines the structure for the response of copying multireddits (custom feeds).
+// Feeds missing on the account are created; existing feeds of the same name get the missing member subreddits added.
+type ManageMultiredditResponseType struct {
+	Error              bool
+	CreatedCount       int
+	MergedCount        int
+	FailedCount        int
+	FailedMultireddits []string
+}
+
+// PrefChange is one entry of a key-by-key preference diff.
+// Values are kept as decoded from JSON, since preferences mix booleans, numbers, strings and null.
+type PrefChange struct {
+	Key    string      `json:"key"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// ManagePrefsResponseType defines the structure for the response of copying account preferences.
+// Changes holds the destination's value before and after the update for every key that changed.
+// When the update fails, it holds the attempted changes, with the values that were sent as After.
+type ManagePrefsResponseType struct {
+	Error      bool
+	StatusCode int
+	Changes    []PrefChange
+	Unchanged  []string // Selected keys that already had the source's value
+	Skipped    []string // Selected keys the source account does not have
+}
+
 // PostActionType defines the action to be performed on a post (save or unsave).
 type PostActionType string
 
@@ -67,6 +148,14 @@ const (
 	SaveAction PostActionType = "save"
 	// UnsaveAction indicates an action to unsave a post.
 	UnsaveAction PostActionType = "unsave"
+	// UpvoteAction indicates an action to upvote a post.
+	UpvoteAction PostActionType = "upvote"
+	// DownvoteAction indicates an action to downvote a post.
+	DownvoteAction PostActionType = "downvote"
+	// HideAction indicates an action to hide a post.
+	HideAction PostActionType = "hide"
+	// UnhideAction indicates an action to unhide a post.
+	UnhideAction PostActionType = "unhide"
 )
 
 // ManagePostResponseType defines the structure for the response of managing posts.
@@ -74,6 +163,7 @@ const (
 type ManagePostResponseType struct {
 	SuccessCount int
 	FailedCount  int
+	FailedPosts  []string // Full names of the posts that failed
 }
 
 // RedditNameType holds lists of subreddit and user display names and full names.
@@ -129,6 +219,18 @@ type TokenResponseType struct {
 	} `json:"data"`
 }
 
+// CookieImportResponseType defines the response structure for importing cookies from an uploaded cookie file.
+// Cookie holds the extracted reddit.com cookies as a Cookie header value, ready to be used like a pasted cookie.
+type CookieImportResponseType struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Format  string `json:"format,omitempty"` // "cookies.txt", "har" or "firefox"
+	Cookie  string `json:"cookie,omitempty"`
+	Data    struct {
+		Username string `json:"username"`
+	} `json:"data"`
+}
+
 // ErrorResponseType defines a generic error response structure from the Reddit API.
 // It usually contains an error code and a descriptive message.
 type ErrorResponseType struct {
@@ -168,6 +270,7 @@ type SavedPostInfo struct {
 	NSFW        bool          `json:"over_18"`
 	Spoiler     bool          `json:"spoiler"`
 	ImageData   PostImageData `json:"image_data"`
+	Index       int           `json:"index"` // Position in the saved listing, shared with saved comments; 0 is the newest
 }
 
 // SubredditInfo contains detailed information about a subreddit for UI display
@@ -186,6 +289,68 @@ type SubredditInfo struct {
 	Created       int64  `json:"created_utc"`
 }
 
+// SavedCommentInfo contains detailed information about a saved comment for UI display
+type SavedCommentInfo struct {
+	ID        string `json:"id"`         // Reddit comment ID (without t1_ prefix)
+	FullName  string `json:"full_name"`  // Full Reddit name (t1_xxxxx)
+	Body      string `json:"body"`       // Comment text (markdown)
+	LinkTitle string `json:"link_title"` // Title of the post the comment belongs to
+	LinkID    string `json:"link_id"`    // Full name of that post (t3_xxxxx)
+	Subreddit string `json:"subreddit"`
+	Author    string `json:"author"`
+	Permalink string `json:"permalink"`
+	Created   int64  `json:"created_utc"`
+	Score     int    `json:"score"`
+	NSFW      bool   `json:"over_18"`
+	Index     int    `json:"index"` // Position in the saved listing, shared with saved posts; 0 is the newest
+}
+
+// MultiredditInfo contains information about a multireddit (custom feed) for UI display and migration
+type MultiredditInfo struct {
+	Name            string   `json:"name"` // URL name, unique per user
+	DisplayName     string   `json:"display_name"`
+	Description     string   `json:"description_md"`
+	Visibility      string   `json:"visibility"` // "private", "public" or "hidden"
+	Subreddits      []string `json:"subreddits"` // Member subreddit display names
+	Path            string   `json:"path"`       // /user/{username}/m/{name}/
+	IconURL         string   `json:"icon_url"`
+	KeyColor        string   `json:"key_color"`
+	WeightingScheme string   `json:"weighting_scheme"` // "classic" or "fresh"
+}
+
+// GetMultiredditsRequest defines the request structure for fetching multireddits (custom feeds)
+type GetMultiredditsRequest struct {
+	AuthMethod  string `json:"auth_method,omitempty"`  // "cookie" or "oauth"
+	Cookie      string `json:"cookie,omitempty"`       // For cookie-based auth
+	AccessToken string `json:"access_token,omitempty"` // For OAuth-based auth
+	Username    string `json:"username,omitempty"`     // For OAuth-based auth
+}
+
+// GetMultiredditsResponse defines the response structure for multireddits
+type GetMultiredditsResponse struct {
+	Success      bool              `json:"success"`
+	Message      string            `json:"message"`
+	Multireddits []MultiredditInfo `json:"multireddits"`
+	Count        int               `json:"count"`
+}
+
+// GetPrefsRequest defines the request structure for fetching account preferences
+type GetPrefsRequest struct {
+	AuthMethod  string `json:"auth_method,omitempty"`  // "cookie" or "oauth"
+	Cookie      string `json:"cookie,omitempty"`       // For cookie-based auth
+	AccessToken string `json:"access_token,omitempty"` // For OAuth-based auth
+	Username    string `json:"username,omitempty"`     // For OAuth-based auth
+}
+
+// GetPrefsResponse defines the response structure for account preferences.
+// DefaultKeys are the keys copied when no keys are picked.
+type GetPrefsResponse struct {
+	Success     bool                   `json:"success"`
+	Message     string                 `json:"message"`
+	Prefs       map[string]interface{} `json:"prefs"`
+	DefaultKeys []string               `json:"default_keys"`
+}
+
 // GetSavedPostsRequest defines the request structure for fetching saved posts with details
 type GetSavedPostsRequest struct {
 	AuthMethod  string `json:"auth_method,omitempty"`  // "cookie" or "oauth"
@@ -196,10 +361,12 @@ type GetSavedPostsRequest struct {
 
 // GetSavedPostsResponse defines the response structure for saved posts with full details
 type GetSavedPostsResponse struct {
-	Success bool            `json:"success"`
-	Message string          `json:"message"`
-	Posts   []SavedPostInfo `json:"posts"`
-	Count   int             `json:"count"`
+	Success      bool               `json:"success"`
+	Message      string             `json:"message"`
+	Posts        []SavedPostInfo    `json:"posts"`
+	Count        int                `json:"count"`
+	Comments     []SavedCommentInfo `json:"comments"`
+	CommentCount int                `json:"comment_count"`
 }
 
 // GetSubredditsRequest defines the request structure for fetching subreddits with details
@@ -220,17 +387,29 @@ type GetSubredditsResponse struct {
 
 // CustomMigrationRequest defines the structure for custom selection migration
 type CustomMigrationRequest struct {
-	AuthMethod          string   `json:"auth_method,omitempty"`          // "cookie" or "oauth"
-	OldAccountCookie    string   `json:"old_account_cookie,omitempty"`   // For cookie-based auth
-	NewAccountCookie    string   `json:"new_account_cookie,omitempty"`   // For cookie-based auth
-	OldAccountToken     string   `json:"old_account_token,omitempty"`    // For OAuth-based auth
-	NewAccountToken     string   `json:"new_account_token,omitempty"`    // For OAuth-based auth
-	OldAccountUsername  string   `json:"old_account_username,omitempty"` // For OAuth-based auth
-	NewAccountUsername  string   `json:"new_account_username,omitempty"` // For OAuth-based auth
-	SelectedSubreddits  []string `json:"selected_subreddits"`            // List of display names
-	SelectedPosts       []string `json:"selected_posts"`                 // List of full names (t3_xxxxx)
-	DeleteOldSubreddits bool     `json:"delete_old_subreddits"`
-	DeleteOldPosts      bool     `json:"delete_old_posts"`
+	AuthMethod           string   `json:"auth_method,omitempty"`          // "cookie" or "oauth"
+	OldAccountCookie     string   `json:"old_account_cookie,omitempty"`   // For cookie-based auth
+	NewAccountCookie     string   `json:"new_account_cookie,omitempty"`   // For cookie-based auth
+	OldAccountToken      string   `json:"old_account_token,omitempty"`    // For OAuth-based auth
+	NewAccountToken      string   `json:"new_account_token,omitempty"`    // For OAuth-based auth
+	OldAccountUsername   string   `json:"old_account_username,omitempty"` // For OAuth-based auth
+	NewAccountUsername   string   `json:"new_account_username,omitempty"` // For OAuth-based auth
+	OldAccountProfile    string   `json:"old_account_profile,omitempty"`  // Saved vault profile used instead of the old account's cookie or token
+	NewAccountProfile    string   `json:"new_account_profile,omitempty"`  // Saved vault profile used instead of the new account's cookie or token
+	SelectedSubreddits   []string `json:"selected_subreddits"`            // List of display names
+	SelectedPosts        []string `json:"selected_posts"`                 // List of full names (t3_xxxxx posts and t1_xxxxx comments) in saved listing order, newest first
+	DeleteOldSubreddits  bool     `json:"delete_old_subreddits"`
+	DeleteOldPosts       bool     `json:"delete_old_posts"`
+	MigrateUpvotes       bool     `json:"migrate_upvotes,omitempty"`       // Re-cast all of the old account's upvotes
+	MigrateDownvotes     bool     `json:"migrate_downvotes,omitempty"`     // Re-cast all of the old account's downvotes
+	MigrateHidden        bool     `json:"migrate_hidden,omitempty"`        // Hide all of the old account's hidden posts
+	SelectedMultireddits []string `json:"selected_multireddits,omitempty"` // List of custom feed names
+	MigrateBlocked       bool     `json:"migrate_blocked,omitempty"`       // Block all of the old account's blocked users
+	MigratePrefs         bool     `json:"migrate_prefs,omitempty"`         // Copy account preferences
+	PrefKeys             []string `json:"pref_keys,omitempty"`             // Preference keys to copy; empty means reddit.DefaultPrefKeys
+	UnhideOld            bool     `json:"unhide_old,omitempty"`            // Unhide migrated posts on the old account
+	PreserveOrder        bool     `json:"preserve_order,omitempty"`        // Save posts strictly one at a time, oldest first, so the saved list keeps its order
+	DryRun               bool     `json:"dry_run,omitempty"`               // Only compute the plan; neither account is modified
 }
 
 // DetailedPostData represents the full Reddit post data structure for parsing API responses
@@ -305,6 +484,42 @@ type DetailedPostData struct {
 	} `json:"data"`
 }
 
+// DetailedCommentData represents the Reddit comment data structure returned in saved listings
+type DetailedCommentData struct {
+	Kind string `json:"kind"`
+	Data struct {
+		ID         string  `json:"id"`
+		Name       string  `json:"name"`
+		Body       string  `json:"body"`
+		LinkTitle  string  `json:"link_title"`
+		LinkID     string  `json:"link_id"`
+		Subreddit  string  `json:"subreddit"`
+		Author     string  `json:"author"`
+		Permalink  string  `json:"permalink"`
+		CreatedUTC float64 `json:"created_utc"`
+		Score      int     `json:"score"`
+		Over18     bool    `json:"over_18"`
+	} `json:"data"`
+}
+
+// DetailedMultiredditData represents the Reddit multireddit (LabeledMulti) structure returned by /api/multi/mine
+type DetailedMultiredditData struct {
+	Kind string `json:"kind"`
+	Data struct {
+		Name            string `json:"name"`
+		DisplayName     string `json:"display_name"`
+		DescriptionMD   string `json:"description_md"`
+		Visibility      string `json:"visibility"`
+		Path            string `json:"path"`
+		IconURL         string `json:"icon_url"`
+		KeyColor        string `json:"key_color"`
+		WeightingScheme string `json:"weighting_scheme"`
+		Subreddits      []struct {
+			Name string `json:"name"`
+		} `json:"subreddits"`
+	} `json:"data"`
+}
+
 // DetailedSubredditData represents the full Reddit subreddit data structure
 type DetailedSubredditData struct {
 	Kind string `json:"kind"`
@@ -346,9 +561,236 @@ type AccountCountsRequest struct {
 
 // AccountCountsResponse defines the response structure for account counts
 type AccountCountsResponse struct {
-	Success         bool   `json:"success"`
-	Message         string `json:"message"`
-	Username        string `json:"username"`
-	SubredditCount  int    `json:"subreddit_count"`
-	SavedPostsCount int    `json:"saved_posts_count"`
+	Success            bool   `json:"success"`
+	Message            string `json:"message"`
+	Username           string `json:"username"`
+	SubredditCount     int    `json:"subreddit_count"`
+	SavedPostsCount    int    `json:"saved_posts_count"`
+	SavedCommentsCount int    `json:"saved_comments_count"`
+}
+
+// ArchiveSchemaVersion is the schema version written to new account archives.
+// Increase it whenever a change to AccountArchive would break readers of older archives.
+const ArchiveSchemaVersion = 3
+
+// AccountArchive is a self-describing backup of an account's subreddits, followed users, saved posts and comments,
+// and custom feeds. Subreddits and saved posts are kept with full details so the archive is useful on its own,
+// even after the account has been deleted. Votes, hidden posts, blocked users and preferences are not archived;
+// only a direct migration copies them.
+type AccountArchive struct {
+	SchemaVersion int                `json:"schema_version"`
+	ExportedAt    time.Time          `json:"exported_at"`
+	Username      string             `json:"username"`
+	Subreddits    []SubredditInfo    `json:"subreddits"`
+	FollowedUsers []string           `json:"followed_users"` // User profile display names (u_xxxxx)
+	SavedPosts    []SavedPostInfo    `json:"saved_posts"`    // Newest first, as listed by Reddit
+	SavedComments []SavedCommentInfo `json:"saved_comments"` // Newest first; added in schema version 2. Index interleaves them with the posts
+	Multireddits  []MultiredditInfo  `json:"multireddits"`   // Added in schema version 3
+}
+
+// ExportRequest defines the request structure for exporting an account archive
+type ExportRequest struct {
+	AuthMethod  string `json:"auth_method,omitempty"`  // "cookie" or "oauth"
+	Cookie      string `json:"cookie,omitempty"`       // For cookie-based auth
+	AccessToken string `json:"access_token,omitempty"` // For OAuth-based auth
+	Username    string `json:"username,omitempty"`     // For OAuth-based auth
+}
+
+// ImportRequest defines the request structure for importing an archive into a destination account.
+// Only the selected items are imported; selections are matched against the archive and items already
+// present on the destination account are skipped, as in a custom migration.
+type ImportRequest struct {
+	AuthMethod           string         `json:"auth_method,omitempty"`  // "cookie" or "oauth"
+	Cookie               string         `json:"cookie,omitempty"`       // For cookie-based auth
+	AccessToken          string         `json:"access_token,omitempty"` // For OAuth-based auth
+	Username             string         `json:"username,omitempty"`     // For OAuth-based auth
+	Profile              string         `json:"profile,omitempty"`      // Saved vault profile used instead of a cookie or token
+	Archive              AccountArchive `json:"archive"`
+	SelectedSubreddits   []string       `json:"selected_subreddits"`             // List of display names
+	SelectedUsers        []string       `json:"selected_users"`                  // List of user profile display names (u_xxxxx)
+	SelectedMultireddits []string       `json:"selected_multireddits,omitempty"` // List of custom feed names
+	SelectedPosts        []string       `json:"selected_posts"`                  // List of full names (t3_xxxxx posts and t1_xxxxx comments)
+	PreserveOrder        bool           `json:"preserve_order,omitempty"`        // Save posts strictly one at a time, oldest first, so the saved list keeps its order
+	DryRun               bool           `json:"dry_run,omitempty"`               // Only compute the plan; the account is not modified
+}
+
+// JobStatus describes the lifecycle state of a background migration job.
+type JobStatus string
+
+const (
+	// JobPending indicates the job has been registered but has not started yet.
+	JobPending JobStatus = "pending"
+	// JobRunning indicates the job is currently executing.
+	JobRunning JobStatus = "running"
+	// JobCompleted indicates the job finished and every operation succeeded.
+	JobCompleted JobStatus = "completed"
+	// JobFailed indicates the job finished with errors or could not run at all.
+	JobFailed JobStatus = "failed"
+	// JobCancelled indicates the job was stopped before it finished.
+	JobCancelled JobStatus = "cancelled"
+)
+
+// JobInfo is a point-in-time snapshot of a background migration job.
+type JobInfo struct {
+	ID         string                 `json:"id"`
+	Kind       string                 `json:"kind"` // "migrate", "migrate-custom", "import" or "resume"
+	Status     JobStatus              `json:"status"`
+	CreatedAt  time.Time              `json:"created_at"`
+	StartedAt  *time.Time             `json:"started_at,omitempty"`
+	FinishedAt *time.Time             `json:"finished_at,omitempty"`
+	Result     *MigrationResponseType `json:"result,omitempty"` // Set once the job has finished
+}
+
+// JobResponseType defines the response structure for endpoints returning a single job.
+type JobResponseType struct {
+	Success bool    `json:"success"`
+	Message string  `json:"message"`
+	Job     JobInfo `json:"job"`
+}
+
+// JobListResponseType defines the response structure for listing background jobs.
+type JobListResponseType struct {
+	Success bool      `json:"success"`
+	Message string    `json:"message"`
+	Jobs    []JobInfo `json:"jobs"`
+	Count   int       `json:"count"`
+}
+
+// ProgressEventType identifies the kind of progress event emitted while a migration job runs.
+type ProgressEventType string
+
+const (
+	// PhaseEvent marks the start of a migration phase (e.g. fetching subreddits, saving posts).
+	PhaseEvent ProgressEventType = "phase"
+	// SubredditChunkEvent reports the outcome of one subscribe/unsubscribe chunk.
+	SubredditChunkEvent ProgressEventType = "subreddit_chunk"
+	// UserEvent reports the outcome of following or unfollowing a single user.
+	UserEvent ProgressEventType = "user"
+	// PostEvent reports the outcome of saving or unsaving a single post.
+	PostEvent ProgressEventType = "post"
+	// MultiredditEvent reports the outcome of creating or merging a single multireddit (custom feed).
+	MultiredditEvent ProgressEventType = "multireddit"
+	// RateLimitPauseEvent reports that workers were paused after hitting Reddit's rate limit.
+	RateLimitPauseEvent ProgressEventType = "rate_limit_pause"
+	// RateLimitResumeEvent reports that workers resumed after a rate limit pause.
+	RateLimitResumeEvent ProgressEventType = "rate_limit_resume"
+	// DoneEvent is the final event of a job and carries its terminal status.
+	DoneEvent ProgressEventType = "done"
+)
+
+// Migration phases reported through PhaseEvent.
+const (
+	PhaseFetchSubreddits       = "fetch_subreddits"
+	PhaseSubscribeSubreddits   = "subscribe_subreddits"
+	PhaseFollowUsers           = "follow_users"
+	PhaseUnsubscribeSubreddits = "unsubscribe_subreddits"
+	PhaseFetchPosts            = "fetch_posts"
+	PhaseSavePosts             = "save_posts"
+	PhaseUnsavePosts           = "unsave_posts"
+	PhaseFetchVotes            = "fetch_votes"
+	PhaseUpvotePosts           = "upvote_posts"
+	PhaseDownvotePosts         = "downvote_posts"
+	PhaseFetchHidden           = "fetch_hidden"
+	PhaseHidePosts             = "hide_posts"
+	PhaseUnhidePosts           = "unhide_posts"
+	PhaseFetchMultireddits     = "fetch_multireddits"
+	PhaseCopyMultireddits      = "copy_multireddits"
+	PhaseFetchBlocked          = "fetch_blocked"
+	PhaseBlockUsers            = "block_users"
+	PhaseFetchPrefs            = "fetch_prefs"
+	PhaseCopyPrefs             = "copy_prefs"
+)
+
+// ProgressEvent is a single progress update of a running migration job, streamed to clients over SSE.
+// Completed and Total are counted within the current phase.
+type ProgressEvent struct {
+	Seq         int               `json:"seq"`
+	Type        ProgressEventType `json:"type"`
+	Time        time.Time         `json:"time"`
+	Phase       string            `json:"phase,omitempty"`
+	Action      string            `json:"action,omitempty"`
+	Item        string            `json:"item,omitempty"`         // Post full name or username
+	Items       []string          `json:"items,omitempty"`        // Subreddits in a chunk
+	FailedItems []string          `json:"failed_items,omitempty"` // Subreddits that failed in a chunk
+	Success     bool              `json:"success"`
+	Error       string            `json:"error,omitempty"`
+	Completed   int               `json:"completed,omitempty"`
+	Total       int               `json:"total,omitempty"`
+	Status      JobStatus         `json:"status,omitempty"` // Only set on DoneEvent
+	Message     string            `json:"message,omitempty"`
+}
+
+// ResumeMigrationRequest defines the request body for resuming a migration from its journal.
+// Credentials are not stored in the journal, so both accounts must be authenticated again.
+// The accounts must be the same ones the original migration ran against.
+type ResumeMigrationRequest struct {
+	AuthMethod         string `json:"auth_method,omitempty"`          // "cookie" or "oauth"
+	OldAccountCookie   string `json:"old_account_cookie,omitempty"`   // For cookie-based auth
+	NewAccountCookie   string `json:"new_account_cookie,omitempty"`   // For cookie-based auth
+	OldAccountToken    string `json:"old_account_token,omitempty"`    // For OAuth-based auth
+	NewAccountToken    string `json:"new_account_token,omitempty"`    // For OAuth-based auth
+	OldAccountUsername string `json:"old_account_username,omitempty"` // For OAuth-based auth
+	NewAccountUsername string `json:"new_account_username,omitempty"` // For OAuth-based auth
+	OldAccountProfile  string `json:"old_account_profile,omitempty"`  // Saved vault profile used instead of the old account's cookie or token
+	NewAccountProfile  string `json:"new_account_profile,omitempty"`  // Saved vault profile used instead of the new account's cookie or token
+}
+
+// JournalSummary describes a migration checkpoint journal and how many of its items are in each state.
+type JournalSummary struct {
+	JobID       string    `json:"job_id"`
+	Kind        string    `json:"kind"` // Kind of the job that created the journal
+	OldUsername string    `json:"old_username"`
+	NewUsername string    `json:"new_username"`
+	CreatedAt   time.Time `json:"created_at"`
+	Pending     int       `json:"pending"`
+	Done        int       `json:"done"`
+	Failed      int       `json:"failed"`
+}
+
+// JournalListResponseType defines the response structure for listing migration journals.
+type JournalListResponseType struct {
+	Success  bool             `json:"success"`
+	Message  string           `json:"message"`
+	Journals []JournalSummary `json:"journals"`
+	Count    int              `json:"count"`
+}
+
+// VaultProfileSummary describes a saved account profile without any of its secrets.
+type VaultProfileSummary struct {
+	Name       string    `json:"name"`
+	Username   string    `json:"username"`
+	AuthMethod string    `json:"auth_method"` // "oauth" or "cookie"
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// VaultStatusResponseType defines the response structure for the credential vault status and its profiles.
+// Profiles are only listed while the vault is unlocked.
+type VaultStatusResponseType struct {
+	Success  bool                  `json:"success"`
+	Message  string                `json:"message"`
+	Exists   bool                  `json:"exists"`   // A vault file has been written
+	Unlocked bool                  `json:"unlocked"` // The vault is unlocked for this server
+	Profiles []VaultProfileSummary `json:"profiles"`
+}
+
+// VaultUnlockRequest defines the request body for unlocking the credential vault.
+// Unlocking a vault that does not exist yet creates it with the passphrase on the first saved profile.
+type VaultUnlockRequest struct {
+	Passphrase string `json:"passphrase"`
+}
+
+// SaveProfileRequest defines the request body for saving an authenticated account as a vault profile.
+// Exactly one of AccessToken (obtained through the OAuth flow) and Cookie must be set.
+type SaveProfileRequest struct {
+	Name        string `json:"name"`
+	AccessToken string `json:"access_token,omitempty"`
+	Cookie      string `json:"cookie,omitempty"`
+}
+
+// ProfileLoginResponseType defines the response structure for logging in with a vault profile.
+type ProfileLoginResponseType struct {
+	Success     bool   `json:"success"`
+	Message     string `json:"message"`
+	Username    string `json:"username"`
+	AccessToken string `json:"access_token"`
 }
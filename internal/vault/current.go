@@ -0,0 +1,53 @@
+package vault
+
+import (
+	"os"
+	"sync"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+)
+
+// current is the vault unlocked for this process, shared by the web interface, the CLI and background jobs.
+var current = struct {
+	mu    sync.Mutex
+	vault *Vault
+}{}
+
+// Unlock opens the vault at config.VaultPath with passphrase and makes it the current vault.
+func Unlock(passphrase string) (*Vault, error) {
+	v, err := Open(config.VaultPath, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	current.mu.Lock()
+	defer current.mu.Unlock()
+	current.vault = v
+	config.InfoLogger.Printf("Vault: Unlocked %s with %d profiles.", v.Path(), len(v.profiles))
+	return v, nil
+}
+
+// Lock forgets the current vault and its key. Profiles must be unlocked again before they can be used.
+func Lock() {
+	current.mu.Lock()
+	defer current.mu.Unlock()
+	if current.vault != nil {
+		config.InfoLogger.Println("Vault: Locked.")
+	}
+	current.vault = nil
+}
+
+// Current returns the unlocked vault, or ErrLocked.
+func Current() (*Vault, error) {
+	current.mu.Lock()
+	defer current.mu.Unlock()
+	if current.vault == nil {
+		return nil, ErrLocked
+	}
+	return current.vault, nil
+}
+
+// Exists reports whether a vault file has been written at config.VaultPath.
+func Exists() bool {
+	_, err := os.Stat(config.VaultPath)
+	return err == nil
+}
@@ -0,0 +1,297 @@
+// Package vault stores named account profiles (refresh tokens, cookies and OAuth app credentials) in a local file
+// encrypted with a key derived from a passphrase, so accounts can be referred to by name instead of pasting secrets
+// on every run.
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// ErrWrongPassphrase is returned when a vault file cannot be decrypted with the given passphrase.
+var ErrWrongPassphrase = errors.New("wrong vault passphrase or corrupted vault file")
+
+// ErrNotFound is returned when no profile exists with the requested name.
+var ErrNotFound = errors.New("profile not found")
+
+// ErrLocked is returned when the process-wide vault is used before it has been unlocked.
+var ErrLocked = errors.New("vault is locked")
+
+// Authentication methods a profile can hold.
+const (
+	AuthOAuth  = "oauth"
+	AuthCookie = "cookie"
+)
+
+const (
+	fileVersion = 1
+	kdfName     = "pbkdf2-sha256"
+	// kdfIterations follows the current OWASP recommendation for PBKDF2-HMAC-SHA256.
+	kdfIterations = 600000
+	saltSize      = 16
+	keySize       = 32 // AES-256
+)
+
+// profileNamePattern restricts profile names to something safe to type on a command line and show in the UI.
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// Profile is one saved account. OAuth profiles hold the app credentials and a refresh token, cookie profiles hold
+// the full cookie string; access tokens are short-lived and never stored.
+type Profile struct {
+	Name         string    `json:"name"`
+	Username     string    `json:"username"`
+	AuthMethod   string    `json:"auth_method"` // AuthOAuth or AuthCookie
+	ClientID     string    `json:"client_id,omitempty"`
+	ClientSecret string    `json:"client_secret,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Cookie       string    `json:"cookie,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Validate checks that the profile has a usable name and the secrets its authentication method needs.
+func (p Profile) Validate() error {
+	if !profileNamePattern.MatchString(p.Name) {
+		return fmt.Errorf("invalid profile name %q: use up to 64 letters, digits, '.', '_' or '-'", p.Name)
+	}
+	switch p.AuthMethod {
+	case AuthOAuth:
+		if p.ClientID == "" || p.RefreshToken == "" {
+			return fmt.Errorf("profile %s needs a client ID and a refresh token", p.Name)
+		}
+	case AuthCookie:
+		if p.Cookie == "" {
+			return fmt.Errorf("profile %s needs a cookie", p.Name)
+		}
+	default:
+		return fmt.Errorf("profile %s has unknown auth method %q", p.Name, p.AuthMethod)
+	}
+	return nil
+}
+
+// file is the on-disk format. Everything but the KDF parameters is inside the AES-GCM ciphertext.
+type file struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// contents is the plaintext sealed in file.Ciphertext.
+type contents struct {
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// Vault is an unlocked vault file. Changes are written to disk immediately.
+type Vault struct {
+	mu       sync.Mutex
+	path     string
+	salt     []byte
+	key      []byte
+	profiles map[string]Profile
+}
+
+// Open decrypts the vault at path with passphrase. If the file does not exist, an empty vault is returned and the
+// file is created, encrypted with passphrase, on the first change.
+func Open(path, passphrase string) (*Vault, error) {
+	if passphrase == "" {
+		return nil, errors.New("vault passphrase is empty")
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		salt := make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("error generating vault salt: %w", err)
+		}
+		return &Vault{
+			path:     path,
+			salt:     salt,
+			key:      deriveKey(passphrase, salt, kdfIterations),
+			profiles: make(map[string]Profile),
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading vault %s: %w", path, err)
+	}
+
+	var f file
+	if err := json.Unmarshal(content, &f); err != nil {
+		return nil, fmt.Errorf("error parsing vault %s: %w", path, err)
+	}
+	if f.Version != fileVersion || f.KDF != kdfName || f.Iterations <= 0 {
+		return nil, fmt.Errorf("unsupported vault %s: version %d, kdf %q", path, f.Version, f.KDF)
+	}
+
+	key := deriveKey(passphrase, f.Salt, f.Iterations)
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Nonce) != gcm.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+	plaintext, err := gcm.Open(nil, f.Nonce, f.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	var c contents
+	if err := json.Unmarshal(plaintext, &c); err != nil {
+		return nil, fmt.Errorf("error parsing decrypted vault %s: %w", path, err)
+	}
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]Profile)
+	}
+	return &Vault{path: path, salt: f.Salt, key: key, profiles: c.Profiles}, nil
+}
+
+// Path returns the file the vault is stored in.
+func (v *Vault) Path() string {
+	return v.path
+}
+
+// Profiles returns all profiles sorted by name.
+func (v *Vault) Profiles() []Profile {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	profiles := make([]Profile, 0, len(v.profiles))
+	for _, profile := range v.profiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles
+}
+
+// Profile returns the profile called name, or ErrNotFound.
+func (v *Vault) Profile(name string) (Profile, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	profile, ok := v.profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return profile, nil
+}
+
+// Put adds or replaces a profile and saves the vault.
+func (v *Vault) Put(profile Profile) error {
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+	profile.UpdatedAt = time.Now().UTC()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	previous, existed := v.profiles[profile.Name]
+	v.profiles[profile.Name] = profile
+	if err := v.saveLocked(); err != nil {
+		if existed {
+			v.profiles[profile.Name] = previous
+		} else {
+			delete(v.profiles, profile.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete removes a profile and saves the vault. It returns ErrNotFound if there is no such profile.
+func (v *Vault) Delete(name string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	previous, ok := v.profiles[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	delete(v.profiles, name)
+	if err := v.saveLocked(); err != nil {
+		v.profiles[name] = previous
+		return err
+	}
+	return nil
+}
+
+// saveLocked encrypts the profiles with a fresh nonce and atomically replaces the vault file. v.mu must be held.
+func (v *Vault) saveLocked() error {
+	plaintext, err := json.Marshal(contents{Profiles: v.profiles})
+	if err != nil {
+		return fmt.Errorf("error encoding vault: %w", err)
+	}
+	gcm, err := newGCM(v.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("error generating vault nonce: %w", err)
+	}
+	content, err := json.MarshalIndent(file{
+		Version:    fileVersion,
+		KDF:        kdfName,
+		Iterations: kdfIterations,
+		Salt:       v.salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding vault: %w", err)
+	}
+
+	dir := filepath.Dir(v.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("error creating vault directory %s: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, ".vault-*.tmp")
+	if err != nil {
+		return fmt.Errorf("error writing vault: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed.
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing vault: %w", err)
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing vault: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing vault: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), v.path); err != nil {
+		return fmt.Errorf("error writing vault %s: %w", v.path, err)
+	}
+	return nil
+}
+
+// newGCM returns an AES-GCM cipher for key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating vault cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error creating vault cipher: %w", err)
+	}
+	return gcm, nil
+}
+
+// deriveKey derives the AES key from passphrase with PBKDF2-HMAC-SHA256 (RFC 8018).
+func deriveKey(passphrase string, salt []byte, iterations int) []byte {
+	return pbkdf2.Key([]byte(passphrase), salt, iterations, keySize, sha256.New)
+}
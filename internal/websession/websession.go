@@ -0,0 +1,215 @@
+// Package websession keeps other web pages and other users away from the local web UI, which holds the credentials
+// of two whole Reddit accounts.
+//
+// The server generates an access token at startup and opens the UI with it in the URL. The first request carrying
+// the token gets a session cookie; every other request needs that cookie. Requests that change state also need the
+// session's CSRF token in the X-CSRF-Token header and an Origin of the server itself, so a page on another site
+// cannot make the browser act on the user's behalf. Requests for host names the server does not answer to are
+// refused, which defeats DNS rebinding.
+package websession
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+)
+
+// Names of the cookies and of the header carrying the CSRF token.
+const (
+	SessionCookie = "reddit_migrate_session"
+	CSRFCookie    = "csrf_token"
+	CSRFHeader    = "X-CSRF-Token"
+)
+
+// TokenParam is the query parameter carrying the access token in the URL the UI is opened with.
+const TokenParam = "token"
+
+// loopbackHosts are always accepted, on any port, so the UI keeps working behind a port mapping such as Docker's.
+var loopbackHosts = []string{"localhost", "127.0.0.1", "::1"}
+
+// Guard is HTTP middleware enforcing the access token, the session cookie, CSRF tokens and Origin checks.
+type Guard struct {
+	token        string
+	allowedHosts map[string]bool
+
+	mu       sync.Mutex
+	sessions map[string]string // CSRF token by session ID
+}
+
+// NewGuard creates a guard with a fresh access token for a server listening on addr. Requests are accepted for the
+// loopback host names, the host of addr unless it is a wildcard address, and extraHosts.
+func NewGuard(addr string, extraHosts []string) (*Guard, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	g := &Guard{token: token, allowedHosts: make(map[string]bool), sessions: make(map[string]string)}
+	hosts := append(append([]string(nil), loopbackHosts...), extraHosts...)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		if ip := net.ParseIP(host); host != "" && (ip == nil || !ip.IsUnspecified()) {
+			hosts = append(hosts, host)
+		}
+	}
+	for _, host := range hosts {
+		if host = strings.ToLower(strings.Trim(strings.TrimSpace(host), "[]")); host != "" {
+			g.allowedHosts[host] = true
+		}
+	}
+	return g, nil
+}
+
+// URL returns baseURL with the access token added, for opening the UI. Whoever knows the URL can use the UI,
+// so it is only shown to the user who started the server.
+func (g *Guard) URL(baseURL string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/?" + url.Values{TokenParam: {g.token}}.Encode()
+}
+
+// Middleware checks every request before passing it to next.
+func (g *Guard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !g.allowedHost(r.Host) {
+			g.reject(w, r, http.StatusForbidden, "This server does not answer to host "+r.Host+". Add it to ALLOWED_HOSTS to use it.")
+			return
+		}
+
+		if token := r.URL.Query().Get(TokenParam); token != "" {
+			g.login(w, r, token)
+			return
+		}
+
+		csrfToken, ok := g.session(r)
+		if !ok {
+			g.reject(w, r, http.StatusUnauthorized, "Open the link printed in the terminal when the server started to use reddit-migrate.")
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if !sameOrigin(r) {
+				g.reject(w, r, http.StatusForbidden, "Cross-origin request refused.")
+				return
+			}
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get(CSRFHeader)), []byte(csrfToken)) != 1 {
+				g.reject(w, r, http.StatusForbidden, "Missing or invalid CSRF token. Reload the page and try again.")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// login starts a session for a request carrying the access token and redirects to the same URL without it,
+// so the token does not stay in the address bar or the browser history.
+func (g *Guard) login(w http.ResponseWriter, r *http.Request, token string) {
+	if r.Method != http.MethodGet || subtle.ConstantTimeCompare([]byte(token), []byte(g.token)) != 1 {
+		g.reject(w, r, http.StatusUnauthorized, "Invalid access token. Open the link printed in the terminal when the server started.")
+		return
+	}
+
+	sessionID, err := randomToken()
+	if err != nil {
+		config.ErrorLogger.Printf("Error generating session ID: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	csrfToken, err := randomToken()
+	if err != nil {
+		config.ErrorLogger.Printf("Error generating CSRF token: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	g.mu.Lock()
+	g.sessions[sessionID] = csrfToken
+	g.mu.Unlock()
+
+	// Lax, so the cookie comes along when Reddit redirects the browser back to the OAuth callback.
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    sessionID,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	// Readable by the page's script, which copies it into the CSRF header. Other sites cannot read it.
+	http.SetCookie(w, &http.Cookie{
+		Name:     CSRFCookie,
+		Value:    csrfToken,
+		Path:     "/",
+		SameSite: http.SameSiteStrictMode,
+	})
+	config.InfoLogger.Printf("Started a web UI session for %s", r.RemoteAddr)
+
+	target := *r.URL
+	query := target.Query()
+	query.Del(TokenParam)
+	target.RawQuery = query.Encode()
+	http.Redirect(w, r, target.RequestURI(), http.StatusSeeOther)
+}
+
+// session returns the CSRF token of the request's session, if it has one.
+func (g *Guard) session(r *http.Request) (string, bool) {
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return "", false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	csrfToken, ok := g.sessions[cookie.Value]
+	return csrfToken, ok
+}
+
+// allowedHost reports whether hostPort, the Host header of a request, names this server. Ports are not compared.
+func (g *Guard) allowedHost(hostPort string) bool {
+	host := hostPort
+	if h, _, err := net.SplitHostPort(hostPort); err == nil {
+		host = h
+	}
+	return g.allowedHosts[strings.ToLower(strings.Trim(host, "[]"))]
+}
+
+// sameOrigin reports whether a request was sent by a page of this server. Browsers send Origin with every request
+// that changes state; the Referer is only consulted when Origin is missing. Requests with neither are refused.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		referer, err := url.Parse(r.Referer())
+		if err != nil || referer.Host == "" {
+			return false
+		}
+		origin = referer.Scheme + "://" + referer.Host
+	}
+	return origin == "http://"+r.Host || origin == "https://"+r.Host
+}
+
+// reject answers a refused request, in JSON for API requests and in plain text for pages.
+func (g *Guard) reject(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
+	config.InfoLogger.Printf("Refused %s %s from %s: %s", r.Method, r.URL.Path, r.RemoteAddr, message)
+	if !strings.HasPrefix(r.URL.Path, "/api/") {
+		http.Error(w, message, statusCode)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+	}{Success: false, Message: message})
+}
+
+// randomToken returns 32 random bytes in URL-safe base64.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
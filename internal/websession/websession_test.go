@@ -0,0 +1,142 @@
+package websession
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/nileshnk/reddit-migrate/internal/config"
+)
+
+func TestMain(m *testing.M) {
+	config.InfoLogger = log.New(io.Discard, "", 0)
+	config.ErrorLogger = log.New(io.Discard, "", 0)
+	config.DebugLogger = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+// newTestGuard returns a guard for a server on localhost:5005 in front of a handler that answers 200.
+func newTestGuard(t *testing.T) (*Guard, http.Handler) {
+	t.Helper()
+	guard, err := NewGuard("localhost:5005", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return guard, guard.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+}
+
+// serve sends r through handler and returns the response.
+func serve(handler http.Handler, r *http.Request) *http.Response {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	return rec.Result()
+}
+
+// login opens the UI with the access token and returns the session and CSRF cookies.
+func login(t *testing.T, guard *Guard, handler http.Handler) (session, csrf *http.Cookie) {
+	t.Helper()
+	resp := serve(handler, httptest.NewRequest(http.MethodGet, guard.URL("http://localhost:5005"), nil))
+	if resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/" {
+		t.Fatalf("opening the UI with the token = %d to %q, want a redirect to /", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	for _, cookie := range resp.Cookies() {
+		switch cookie.Name {
+		case SessionCookie:
+			session = cookie
+		case CSRFCookie:
+			csrf = cookie
+		}
+	}
+	if session == nil || csrf == nil || !session.HttpOnly {
+		t.Fatalf("cookies after login = %v", resp.Cookies())
+	}
+	return session, csrf
+}
+
+func TestAccessToken(t *testing.T) {
+	guard, handler := newTestGuard(t)
+
+	r := httptest.NewRequest(http.MethodGet, "http://localhost:5005/api/jobs", nil)
+	if resp := serve(handler, r); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("request without session = %d, want 401", resp.StatusCode)
+	}
+	r = httptest.NewRequest(http.MethodGet, "http://localhost:5005/?token=wrong", nil)
+	if resp := serve(handler, r); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("request with a wrong token = %d, want 401", resp.StatusCode)
+	}
+
+	session, _ := login(t, guard, handler)
+	r = httptest.NewRequest(http.MethodGet, "http://localhost:5005/api/jobs", nil)
+	r.AddCookie(session)
+	if resp := serve(handler, r); resp.StatusCode != http.StatusOK {
+		t.Fatalf("request with session = %d, want 200", resp.StatusCode)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "http://localhost:5005/api/jobs", nil)
+	r.AddCookie(&http.Cookie{Name: SessionCookie, Value: "forged"})
+	if resp := serve(handler, r); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("request with an unknown session = %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestMutatingRequests(t *testing.T) {
+	guard, handler := newTestGuard(t)
+	session, csrf := login(t, guard, handler)
+
+	tests := []struct {
+		name   string
+		origin string
+		csrf   string
+		want   int
+	}{
+		{"same origin with CSRF token", "http://localhost:5005", csrf.Value, http.StatusOK},
+		{"missing CSRF token", "http://localhost:5005", "", http.StatusForbidden},
+		{"wrong CSRF token", "http://localhost:5005", "wrong", http.StatusForbidden},
+		{"other origin", "https://evil.example", csrf.Value, http.StatusForbidden},
+		{"other port", "http://localhost:8080", csrf.Value, http.StatusForbidden},
+		{"no origin", "", csrf.Value, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost:5005/api/migrate", nil)
+		r.AddCookie(session)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if tt.csrf != "" {
+			r.Header.Set(CSRFHeader, tt.csrf)
+		}
+		if resp := serve(handler, r); resp.StatusCode != tt.want {
+			t.Errorf("%s: POST = %d, want %d", tt.name, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestAllowedHosts(t *testing.T) {
+	// A wildcard address answers to the loopback names on any port and to the configured hosts, nothing else.
+	guard, err := NewGuard(":5005", []string{"nas.local"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := guard.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		host string
+		want int
+	}{
+		{"localhost:8080", http.StatusSeeOther},
+		{"127.0.0.1:5005", http.StatusSeeOther},
+		{"[::1]:5005", http.StatusSeeOther},
+		{"NAS.local:5005", http.StatusSeeOther},
+		{"rebound.evil.example:5005", http.StatusForbidden},
+		{"0.0.0.0:5005", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, guard.URL("http://"+tt.host), nil)
+		if resp := serve(handler, r); resp.StatusCode != tt.want {
+			t.Errorf("Host %s: opening the UI = %d, want %d", tt.host, resp.StatusCode, tt.want)
+		}
+	}
+}
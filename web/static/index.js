@@ -1,6 +1,21 @@
 // API Base URL Configuration
 const API_BASE_URL = "";
 
+// The server only accepts requests that change state when they carry the CSRF
+// token it set in a cookie when the page was opened with the access token, so
+// every such request sends it in the X-CSRF-Token header.
+const nativeFetch = window.fetch.bind(window);
+window.fetch = (resource, options = {}) => {
+  const method = (options.method || "GET").toUpperCase();
+  if (["GET", "HEAD", "OPTIONS"].includes(method)) {
+    return nativeFetch(resource, options);
+  }
+  const match = document.cookie.match(/(?:^|;\s*)csrf_token=([^;]*)/);
+  const headers = new Headers(options.headers || {});
+  headers.set("X-CSRF-Token", match ? decodeURIComponent(match[1]) : "");
+  return nativeFetch(resource, { ...options, headers });
+};
+
 let BOOL_OLD_TOKEN_VERIFIED = false;
 let BOOL_NEW_TOKEN_VERIFIED = false;
 let BOOL_MIGRATE_SUBREDDITS = false;
@@ -28,6 +43,7 @@ let SELECTED_SUBREDDITS = [];
 let SELECTED_POSTS = [];
 let ALL_SUBREDDITS = [];
 let ALL_POSTS = [];
+let ALL_MULTIREDDITS = [];
 
 let OLD_ACCESS_TOKEN = "";
 let NEW_ACCESS_TOKEN = "";
@@ -91,6 +107,7 @@ class DarkModeManager {
 const optionSubmit = document.getElementById("option-submit");
 const loadingBtn = document.getElementById("loading-btn");
 const migrateResponseBlock = document.getElementById("migrate-response-block");
+const migrateProgressBlock = document.getElementById("migrate-progress-block");
 const migrateResponseData = document.getElementById("migrate-response-data");
 
 // Selection Modal Management
@@ -292,7 +309,11 @@ class SelectionModal {
       const data = await response.json();
 
       if (data.success) {
-        ALL_POSTS = data.posts || []; // Ensure it's always an array
+        // Saved comments are listed alongside posts so they can be selected the same way,
+        // in the order they were saved in
+        ALL_POSTS = (data.posts || [])
+          .concat((data.comments || []).map(commentToPostItem))
+          .sort((a, b) => (a.index || 0) - (b.index || 0));
         filteredItems = [...ALL_POSTS];
         this.renderPosts();
       } else {
@@ -665,6 +686,8 @@ class SelectionModal {
         return "🖼️";
       case "link":
         return "🔗";
+      case "comment":
+        return "💬";
       default:
         return "📄";
     }
@@ -861,6 +884,37 @@ class SelectionModal {
 const selectionModal = new SelectionModal();
 
 // Helper functions
+
+// Convert a saved comment into the shape used for saved posts in the selection list
+function commentToPostItem(comment) {
+  return {
+    id: comment.id,
+    full_name: comment.full_name,
+    title: comment.link_title,
+    subreddit: comment.subreddit,
+    author: comment.author,
+    permalink: comment.permalink,
+    created_utc: comment.created_utc,
+    score: comment.score,
+    num_comments: 0,
+    domain: "saved comment",
+    selftext: comment.body,
+    over_18: comment.over_18,
+    spoiler: false,
+    index: comment.index,
+    is_comment: true,
+    image_data: { media_type: "comment" },
+  };
+}
+
+// The selected saved items in the order they were saved in, newest first, rather than the order they were selected
+// in. The server saves them in reverse so the new account lists them the same way.
+function selectedPostsInSavedOrder() {
+  return ALL_POSTS.map((post) => post.full_name).filter((name) =>
+    SELECTED_POSTS.includes(name)
+  );
+}
+
 function formatNumber(num) {
   if (num >= 1000000) return (num / 1000000).toFixed(1) + "M";
   if (num >= 1000) return (num / 1000).toFixed(1) + "K";
@@ -1056,6 +1110,151 @@ function toggleDeleteOptions(type, show) {
   }
 }
 
+// Custom feeds are few, so they are listed inline instead of in the selection modal.
+// The list is loaded when the checkbox is ticked and every feed starts out selected.
+const migrateMultiredditsCheckbox = document.getElementById(
+  "migrateMultiredditsCheckbox"
+);
+const multiredditList = document.getElementById("multiredditList");
+const multiredditStatus = document.getElementById("multiredditStatus");
+
+migrateMultiredditsCheckbox.addEventListener("change", async (e) => {
+  multiredditList.innerHTML = "";
+  multiredditList.classList.add("hidden");
+  multiredditStatus.classList.add("hidden");
+  ALL_MULTIREDDITS = [];
+  if (!e.target.checked) return;
+
+  if (!isSourceAccountVerified()) {
+    alert("Please verify your source account first");
+    e.target.checked = false;
+    return;
+  }
+
+  multiredditStatus.textContent = "Loading custom feeds...";
+  multiredditStatus.classList.remove("hidden");
+  try {
+    const response = await fetch(`${API_BASE_URL}/api/multireddits`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(getAuthRequestBody()),
+    });
+    if (!response.ok) {
+      throw new Error(await response.text());
+    }
+    const data = await response.json();
+    ALL_MULTIREDDITS = data.multireddits || [];
+  } catch (error) {
+    console.error("Failed to load custom feeds:", error);
+    multiredditStatus.textContent = `Failed to load custom feeds: ${error.message}`;
+    e.target.checked = false;
+    return;
+  }
+
+  if (ALL_MULTIREDDITS.length === 0) {
+    multiredditStatus.textContent = "The source account has no custom feeds.";
+    return;
+  }
+  multiredditStatus.classList.add("hidden");
+  ALL_MULTIREDDITS.forEach((multi) => {
+    const item = document.createElement("li");
+    item.className = "flex items-center bg-slate-700/30 rounded-lg p-2";
+    item.innerHTML = `
+      <input type="checkbox" class="multireddit-checkbox w-4 h-4 accent-red-500" checked />
+      <span class="ml-3 text-sm text-slate-300"></span>
+    `;
+    item.querySelector("input").value = multi.name;
+    item.querySelector("span").textContent = `${
+      multi.display_name || multi.name
+    } (${(multi.subreddits || []).length} subreddits, ${multi.visibility})`;
+    multiredditList.appendChild(item);
+  });
+  multiredditList.classList.remove("hidden");
+});
+
+// Names of the custom feeds ticked in the inline list; empty when feeds are not being migrated
+function getSelectedMultireddits() {
+  if (!migrateMultiredditsCheckbox.checked) return [];
+  return Array.from(
+    multiredditList.querySelectorAll(".multireddit-checkbox:checked")
+  ).map((checkbox) => checkbox.value);
+}
+
+// Preferences are listed inline like custom feeds. Keys in the server's default allowlist start out ticked.
+const migratePrefsCheckbox = document.getElementById("migratePrefsCheckbox");
+const prefsList = document.getElementById("prefsList");
+const prefsStatus = document.getElementById("prefsStatus");
+
+migratePrefsCheckbox.addEventListener("change", async (e) => {
+  prefsList.innerHTML = "";
+  prefsList.classList.add("hidden");
+  prefsStatus.classList.add("hidden");
+  if (!e.target.checked) return;
+
+  if (!isSourceAccountVerified()) {
+    alert("Please verify your source account first");
+    e.target.checked = false;
+    return;
+  }
+
+  prefsStatus.textContent = "Loading preferences...";
+  prefsStatus.classList.remove("hidden");
+  let data;
+  try {
+    const response = await fetch(`${API_BASE_URL}/api/prefs`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(getAuthRequestBody()),
+    });
+    if (!response.ok) {
+      throw new Error(await response.text());
+    }
+    data = await response.json();
+  } catch (error) {
+    console.error("Failed to load preferences:", error);
+    prefsStatus.textContent = `Failed to load preferences: ${error.message}`;
+    e.target.checked = false;
+    return;
+  }
+
+  prefsStatus.classList.add("hidden");
+  const defaults = new Set(data.default_keys || []);
+  Object.keys(data.prefs || {})
+    .sort()
+    .forEach((key) => {
+      const item = document.createElement("li");
+      item.className = "flex items-center text-sm text-slate-300";
+      item.innerHTML = `
+        <input type="checkbox" class="pref-checkbox w-4 h-4 accent-red-500" />
+        <span class="ml-3 font-mono"></span>
+        <span class="ml-2 text-xs text-slate-400"></span>
+      `;
+      const checkbox = item.querySelector("input");
+      checkbox.value = key;
+      checkbox.checked = defaults.has(key);
+      const [keyLabel, valueLabel] = item.querySelectorAll("span");
+      keyLabel.textContent = key;
+      valueLabel.textContent = JSON.stringify(data.prefs[key]);
+      prefsList.appendChild(item);
+    });
+  prefsList.classList.remove("hidden");
+});
+
+// Preference keys ticked in the inline list; empty when preferences are not being migrated
+function getSelectedPrefKeys() {
+  if (!migratePrefsCheckbox.checked) return [];
+  return Array.from(prefsList.querySelectorAll(".pref-checkbox:checked")).map(
+    (checkbox) => checkbox.value
+  );
+}
+
+// Format one entry of a preference diff as "key: before → after"
+function formatPrefChange(change) {
+  return `${change.key}: ${JSON.stringify(change.before)} → ${JSON.stringify(
+    change.after
+  )}`;
+}
+
 // Event listeners for selection radio buttons
 document
   .querySelectorAll('input[name="subredditSelection"]')
@@ -1191,6 +1390,23 @@ optionSubmit.addEventListener("click", async (e) => {
     "deleteSubredditsYes"
   ).checked;
   const deletePosts = document.getElementById("deleteSavedPostsYes").checked;
+  const dryRun = document.getElementById("dryRunCheckbox").checked;
+  const migrateUpvotes = document.getElementById(
+    "migrateUpvotesCheckbox"
+  ).checked;
+  const migrateDownvotes = document.getElementById(
+    "migrateDownvotesCheckbox"
+  ).checked;
+  const migrateHidden = document.getElementById("migrateHiddenCheckbox").checked;
+  const unhideOld = document.getElementById("unhideOldCheckbox").checked;
+  const selectedMultireddits = getSelectedMultireddits();
+  const migrateBlocked = document.getElementById(
+    "migrateBlockedCheckbox"
+  ).checked;
+  const prefKeys = getSelectedPrefKeys();
+  const preserveOrder = document.getElementById(
+    "preserveOrderCheckbox"
+  ).checked;
 
   let requestBody;
   let endpoint;
@@ -1208,9 +1424,20 @@ optionSubmit.addEventListener("click", async (e) => {
         new_account_username: DEST_USERNAME,
         selected_subreddits:
           SUBREDDIT_SELECTION === "custom" ? SELECTED_SUBREDDITS : [],
-        selected_posts: POSTS_SELECTION === "custom" ? SELECTED_POSTS : [],
+        selected_posts:
+          POSTS_SELECTION === "custom" ? selectedPostsInSavedOrder() : [],
         delete_old_subreddits: deleteSubreddits,
         delete_old_posts: deletePosts,
+        migrate_upvotes: migrateUpvotes,
+        migrate_downvotes: migrateDownvotes,
+        migrate_hidden: migrateHidden,
+        unhide_old: unhideOld,
+        selected_multireddits: selectedMultireddits,
+        migrate_blocked: migrateBlocked,
+        migrate_prefs: prefKeys.length > 0,
+        pref_keys: prefKeys,
+        preserve_order: preserveOrder,
+        dry_run: dryRun,
       };
     } else {
       requestBody = {
@@ -1219,9 +1446,20 @@ optionSubmit.addEventListener("click", async (e) => {
         new_account_cookie: NEW_ACCESS_TOKEN,
         selected_subreddits:
           SUBREDDIT_SELECTION === "custom" ? SELECTED_SUBREDDITS : [],
-        selected_posts: POSTS_SELECTION === "custom" ? SELECTED_POSTS : [],
+        selected_posts:
+          POSTS_SELECTION === "custom" ? selectedPostsInSavedOrder() : [],
         delete_old_subreddits: deleteSubreddits,
         delete_old_posts: deletePosts,
+        migrate_upvotes: migrateUpvotes,
+        migrate_downvotes: migrateDownvotes,
+        migrate_hidden: migrateHidden,
+        unhide_old: unhideOld,
+        selected_multireddits: selectedMultireddits,
+        migrate_blocked: migrateBlocked,
+        migrate_prefs: prefKeys.length > 0,
+        pref_keys: prefKeys,
+        preserve_order: preserveOrder,
+        dry_run: dryRun,
       };
     }
   } else {
@@ -1239,6 +1477,17 @@ optionSubmit.addEventListener("click", async (e) => {
           migrate_post_bool: POSTS_SELECTION === "all",
           delete_post_bool: deletePosts,
           delete_subreddit_bool: deleteSubreddits,
+          migrate_upvotes_bool: migrateUpvotes,
+          migrate_downvotes_bool: migrateDownvotes,
+          migrate_hidden_bool: migrateHidden,
+          unhide_old_bool: unhideOld,
+          migrate_multireddits_bool: selectedMultireddits.length > 0,
+          selected_multireddits: selectedMultireddits,
+          migrate_blocked_bool: migrateBlocked,
+          migrate_prefs_bool: prefKeys.length > 0,
+          pref_keys: prefKeys,
+          preserve_order_bool: preserveOrder,
+          dry_run: dryRun,
         },
       };
     } else {
@@ -1251,6 +1500,17 @@ optionSubmit.addEventListener("click", async (e) => {
           migrate_post_bool: POSTS_SELECTION === "all",
           delete_post_bool: deletePosts,
           delete_subreddit_bool: deleteSubreddits,
+          migrate_upvotes_bool: migrateUpvotes,
+          migrate_downvotes_bool: migrateDownvotes,
+          migrate_hidden_bool: migrateHidden,
+          unhide_old_bool: unhideOld,
+          migrate_multireddits_bool: selectedMultireddits.length > 0,
+          selected_multireddits: selectedMultireddits,
+          migrate_blocked_bool: migrateBlocked,
+          migrate_prefs_bool: prefKeys.length > 0,
+          pref_keys: prefKeys,
+          preserve_order_bool: preserveOrder,
+          dry_run: dryRun,
         },
       };
     }
@@ -1273,14 +1533,20 @@ optionSubmit.addEventListener("click", async (e) => {
     const response = await migrateResponse.json();
     console.log(response);
 
-    if (migrateResponse.status === 200) {
-      displayMigrationResponse(response);
-    } else {
+    if (migrateResponse.status !== 202 || !response.job) {
       throw new Error(response.message || "Migration failed");
     }
+
+    resetMigrationProgress();
+    const finishedJob = await waitForJob(response.job.id);
+    if (!finishedJob.result) {
+      throw new Error(`Migration job ${finishedJob.status}`);
+    }
+    displayMigrationResponse(finishedJob.result);
   } catch (error) {
     console.error("Migration error:", error);
     alert("Migration failed: " + error.message);
+    migrateProgressBlock.style.display = "none";
 
     // Re-enable form
     optionSubmit.style.display = "block";
@@ -1297,8 +1563,482 @@ optionSubmit.addEventListener("click", async (e) => {
   }
 });
 
+// Export the source account as a JSON archive and download it
+const exportBtn = document.getElementById("export-btn");
+exportBtn.addEventListener("click", async (e) => {
+  e.preventDefault();
+  const exportError = document.getElementById("export-error");
+  exportError.classList.add("hidden");
+
+  if (!isSourceAccountVerified()) {
+    alert("Please verify the source account first");
+    return;
+  }
+
+  exportBtn.disabled = true;
+  exportBtn.classList.add("cursor-not-allowed", "opacity-50");
+  try {
+    const response = await fetch(`${API_BASE_URL}/api/export`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(getAuthRequestBody()),
+    });
+    if (!response.ok) {
+      throw new Error((await response.text()) || response.statusText);
+    }
+
+    const disposition = response.headers.get("Content-Disposition") || "";
+    const match = disposition.match(/filename="([^"]+)"/);
+    const url = URL.createObjectURL(await response.blob());
+    const link = document.createElement("a");
+    link.href = url;
+    link.download = match ? match[1] : "reddit-export.json";
+    document.body.appendChild(link);
+    link.click();
+    link.remove();
+    URL.revokeObjectURL(url);
+  } catch (error) {
+    console.error("Export failed:", error);
+    exportError.textContent = "Export failed: " + error.message;
+    exportError.classList.remove("hidden");
+  } finally {
+    exportBtn.disabled = false;
+    exportBtn.classList.remove("cursor-not-allowed", "opacity-50");
+  }
+});
+
+// Import a previously exported archive into the destination account
+const importBtn = document.getElementById("import-btn");
+importBtn.addEventListener("click", async (e) => {
+  e.preventDefault();
+  const file = document.getElementById("import-file").files[0];
+  if (!file) {
+    alert("Please choose an exported archive first");
+    return;
+  }
+  if (!isDestAccountVerified()) {
+    alert("Please verify the destination account first");
+    return;
+  }
+
+  let archive;
+  try {
+    archive = JSON.parse(await file.text());
+  } catch (error) {
+    alert("The selected file is not a valid archive: " + error.message);
+    return;
+  }
+
+  const requestBody = {
+    archive,
+    selected_subreddits: (archive.subreddits || []).map((s) => s.display_name),
+    selected_users: archive.followed_users || [],
+    selected_posts: (archive.saved_posts || [])
+      .concat(archive.saved_comments || [])
+      .sort((a, b) => (a.index || 0) - (b.index || 0))
+      .map((p) => p.full_name),
+    selected_multireddits: (archive.multireddits || []).map((m) => m.name),
+    preserve_order: document.getElementById("preserveOrderCheckbox").checked,
+    dry_run: document.getElementById("dryRunCheckbox").checked,
+  };
+  if (CURRENT_AUTH_METHOD === "oauth") {
+    requestBody.auth_method = "oauth";
+    requestBody.access_token = DEST_ACCESS_TOKEN;
+    requestBody.username = DEST_USERNAME;
+  } else {
+    requestBody.auth_method = "cookie";
+    requestBody.cookie = getDestAccessToken();
+  }
+
+  importBtn.disabled = true;
+  importBtn.classList.add("cursor-not-allowed", "opacity-50");
+  migrateResponseBlock.style.display = "none";
+  try {
+    const importResponse = await fetch(`${API_BASE_URL}/api/import`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(requestBody),
+    });
+    const response = await importResponse.json();
+    if (importResponse.status !== 202 || !response.job) {
+      throw new Error(response.message || "Import failed");
+    }
+
+    resetMigrationProgress();
+    const finishedJob = await waitForJob(response.job.id);
+    if (!finishedJob.result) {
+      throw new Error(`Import job ${finishedJob.status}`);
+    }
+    displayMigrationResponse(finishedJob.result);
+  } catch (error) {
+    console.error("Import error:", error);
+    alert("Import failed: " + error.message);
+    migrateProgressBlock.style.display = "none";
+  } finally {
+    importBtn.disabled = false;
+    importBtn.classList.remove("cursor-not-allowed", "opacity-50");
+  }
+});
+
+// Saved profiles: an encrypted vault of accounts that can be used instead of authenticating again
+const vaultError = document.getElementById("vault-error");
+
+function showVaultError(message) {
+  vaultError.textContent = message;
+  vaultError.classList.toggle("hidden", !message);
+}
+
+function renderVaultStatus(status) {
+  document
+    .getElementById("vault-locked")
+    .classList.toggle("hidden", status.unlocked);
+  document
+    .getElementById("vault-unlocked")
+    .classList.toggle("hidden", !status.unlocked);
+
+  for (const id of ["vault-source-profile", "vault-dest-profile"]) {
+    const select = document.getElementById(id);
+    select.innerHTML = "";
+    if (status.profiles.length === 0) {
+      select.add(new Option("No saved profiles", ""));
+    }
+    for (const profile of status.profiles) {
+      select.add(
+        new Option(
+          `${profile.name} (u/${profile.username}, ${profile.auth_method})`,
+          profile.name
+        )
+      );
+    }
+  }
+}
+
+async function vaultRequest(path, method = "GET", body = undefined) {
+  const response = await fetch(`${API_BASE_URL}/api/vault${path}`, {
+    method,
+    headers: body ? { "Content-Type": "application/json" } : {},
+    body: body ? JSON.stringify(body) : undefined,
+  });
+  const data = await response.json();
+  if (!response.ok || !data.success) {
+    throw new Error(data.message || response.statusText);
+  }
+  return data;
+}
+
+async function refreshVaultStatus() {
+  try {
+    renderVaultStatus(await vaultRequest(""));
+  } catch (error) {
+    console.error("Failed to fetch vault status:", error);
+  }
+}
+
+document
+  .getElementById("vault-unlock-btn")
+  .addEventListener("click", async (e) => {
+    e.preventDefault();
+    const passphrase = document.getElementById("vault-passphrase");
+    try {
+      renderVaultStatus(
+        await vaultRequest("/unlock", "POST", { passphrase: passphrase.value })
+      );
+      passphrase.value = "";
+      showVaultError("");
+    } catch (error) {
+      showVaultError("Could not unlock the vault: " + error.message);
+    }
+  });
+
+document.getElementById("vault-lock-btn").addEventListener("click", async (e) => {
+  e.preventDefault();
+  try {
+    renderVaultStatus(await vaultRequest("/lock", "POST"));
+    showVaultError("");
+  } catch (error) {
+    showVaultError("Could not lock the vault: " + error.message);
+  }
+});
+
+// Logging in with a profile yields an OAuth access token, so the page switches to OAuth mode like after the OAuth flow.
+async function useVaultProfile(type) {
+  const name = document.getElementById(`vault-${type}-profile`).value;
+  if (!name) {
+    return;
+  }
+  try {
+    const data = await vaultRequest(
+      `/profiles/${encodeURIComponent(name)}/login`,
+      "POST"
+    );
+    oauthModalManager.handleModalAuthSuccess(
+      type,
+      data.username,
+      data.access_token
+    );
+    showVaultError("");
+  } catch (error) {
+    showVaultError(`Could not use profile ${name}: ` + error.message);
+  }
+}
+
+// OAuth accounts are saved by their refresh token, cookie accounts by their cookie.
+async function saveVaultProfile(type) {
+  const verified =
+    type === "source" ? isSourceAccountVerified() : isDestAccountVerified();
+  if (!verified) {
+    const label = type === "source" ? "source" : "destination";
+    alert(`Please verify the ${label} account first`);
+    return;
+  }
+  const name = prompt("Profile name (letters, digits, '.', '_' or '-'):");
+  if (!name) {
+    return;
+  }
+
+  const body = { name };
+  if (CURRENT_AUTH_METHOD === "oauth") {
+    body.access_token =
+      type === "source" ? SOURCE_ACCESS_TOKEN : DEST_ACCESS_TOKEN;
+  } else {
+    body.cookie = type === "source" ? OLD_ACCESS_TOKEN : NEW_ACCESS_TOKEN;
+  }
+  try {
+    renderVaultStatus(await vaultRequest("/profiles", "POST", body));
+    showVaultError("");
+  } catch (error) {
+    showVaultError("Could not save profile: " + error.message);
+  }
+}
+
+document
+  .getElementById("vault-use-source-btn")
+  .addEventListener("click", (e) => {
+    e.preventDefault();
+    useVaultProfile("source");
+  });
+document.getElementById("vault-use-dest-btn").addEventListener("click", (e) => {
+  e.preventDefault();
+  useVaultProfile("dest");
+});
+document
+  .getElementById("vault-save-source-btn")
+  .addEventListener("click", (e) => {
+    e.preventDefault();
+    saveVaultProfile("source");
+  });
+document
+  .getElementById("vault-save-dest-btn")
+  .addEventListener("click", (e) => {
+    e.preventDefault();
+    saveVaultProfile("dest");
+  });
+
+const PHASE_LABELS = {
+  fetch_subreddits: "Fetching subreddits...",
+  subscribe_subreddits: "Subscribing to subreddits",
+  follow_users: "Following users",
+  unsubscribe_subreddits: "Unsubscribing from old subreddits",
+  fetch_posts: "Fetching saved posts...",
+  save_posts: "Saving posts",
+  unsave_posts: "Unsaving posts from old account",
+  fetch_votes: "Fetching votes...",
+  upvote_posts: "Upvoting posts",
+  downvote_posts: "Downvoting posts",
+  fetch_hidden: "Fetching hidden posts...",
+  hide_posts: "Hiding posts",
+  unhide_posts: "Unhiding posts on old account",
+  fetch_multireddits: "Fetching custom feeds...",
+  copy_multireddits: "Copying custom feeds",
+  fetch_blocked: "Fetching blocked users...",
+  block_users: "Blocking users",
+  fetch_prefs: "Fetching preferences...",
+  copy_prefs: "Updating preferences",
+};
+
+// Reset and show the live progress block for a new migration
+function resetMigrationProgress() {
+  document.getElementById("migrate-progress-phase").textContent =
+    "Starting migration...";
+  document.getElementById("migrate-progress-count").textContent = "";
+  document.getElementById("migrate-progress-bar").style.width = "0%";
+  document.getElementById("migrate-progress-ratelimit").style.display = "none";
+  const failures = document.getElementById("migrate-progress-failures");
+  failures.innerHTML = "";
+  failures.style.display = "none";
+  migrateProgressBlock.style.display = "block";
+}
+
+// Update the progress block from a single job progress event
+function handleProgressEvent(event) {
+  const phaseLabel = document.getElementById("migrate-progress-phase");
+  const countLabel = document.getElementById("migrate-progress-count");
+  const bar = document.getElementById("migrate-progress-bar");
+  const rateLimit = document.getElementById("migrate-progress-ratelimit");
+
+  switch (event.type) {
+    case "phase":
+      phaseLabel.textContent = PHASE_LABELS[event.phase] || event.phase;
+      countLabel.textContent = event.total ? `0 / ${event.total}` : "";
+      bar.style.width = "0%";
+      break;
+    case "post":
+    case "user":
+    case "subreddit_chunk":
+      if (event.total) {
+        countLabel.textContent = `${event.completed} / ${event.total}`;
+        bar.style.width = `${Math.round((event.completed / event.total) * 100)}%`;
+      }
+      if (event.type === "subreddit_chunk") {
+        (event.failed_items || []).forEach((item) =>
+          addProgressFailure(`r/${item}`, event.action, "")
+        );
+      } else if (!event.success) {
+        addProgressFailure(event.item, event.action, event.error);
+      }
+      break;
+    case "rate_limit_pause":
+      document.getElementById("migrate-progress-ratelimit-text").textContent =
+        event.message || "Rate limited by Reddit, waiting...";
+      rateLimit.style.display = "flex";
+      break;
+    case "rate_limit_resume":
+      rateLimit.style.display = "none";
+      break;
+  }
+}
+
+function addProgressFailure(item, action, error) {
+  const failures = document.getElementById("migrate-progress-failures");
+  const entry = document.createElement("li");
+  entry.textContent = `Failed to ${action} ${item}${error ? `: ${error}` : ""}`;
+  failures.appendChild(entry);
+  failures.style.display = "block";
+}
+
+// Follow a background migration job until it finishes.
+// Progress is streamed over Server-Sent Events; polling is used if the stream is unavailable.
+async function waitForJob(jobId) {
+  if (window.EventSource) {
+    const streamed = await new Promise((resolve) => {
+      const source = new EventSource(`${API_BASE_URL}/api/jobs/${jobId}/events`);
+      const eventTypes = [
+        "phase",
+        "subreddit_chunk",
+        "user",
+        "post",
+        "rate_limit_pause",
+        "rate_limit_resume",
+      ];
+      eventTypes.forEach((type) =>
+        source.addEventListener(type, (e) =>
+          handleProgressEvent(JSON.parse(e.data))
+        )
+      );
+      source.addEventListener("done", () => {
+        source.close();
+        resolve(true);
+      });
+      source.onerror = () => {
+        if (source.readyState === EventSource.CLOSED) {
+          resolve(false);
+        }
+      };
+    });
+    if (!streamed) {
+      console.warn("Progress stream unavailable, falling back to polling");
+    }
+  }
+  return pollJob(jobId);
+}
+
+// Poll a background migration job until it reaches a terminal state
+async function pollJob(jobId) {
+  const terminalStates = ["completed", "failed", "cancelled"];
+  while (true) {
+    const jobResponse = await fetch(`${API_BASE_URL}/api/jobs/${jobId}`);
+    const data = await jobResponse.json();
+    if (!jobResponse.ok) {
+      throw new Error(data.message || "Failed to fetch migration status");
+    }
+    if (terminalStates.includes(data.job.status)) {
+      return data.job;
+    }
+    await new Promise((resolve) => setTimeout(resolve, 2000));
+  }
+}
+
+// Render the plan returned by a dry run: one row per planned change with its count
+function displayMigrationPlan(plan) {
+  migrateResponseData.innerHTML = "";
+
+  const rows = [
+    ["Subreddits to subscribe", plan.subreddits_to_subscribe],
+    ["Subreddits already present", plan.subreddits_already_present],
+    ["Subreddits to unsubscribe from old account", plan.subreddits_to_unsubscribe],
+    ["Users to follow", plan.users_to_follow],
+    ["Users already followed", plan.users_already_followed],
+    ["Posts to save", plan.posts_to_save],
+    ["Posts already saved", plan.posts_already_saved],
+    ["Posts to unsave from old account", plan.posts_to_unsave],
+    ["Posts to upvote", plan.posts_to_upvote],
+    ["Posts already upvoted", plan.posts_already_upvoted],
+    ["Posts to downvote", plan.posts_to_downvote],
+    ["Posts already downvoted", plan.posts_already_downvoted],
+    ["Posts to hide", plan.posts_to_hide],
+    ["Posts already hidden", plan.posts_already_hidden],
+    ["Posts to unhide on old account", plan.posts_to_unhide],
+    ["Custom feeds to create", plan.multireddits_to_create],
+    ["Custom feeds to merge", plan.multireddits_to_merge],
+    ["Custom feeds already present", plan.multireddits_already_present],
+    ["Users to block", plan.users_to_block],
+    ["Users already blocked", plan.users_already_blocked],
+    ["Preferences to change", (plan.pref_changes || []).map(formatPrefChange)],
+    ["Preferences unchanged", plan.prefs_unchanged],
+  ];
+
+  rows.forEach(([label, items]) => {
+    const list = items || [];
+    const rowElement = document.createElement("li");
+    rowElement.className =
+      "p-3 bg-sky-900/20 rounded-lg border border-sky-500/20";
+    rowElement.innerHTML = `
+      <details>
+        <summary class="flex items-center space-x-3 cursor-pointer">
+          <span class="material-icons text-sky-400">visibility</span>
+          <span class="text-sm font-medium text-slate-300">
+            ${label}: <span class="text-sky-400 font-bold">${list.length}</span>
+          </span>
+        </summary>
+        <div class="mt-2 ml-9 text-xs text-slate-400 max-h-40 overflow-y-auto"></div>
+      </details>
+    `;
+    rowElement.querySelector("div").textContent = list.join(", ");
+    migrateResponseData.appendChild(rowElement);
+  });
+
+  optionSubmit.style.display = "block";
+  loadingBtn.style.display = "none";
+  migrateResponseBlock.style.display = "block";
+  migrateResponseBlock.scrollIntoView({ behavior: "smooth" });
+}
+
+// Add a note to a result row whose phase Reddit refused for lack of the "account" OAuth scope
+function appendMissingScopeNote(statusElement) {
+  const note = document.createElement("span");
+  note.className = "text-xs text-amber-400";
+  note.textContent =
+    "Reddit refused this: the destination account was connected without the account permission. Connect it again with OAuth and rerun the migration.";
+  statusElement.appendChild(note);
+}
+
 // Keep original functions (simplified)
 function displayMigrationResponse(response) {
+  if (response.data && response.data.plan) {
+    displayMigrationPlan(response.data.plan);
+    return;
+  }
+
   // Clear previous response data
   migrateResponseData.innerHTML = "";
 
@@ -1309,6 +2049,17 @@ function displayMigrationResponse(response) {
   const migratingPosts =
     POSTS_SELECTION === "all" ||
     (POSTS_SELECTION === "custom" && SELECTED_POSTS.length > 0);
+  const migratingVotes =
+    document.getElementById("migrateUpvotesCheckbox").checked ||
+    document.getElementById("migrateDownvotesCheckbox").checked;
+  const migratingHidden =
+    document.getElementById("migrateHiddenCheckbox").checked ||
+    document.getElementById("unhideOldCheckbox").checked;
+  const migratingMultireddits = getSelectedMultireddits().length > 0;
+  const migratingBlocked = document.getElementById(
+    "migrateBlockedCheckbox"
+  ).checked;
+  const migratingPrefs = getSelectedPrefKeys().length > 0;
 
   // Create subreddit status if subreddits were migrated
   if (migratingSubreddits && response.data.subscribeSubreddit) {
@@ -1340,8 +2091,112 @@ function displayMigrationResponse(response) {
     migrateResponseData.appendChild(postStatusElement);
   }
 
+  // Create vote status if votes were migrated
+  if (migratingVotes && response.data.upvotePost && response.data.downvotePost) {
+    const voteStatusElement = document.createElement("li");
+    voteStatusElement.className =
+      "flex items-center space-x-3 p-3 bg-emerald-900/20 rounded-lg border border-emerald-500/20";
+    voteStatusElement.innerHTML = `
+      <span class="material-icons text-emerald-400">check_circle</span>
+      <span class="text-sm font-medium text-slate-300">
+        Votes cast on new account: 
+        <span class="text-emerald-400 font-bold">${response.data.upvotePost.SuccessCount}</span> up,
+        <span class="text-emerald-400 font-bold">${response.data.downvotePost.SuccessCount}</span> down
+      </span>
+    `;
+    migrateResponseData.appendChild(voteStatusElement);
+  }
+
+  // Create hidden post status if hidden posts were migrated
+  if (migratingHidden && response.data.hidePost && response.data.unhidePost) {
+    const hiddenStatusElement = document.createElement("li");
+    hiddenStatusElement.className =
+      "flex items-center space-x-3 p-3 bg-emerald-900/20 rounded-lg border border-emerald-500/20";
+    hiddenStatusElement.innerHTML = `
+      <span class="material-icons text-emerald-400">check_circle</span>
+      <span class="text-sm font-medium text-slate-300">
+        Posts hidden on new account: 
+        <span class="text-emerald-400 font-bold">${response.data.hidePost.SuccessCount}</span>,
+        unhidden on old account:
+        <span class="text-emerald-400 font-bold">${response.data.unhidePost.SuccessCount}</span>
+      </span>
+    `;
+    migrateResponseData.appendChild(hiddenStatusElement);
+  }
+
+  // Create custom feed status if custom feeds were migrated
+  if (migratingMultireddits && response.data.multireddits) {
+    const multiredditStatusElement = document.createElement("li");
+    multiredditStatusElement.className =
+      "flex items-center space-x-3 p-3 bg-emerald-900/20 rounded-lg border border-emerald-500/20";
+    multiredditStatusElement.innerHTML = `
+      <span class="material-icons text-emerald-400">check_circle</span>
+      <span class="text-sm font-medium text-slate-300">
+        Custom feeds created on new account: 
+        <span class="text-emerald-400 font-bold">${response.data.multireddits.CreatedCount}</span>,
+        merged:
+        <span class="text-emerald-400 font-bold">${response.data.multireddits.MergedCount}</span>
+      </span>
+    `;
+    migrateResponseData.appendChild(multiredditStatusElement);
+  }
+
+  // Create blocked user status if blocked users were migrated
+  if (migratingBlocked && response.data.blockUser) {
+    const blockedStatusElement = document.createElement("li");
+    blockedStatusElement.className =
+      "flex items-center space-x-3 p-3 bg-emerald-900/20 rounded-lg border border-emerald-500/20";
+    blockedStatusElement.innerHTML = `
+      <span class="material-icons text-emerald-400">check_circle</span>
+      <span class="text-sm font-medium text-slate-300">
+        Users blocked on new account: 
+        <span class="text-emerald-400 font-bold">${response.data.blockUser.SuccessCount}</span>
+        (failed: ${response.data.blockUser.FailedCount})
+      </span>
+    `;
+    if (response.data.blockUser.StatusCode === 403) {
+      appendMissingScopeNote(blockedStatusElement);
+    }
+    migrateResponseData.appendChild(blockedStatusElement);
+  }
+
+  // Create preference status if preferences were migrated, listing each change
+  if (migratingPrefs && response.data.prefs) {
+    const changes = response.data.prefs.Changes || [];
+    const prefsStatusElement = document.createElement("li");
+    prefsStatusElement.className =
+      "p-3 bg-emerald-900/20 rounded-lg border border-emerald-500/20";
+    prefsStatusElement.innerHTML = `
+      <details>
+        <summary class="flex items-center space-x-3 cursor-pointer">
+          <span class="material-icons text-emerald-400">check_circle</span>
+          <span class="text-sm font-medium text-slate-300">
+            Preferences changed on new account: 
+            <span class="text-emerald-400 font-bold">${changes.length}</span>
+          </span>
+        </summary>
+        <div class="mt-2 ml-9 text-xs text-slate-400 max-h-40 overflow-y-auto"></div>
+      </details>
+    `;
+    prefsStatusElement.querySelector("div").textContent = changes
+      .map(formatPrefChange)
+      .join(", ");
+    if (response.data.prefs.StatusCode === 403) {
+      appendMissingScopeNote(prefsStatusElement);
+    }
+    migrateResponseData.appendChild(prefsStatusElement);
+  }
+
   // If nothing was migrated, show a message
-  if (!migratingSubreddits && !migratingPosts) {
+  if (
+    !migratingSubreddits &&
+    !migratingPosts &&
+    !migratingVotes &&
+    !migratingHidden &&
+    !migratingMultireddits &&
+    !migratingBlocked &&
+    !migratingPrefs
+  ) {
     const noMigrationElement = document.createElement("li");
     noMigrationElement.className =
       "flex items-center space-x-3 p-3 bg-amber-900/20 rounded-lg border border-amber-500/20";
@@ -1467,6 +2322,46 @@ newTokenVerifyBtn.addEventListener("click", async (e) => {
   }
 });
 
+// Import the reddit.com cookies from a browser cookie export, then verify them like a pasted cookie
+async function importCookieFile(who) {
+  const fileInput = document.getElementById(`${who}CookieFile`);
+  const cookieInput = document.getElementById(`${who}AccessToken`);
+  const error = document.getElementById(`${who}CookieFileError`);
+  const file = fileInput.files[0];
+  error.classList.add("hidden");
+  if (!file || cookieInput.disabled) {
+    return;
+  }
+
+  const form = new FormData();
+  form.append("file", file);
+  try {
+    const response = await fetch(`${API_BASE_URL}/api/import-cookies`, {
+      method: "POST",
+      body: form,
+    });
+    const body = await response.json();
+    if (!response.ok || !body.success) {
+      throw new Error(body.message || response.statusText);
+    }
+    cookieInput.value = body.cookie;
+    document.getElementById(`${who}TokenVerifyBtn`).click();
+  } catch (err) {
+    console.error("Cookie import failed:", err);
+    error.textContent = "Could not import cookies: " + err.message;
+    error.classList.remove("hidden");
+  } finally {
+    fileInput.value = "";
+  }
+}
+
+document
+  .getElementById("oldCookieFile")
+  .addEventListener("change", () => importCookieFile("old"));
+document
+  .getElementById("newCookieFile")
+  .addEventListener("change", () => importCookieFile("new"));
+
 async function verifyCookie(cookie) {
   const cookieData = getCookieObject(cookie);
   if (cookieData.token_v2 === undefined) {
@@ -1655,6 +2550,8 @@ class TabManager {
 }
 
 // OAuth Modal Management
+let oauthModalManager = null;
+
 class OAuthModalManager {
   constructor() {
     this.init();
@@ -1732,8 +2629,9 @@ class OAuthModalManager {
       .getElementById(`${type}ModalClientSecret`)
       .value.trim();
 
-    if (!clientId || !clientSecret) {
-      alert("Please enter both Client ID and Client Secret");
+    // Installed apps have no secret; the server protects their code exchange with PKCE.
+    if (!clientId) {
+      alert("Please enter the Client ID");
       return;
     }
 
@@ -1962,8 +2860,10 @@ document.addEventListener("DOMContentLoaded", () => {
     new TabManager();
     console.log("TabManager initialized");
 
-    new OAuthModalManager();
+    oauthModalManager = new OAuthModalManager();
     console.log("OAuthModalManager initialized");
+
+    refreshVaultStatus();
   } catch (error) {
     console.error("Error initializing managers:", error);
   }